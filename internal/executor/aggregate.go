@@ -0,0 +1,295 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// aggregator accumulates the non-NULL values of an aggregate function
+// argument over the rows of a query.
+type aggregator interface {
+	add(v storage.Value) error
+	result() (storage.Value, error)
+}
+
+// aggregateFunctions maps each aggregate function to a constructor of its
+// accumulator.
+var aggregateFunctions = map[string]func() aggregator{
+	"COUNT": func() aggregator { return &countAggregate{} },
+	"SUM":   func() aggregator { return &sumAggregate{} },
+	"AVG":   func() aggregator { return &sumAggregate{avg: true} },
+	"MIN":   func() aggregator { return &extremumAggregate{sign: -1} },
+	"MAX":   func() aggregator { return &extremumAggregate{sign: 1} },
+}
+
+func isAggregate(call *parser.FunctionCall) bool {
+	_, ok := aggregateFunctions[call.Name]
+	return ok
+}
+
+// containsAggregate reports whether expr calls an aggregate function.
+func containsAggregate(expr parser.Expression) bool {
+	found := false
+	parser.Inspect(expr, func(node parser.Expression) bool {
+		if call, ok := node.(*parser.FunctionCall); ok && isAggregate(call) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// collectAggregates returns the aggregate calls in exprs. Only COUNT may
+// be called with *, every other call takes one argument, and aggregate
+// calls cannot be nested.
+func collectAggregates(exprs []parser.Expression) ([]*parser.FunctionCall, error) {
+	var calls []*parser.FunctionCall
+	var err error
+	for _, expr := range exprs {
+		parser.Inspect(expr, func(node parser.Expression) bool {
+			call, ok := node.(*parser.FunctionCall)
+			if !ok || !isAggregate(call) || err != nil {
+				return err == nil
+			}
+			switch {
+			case call.Star && call.Name != "COUNT":
+				err = fmt.Errorf("function %s does not accept *", call.Name)
+			case !call.Star && len(call.Arguments) != 1:
+				err = fmt.Errorf("function %s: expected 1 argument(s), got %d", call.Name, len(call.Arguments))
+			case !call.Star && containsAggregate(call.Arguments[0]):
+				err = fmt.Errorf("aggregate function calls cannot be nested")
+			}
+			calls = append(calls, call)
+			return false
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return calls, nil
+}
+
+// checkAggregated reports an error if expr refers to a column outside the
+// argument of an aggregate call. Names in outputs refer to result columns
+// and are allowed.
+func checkAggregated(expr parser.Expression, outputs []string) error {
+	var err error
+	parser.Inspect(expr, func(node parser.Expression) bool {
+		switch n := node.(type) {
+		case *parser.FunctionCall:
+			return !isAggregate(n)
+		case *parser.Identifier:
+			if n.Table != "" || !slices.Contains(outputs, n.Name) {
+				err = fmt.Errorf("column %q must be used in an aggregate function", n.String())
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// selectAggregate runs a SELECT that uses aggregate functions. There is
+// no GROUP BY, so the rows matching WHERE form one group and the result
+// has exactly one row, even when no rows match.
+func (e *Executor) selectAggregate(src *selectSource, stmt *parser.SelectStatement, result *Result,
+	projections []parser.Expression, calls []*parser.FunctionCall) (*Result, error) {
+	for _, expr := range projections {
+		if err := checkAggregated(expr, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range stmt.OrderBy {
+		if err := checkAggregated(item.Expression, result.Columns); err != nil {
+			return nil, err
+		}
+	}
+	for _, expr := range stmt.DistinctOn {
+		if err := checkAggregated(expr, result.Columns); err != nil {
+			return nil, err
+		}
+	}
+	for _, call := range calls {
+		if !call.Star {
+			if err := checkColumns(call.Arguments[0], newRowScope(src.schema, nil)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	aggs := make([]aggregator, len(calls))
+	for i, call := range calls {
+		aggs[i] = aggregateFunctions[call.Name]()
+	}
+
+	var evalErr error
+	err := src.scan(func(row []storage.Value) bool {
+		scope := newRowScope(src.schema, row)
+		if stmt.Where != nil {
+			var matched bool
+			if matched, evalErr = e.matches(stmt.Where, scope); evalErr != nil || !matched {
+				return evalErr == nil
+			}
+		}
+
+		for i, call := range calls {
+			// COUNT(*) counts every row.
+			val := storage.NewBoolValue(true)
+			if !call.Star {
+				if val, evalErr = e.evaluate(call.Arguments[0], scope); evalErr != nil {
+					return false
+				}
+			}
+			if val.IsNull {
+				continue
+			}
+			if evalErr = aggs[i].add(val); evalErr != nil {
+				evalErr = fmt.Errorf("function %s: %w", call.Name, evalErr)
+				return false
+			}
+		}
+		return true
+	})
+	if evalErr != nil {
+		return nil, evalErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scope := &rowScope{aggregates: make(map[*parser.FunctionCall]storage.Value, len(calls))}
+	for i, call := range calls {
+		val, err := aggs[i].result()
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %w", call.Name, err)
+		}
+		scope.aggregates[call] = val
+	}
+
+	values, err := e.evaluateList(projections, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single row needs no sorting or deduplication, but ORDER BY and
+	// DISTINCT ON are still evaluated to report errors in them.
+	outScope := (&rowScope{
+		names:  result.Columns,
+		tables: make([]string, len(result.Columns)),
+		values: values,
+	}).join(scope)
+	outScope.aggregates = scope.aggregates
+	if _, err := e.evaluateSortKeys(stmt.OrderBy, outScope, values); err != nil {
+		return nil, err
+	}
+	if _, err := e.evaluateList(stmt.DistinctOn, outScope); err != nil {
+		return nil, err
+	}
+
+	result.Rows = append(result.Rows, values)
+	return result, nil
+}
+
+// countAggregate implements COUNT.
+type countAggregate struct {
+	n int64
+}
+
+func (a *countAggregate) add(storage.Value) error {
+	a.n++
+	return nil
+}
+
+func (a *countAggregate) result() (storage.Value, error) {
+	return storage.NewInt64Value(a.n), nil
+}
+
+// sumAggregate implements SUM and AVG of numbers and intervals. SUM of
+// integers is an INT64, or a UINT64 for UINT64 input, with overflow an
+// error; SUM of other types keeps the type of its input, with FLOAT32
+// widened to FLOAT64. AVG of integers is a FLOAT64 computed from an exact
+// sum, and AVG of DECIMAL is a DECIMAL rounded as for division.
+type sumAggregate struct {
+	avg   bool
+	sum   storage.Value
+	count int64
+	// ints is set while every input has been an integer.
+	ints bool
+}
+
+func (a *sumAggregate) add(v storage.Value) error {
+	if !v.Type.IsNumeric() && v.Type != storage.TypeInterval {
+		return fmt.Errorf("argument must be numeric or INTERVAL, not %s", v.Type)
+	}
+	if a.count == 0 {
+		a.ints = true
+	}
+	a.count++
+	switch {
+	case !v.Type.IsInteger():
+		a.ints = false
+		if v.Type == storage.TypeFloat32 {
+			v, _ = castToFloat64(v)
+		}
+	case a.avg:
+		v, _ = castToDecimal(v)
+	case v.Type != storage.TypeUint64:
+		// Narrow integers are summed as INT64.
+		v, _ = castToInteger(v, storage.TypeInt64)
+	}
+
+	if a.count == 1 {
+		a.sum = v
+		return nil
+	}
+	sum, err := evaluateArithmetic("+", a.sum, v)
+	if err != nil {
+		return err
+	}
+	a.sum = sum
+	return nil
+}
+
+func (a *sumAggregate) result() (storage.Value, error) {
+	switch {
+	case a.count == 0:
+		return storage.NewNullValue(), nil
+	case !a.avg:
+		return a.sum, nil
+	case a.ints:
+		d, _ := a.sum.AsDecimal()
+		return storage.NewFloat64Value(d.Float64() / float64(a.count)), nil
+	default:
+		return evaluateArithmetic("/", a.sum, storage.NewInt64Value(a.count))
+	}
+}
+
+// extremumAggregate implements MAX (sign 1) and MIN (sign -1).
+type extremumAggregate struct {
+	sign int
+	best storage.Value
+	seen bool
+}
+
+func (a *extremumAggregate) add(v storage.Value) error {
+	if !a.seen {
+		a.best, a.seen = v, true
+		return nil
+	}
+	if !canCompare(a.best, v) {
+		return fmt.Errorf("cannot compare %s with %s", a.best.Type, v.Type)
+	}
+	if v.Compare(a.best)*a.sign > 0 {
+		a.best = v
+	}
+	return nil
+}
+
+func (a *extremumAggregate) result() (storage.Value, error) {
+	if !a.seen {
+		return storage.NewNullValue(), nil
+	}
+	return a.best, nil
+}
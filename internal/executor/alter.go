@@ -0,0 +1,230 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// executeAlterTable applies one ALTER TABLE action to the table and then
+// stores the altered schema in the catalog.
+func (e *Executor) executeAlterTable(stmt *parser.AlterTableStatement) (*Result, error) {
+	table, err := e.getTable(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	switch stmt.Action {
+	case parser.AlterAddColumn:
+		err = e.alterAddColumn(table, stmt.Column)
+	case parser.AlterDropColumn:
+		err = e.alterDropColumn(table, stmt.ColumnName)
+	case parser.AlterRenameColumn:
+		err = e.alterRenameColumn(table, stmt.ColumnName, stmt.NewName)
+	case parser.AlterColumnType:
+		err = e.alterColumnType(table, stmt.ColumnName, stmt.DataType, stmt.Collation)
+	case parser.AlterRenameTable:
+		err = e.alterRenameTable(table, stmt.NewName)
+	default:
+		err = fmt.Errorf("unsupported ALTER TABLE action")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.catalog.ReplaceTable(stmt.TableName, table.Schema); err != nil {
+		return nil, err
+	}
+	if stmt.Action == parser.AlterRenameTable {
+		delete(e.tables, stmt.TableName)
+		e.tables[stmt.NewName] = table
+	}
+
+	return &Result{
+		Message: fmt.Sprintf("Table %q altered successfully", stmt.TableName),
+	}, nil
+}
+
+// alterAddColumn adds a column and fills it for the existing rows with its
+// default or generated value.
+func (e *Executor) alterAddColumn(table *storage.Table, col parser.ColumnDefinition) error {
+	schema := table.Schema
+	switch {
+	case col.PrimaryKey:
+		return fmt.Errorf("ADD COLUMN with PRIMARY KEY is not supported")
+	case col.Unique:
+		return fmt.Errorf("ADD COLUMN with UNIQUE is not supported")
+	case col.Identity != "":
+		return fmt.Errorf("ADD COLUMN with an identity column is not supported")
+	}
+
+	ordinary := &rowScope{}
+	for _, c := range schema.Columns {
+		if c.Generated == "" {
+			ordinary.names = append(ordinary.names, c.Name)
+			ordinary.tables = append(ordinary.tables, "")
+		}
+	}
+	if err := checkColumnExpression(col, ordinary); err != nil {
+		return err
+	}
+
+	def, err := e.columnDef(col)
+	if err != nil {
+		return err
+	}
+
+	rows := scanRows(table)
+	values := make([]storage.Value, len(rows))
+	for i, row := range rows {
+		if col.Generated == nil {
+			if values[i], err = e.columnDefault(def); err != nil {
+				return err
+			}
+			continue
+		}
+		val, err := e.evaluate(col.Generated, newRowScope(schema, row))
+		if err != nil {
+			return fmt.Errorf("generated column %q: %w", col.Name, err)
+		}
+		if values[i], err = e.assign(def, val, col.Generated); err != nil {
+			return err
+		}
+	}
+
+	return table.AddColumn(def, values)
+}
+
+// alterDropColumn drops a column. The sequence of an identity column is
+// dropped with it.
+func (e *Executor) alterDropColumn(table *storage.Table, name string) error {
+	col, ok := table.Schema.GetColumn(name)
+	if !ok {
+		return fmt.Errorf("column %q of table %q does not exist", name, table.Schema.Name)
+	}
+	if dependent, err := e.generatedDependent(table.Schema, name); err != nil {
+		return err
+	} else if dependent != "" {
+		return fmt.Errorf("cannot drop column %q because generated column %q depends on it", name, dependent)
+	}
+
+	sequence := col.Sequence
+	if sequence != "" {
+		if err := e.dropSequenceDependents([]string{sequence}, "", false); err != nil {
+			return err
+		}
+	}
+	if err := table.DropColumn(name); err != nil {
+		return err
+	}
+	if sequence != "" {
+		delete(e.currvals, sequence)
+		return e.catalog.DropSequence(sequence)
+	}
+	return nil
+}
+
+// alterRenameColumn renames a column and rewrites the generation
+// expressions that refer to it.
+func (e *Executor) alterRenameColumn(table *storage.Table, oldName, newName string) error {
+	schema := table.Schema
+	generated := make(map[int]string)
+	for i, col := range schema.Columns {
+		if col.Generated == "" {
+			continue
+		}
+		// Parse a fresh copy, as cached expressions are shared.
+		expr, err := parser.ParseExpression(col.Generated)
+		if err != nil {
+			return fmt.Errorf("invalid stored expression %q: %w", col.Generated, err)
+		}
+		renamed := false
+		parser.Inspect(expr, func(node parser.Expression) bool {
+			if ident, ok := node.(*parser.Identifier); ok && ident.Name == oldName {
+				ident.Name = newName
+				renamed = true
+			}
+			return true
+		})
+		if renamed {
+			generated[i] = expr.String()
+		}
+	}
+
+	if err := table.RenameColumn(oldName, newName); err != nil {
+		return err
+	}
+	if len(generated) == 0 {
+		return nil
+	}
+	for i, sql := range generated {
+		schema.Columns[i].Generated = sql
+	}
+	return table.SaveSchema()
+}
+
+// alterColumnType converts the values of a column to a new type as CAST
+// does. The column takes the collation given with the type, if any.
+func (e *Executor) alterColumnType(table *storage.Table, name, typeName, collationName string) error {
+	schema := table.Schema
+	idx := schema.GetColumnIndex(name)
+	if idx == -1 {
+		return fmt.Errorf("column %q of table %q does not exist", name, schema.Name)
+	}
+	def, err := e.columnDef(parser.ColumnDefinition{Name: name, DataType: typeName, Collation: collationName})
+	if err != nil {
+		return err
+	}
+	if schema.Columns[idx].Identity != "" {
+		return fmt.Errorf("cannot alter the type of identity column %q", name)
+	}
+	if dependent, err := e.generatedDependent(schema, name); err != nil {
+		return err
+	} else if dependent != "" {
+		return fmt.Errorf("cannot alter type of column %q because generated column %q depends on it", name, dependent)
+	}
+
+	rows := scanRows(table)
+	values := make([]storage.Value, len(rows))
+	for i, row := range rows {
+		if values[i], err = e.castToTypeName(row[idx], typeName); err != nil {
+			return fmt.Errorf("column %q cannot be cast to type %s: %w", name, def.Type, err)
+		}
+	}
+
+	return table.SetColumnType(name, def, values)
+}
+
+// alterRenameTable renames the table and its directory.
+func (e *Executor) alterRenameTable(table *storage.Table, newName string) error {
+	if e.catalog.TableExists(newName) {
+		return fmt.Errorf("table %q already exists", newName)
+	}
+	return table.Rename(newName)
+}
+
+// generatedDependent returns the name of a generated column whose
+// expression refers to the named column, or "" if there is none.
+func (e *Executor) generatedDependent(schema *storage.TableSchema, name string) (string, error) {
+	for _, col := range schema.Columns {
+		if col.Generated == "" {
+			continue
+		}
+		expr, err := e.storedExpression(col.Generated)
+		if err != nil {
+			return "", err
+		}
+		found := false
+		parser.Inspect(expr, func(node parser.Expression) bool {
+			if ident, ok := node.(*parser.Identifier); ok && ident.Name == name {
+				found = true
+			}
+			return !found
+		})
+		if found {
+			return col.Name, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+func init() {
+	registerFunctions(map[string]builtinFunction{
+		"ENCODE":       {minArgs: 2, maxArgs: 2, call: fnEncode},
+		"DECODE":       {minArgs: 2, maxArgs: 2, call: fnDecode},
+		"OCTET_LENGTH": {minArgs: 1, maxArgs: 1, call: fnOctetLength},
+	})
+}
+
+// castToBytes converts a string to BYTES. A string starting with \x is
+// read as hex digits, the form BYTES values are displayed in; any other
+// string is taken as its UTF-8 encoding. A UUID converts to its 16 bytes.
+func castToBytes(v storage.Value) (storage.Value, error) {
+	if u, ok := v.AsUUID(); ok {
+		return storage.NewBytesValue(u[:]), nil
+	}
+	s, ok := v.AsString()
+	if !ok {
+		return storage.NewNullValue(), cannotCast(v.Type, storage.TypeBytes)
+	}
+	digits, isHex := strings.CutPrefix(s, `\x`)
+	if !isHex {
+		return storage.NewBytesValue([]byte(s)), nil
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return storage.NewNullValue(), invalidInput(storage.TypeBytes, s)
+	}
+	return storage.NewBytesValue(b), nil
+}
+
+// bytesArg returns args[i] as a byte slice or an error naming its position.
+func bytesArg(args []storage.Value, i int) ([]byte, error) {
+	b, ok := args[i].AsBytes()
+	if !ok {
+		return nil, fmt.Errorf("argument %d must be BYTES, not %s", i+1, args[i].Type)
+	}
+	return b, nil
+}
+
+// byteEncoding is a text encoding of bytes, as implemented by
+// base64.Encoding.
+type byteEncoding interface {
+	EncodeToString([]byte) string
+	DecodeString(string) ([]byte, error)
+}
+
+// byteEncodings maps the format names accepted by ENCODE and DECODE to
+// their encodings.
+var byteEncodings = map[string]byteEncoding{
+	"hex":    hexEncoding{},
+	"base64": base64.StdEncoding,
+}
+
+// hexEncoding adapts encoding/hex to byteEncoding.
+type hexEncoding struct{}
+
+func (hexEncoding) EncodeToString(b []byte) string        { return hex.EncodeToString(b) }
+func (hexEncoding) DecodeString(s string) ([]byte, error) { return hex.DecodeString(s) }
+
+// encodingArg returns the encoding named by args[i].
+func encodingArg(args []storage.Value, i int) (string, error) {
+	name, err := stringArg(args, i)
+	if err != nil {
+		return "", err
+	}
+	name = strings.ToLower(name)
+	if _, ok := byteEncodings[name]; !ok {
+		return "", fmt.Errorf("unrecognized encoding: %q", name)
+	}
+	return name, nil
+}
+
+// fnEncode renders bytes as text in the hex or base64 format.
+func fnEncode(args []storage.Value) (storage.Value, error) {
+	b, err := bytesArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	name, err := encodingArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewStringValue(byteEncodings[name].EncodeToString(b)), nil
+}
+
+// fnDecode parses text in the hex or base64 format into bytes.
+func fnDecode(args []storage.Value) (storage.Value, error) {
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	name, err := encodingArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	b, err := byteEncodings[name].DecodeString(s)
+	if err != nil {
+		return storage.NewNullValue(), fmt.Errorf("invalid %s data: %q", name, s)
+	}
+	return storage.NewBytesValue(b), nil
+}
+
+// fnOctetLength returns the number of bytes in a BYTES or STRING value.
+func fnOctetLength(args []storage.Value) (storage.Value, error) {
+	if b, ok := args[0].AsBytes(); ok {
+		return storage.NewInt64Value(int64(len(b))), nil
+	}
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewInt64Value(int64(len(s))), nil
+}
@@ -0,0 +1,430 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// resolveDataType maps a type name from the parser to a storage type.
+func (e *Executor) resolveDataType(name string) (storage.DataType, error) {
+	col, err := e.columnType(name)
+	return col.Type, err
+}
+
+// columnType parses a type name from the parser into the type fields of a
+// column definition. Only DECIMAL takes a precision and scale, and LIST and
+// STRUCT types carry their element or field types as children. A name that
+// is not a built-in type refers to an ENUM type created with CREATE TYPE.
+func (e *Executor) columnType(name string) (storage.ColumnDef, error) {
+	col := storage.ColumnDef{Type: storage.ParseDataType(name)}
+	switch {
+	case col.Type == storage.TypeNull:
+		enum, ok := e.catalog.GetType(name)
+		if !ok {
+			return col, fmt.Errorf("unknown data type %q", name)
+		}
+		col.Type, col.Enum = storage.TypeEnum, enum
+	case col.Type == storage.TypeDecimal:
+		var err error
+		if col.Precision, col.Scale, err = decimalModifiers(name); err != nil {
+			return storage.ColumnDef{}, err
+		}
+	case col.Type.IsNested():
+		return e.nestedColumnType(col, name)
+	case strings.Contains(name, "("):
+		return storage.ColumnDef{}, fmt.Errorf("type %s does not take a precision or scale", col.Type)
+	}
+	return col, nil
+}
+
+// castToTypeName casts a value to a type named in SQL. A DECIMAL result is
+// fitted to the precision and scale given with the type; a bare DECIMAL
+// keeps the scale of the value.
+func (e *Executor) castToTypeName(v storage.Value, name string) (storage.Value, error) {
+	col, err := e.columnType(name)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	if col.Type == storage.TypeDecimal && !strings.Contains(name, "(") {
+		return castValue(v, col.Type)
+	}
+	return castToColumn(v, col)
+}
+
+// castValue converts a value to the target type as CAST does. NULL casts
+// to NULL. Conversions with no sensible meaning, such as FLOAT64 to BOOL,
+// are rejected, as are values that cannot be represented in the target.
+func castValue(v storage.Value, target storage.DataType) (storage.Value, error) {
+	if v.IsNull || v.Type == target {
+		return v, nil
+	}
+	if v.Type == storage.TypeJSON && target != storage.TypeString {
+		return castFromJSON(v, target)
+	}
+
+	switch target {
+	case storage.TypeInt8, storage.TypeInt16, storage.TypeInt32, storage.TypeInt64,
+		storage.TypeUint8, storage.TypeUint16, storage.TypeUint32, storage.TypeUint64:
+		return castToInteger(v, target)
+	case storage.TypeFloat64:
+		return castToFloat64(v)
+	case storage.TypeFloat32:
+		return castToFloat32(v)
+	case storage.TypeString:
+		return storage.NewStringValue(formatText(v)), nil
+	case storage.TypeBool:
+		return castToBool(v)
+	case storage.TypeDate, storage.TypeTimestamp, storage.TypeTimestampTZ:
+		return castToTemporal(v, target)
+	case storage.TypeInterval:
+		return castToInterval(v)
+	case storage.TypeDecimal:
+		return castToDecimal(v)
+	case storage.TypeBytes:
+		return castToBytes(v)
+	case storage.TypeJSON:
+		return castToJSON(v)
+	case storage.TypeUUID:
+		return castToUUID(v)
+	}
+	return storage.NewNullValue(), cannotCast(v.Type, target)
+}
+
+func cannotCast(from, to storage.DataType) error {
+	return fmt.Errorf("cannot cast %s to %s", from, to)
+}
+
+func invalidInput(target storage.DataType, s string) error {
+	return fmt.Errorf("invalid input syntax for type %s: %q", target, s)
+}
+
+// castToInteger converts a value to an integer type. Floats and decimals
+// are rounded half away from zero; values outside the range of the type
+// are rejected.
+func castToInteger(v storage.Value, target storage.DataType) (storage.Value, error) {
+	var n *big.Int
+	switch {
+	case v.Type.IsInteger():
+		n, _ = v.AsBigInt()
+	case v.Type.IsFloat():
+		f, _ := v.AsFloat64()
+		r := math.Round(f)
+		if math.IsNaN(r) || math.IsInf(r, 0) {
+			return storage.NewNullValue(), outOfRange(target)
+		}
+		n, _ = big.NewFloat(r).Int(nil)
+	case v.Type == storage.TypeDecimal:
+		d, _ := v.AsDecimal()
+		n = d.Integer(storage.RoundHalfUp)
+	case v.Type == storage.TypeBool:
+		n = big.NewInt(0)
+		if b, _ := v.AsBool(); b {
+			n.SetInt64(1)
+		}
+	case v.Type == storage.TypeString:
+		s, _ := v.AsString()
+		var ok bool
+		if n, ok = new(big.Int).SetString(strings.TrimSpace(s), 10); !ok {
+			return storage.NewNullValue(), invalidInput(target, s)
+		}
+	default:
+		return storage.NewNullValue(), cannotCast(v.Type, target)
+	}
+
+	lo, hi := storage.IntegerRange(target)
+	if n.Cmp(lo) < 0 || n.Cmp(hi) > 0 {
+		return storage.NewNullValue(), outOfRange(target)
+	}
+	return storage.NewIntegerValue(target, n), nil
+}
+
+// outOfRange is the error for a value that does not fit a numeric type.
+func outOfRange(t storage.DataType) error {
+	switch t {
+	case storage.TypeInt64:
+		return errIntegerOverflow
+	case storage.TypeFloat64:
+		return errFloatOverflow
+	}
+	return fmt.Errorf("%s out of range", t)
+}
+
+func castToFloat64(v storage.Value) (storage.Value, error) {
+	if f, ok := numericValue(v); ok {
+		return storage.NewFloat64Value(f), nil
+	}
+	switch v.Type {
+	case storage.TypeString:
+		s, _ := v.AsString()
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+				return storage.NewNullValue(), errFloatOverflow
+			}
+			return storage.NewNullValue(), invalidInput(storage.TypeFloat64, s)
+		}
+		return storage.NewFloat64Value(f), nil
+	}
+	return storage.NewNullValue(), cannotCast(v.Type, storage.TypeFloat64)
+}
+
+// castToFloat32 converts a value as castToFloat64 does and rounds it to
+// single precision.
+func castToFloat32(v storage.Value) (storage.Value, error) {
+	wide, err := castToFloat64(v)
+	if err != nil {
+		return wide, err
+	}
+	f, _ := wide.AsFloat64()
+	if math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+		return storage.NewNullValue(), outOfRange(storage.TypeFloat32)
+	}
+	return storage.NewFloat32Value(float32(f)), nil
+}
+
+func castToBool(v storage.Value) (storage.Value, error) {
+	if n, ok := v.AsBigInt(); ok {
+		return storage.NewBoolValue(n.Sign() != 0), nil
+	}
+	switch v.Type {
+	case storage.TypeString:
+		s, _ := v.AsString()
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "t", "true", "y", "yes", "on", "1":
+			return storage.NewBoolValue(true), nil
+		case "f", "false", "n", "no", "off", "0":
+			return storage.NewBoolValue(false), nil
+		}
+		return storage.NewNullValue(), invalidInput(storage.TypeBool, s)
+	}
+	return storage.NewNullValue(), cannotCast(v.Type, storage.TypeBool)
+}
+
+// castToTemporal converts a string or another DATE or TIMESTAMP value. A
+// TIMESTAMP ignores any UTC offset in its input; a TIMESTAMPTZ converts the
+// input to UTC, reading it as UTC when it has no offset.
+func castToTemporal(v storage.Value, target storage.DataType) (storage.Value, error) {
+	var t time.Time
+	switch {
+	case v.Type == storage.TypeString:
+		s, _ := v.AsString()
+		parsed, hasZone, err := parseTimestamp(s)
+		if err != nil {
+			return storage.NewNullValue(), invalidInput(target, s)
+		}
+		if hasZone && target != storage.TypeTimestampTZ {
+			_, offset := parsed.Zone()
+			parsed = parsed.Add(time.Duration(offset) * time.Second)
+		}
+		t = parsed.UTC()
+	case v.IsTemporal():
+		t, _ = v.AsTime()
+	default:
+		return storage.NewNullValue(), cannotCast(v.Type, target)
+	}
+
+	switch target {
+	case storage.TypeDate:
+		return storage.NewDateValue(t), nil
+	case storage.TypeTimestamp:
+		return storage.NewTimestampValue(t), nil
+	default:
+		return storage.NewTimestampTZValue(t), nil
+	}
+}
+
+// timestampLayouts are the accepted forms of a date and time, each of
+// which may be followed by a UTC offset such as Z, +09 or +09:00. Seconds
+// may have a fraction.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+var zoneLayouts = []string{"", "Z07:00", "Z0700", "Z07", " Z07:00", " Z0700", " Z07"}
+
+// parseTimestamp parses a date or date and time, or one of the special
+// values 'epoch', 'now' and 'today'. It reports whether the input had a
+// UTC offset.
+func parseTimestamp(s string) (time.Time, bool, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "epoch":
+		return time.Unix(0, 0).UTC(), false, nil
+	case "now":
+		return time.Now().UTC(), false, nil
+	case "today":
+		y, m, d := time.Now().UTC().Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), false, nil
+	}
+	if upper := strings.ToUpper(s); strings.HasSuffix(upper, " UTC") {
+		s = s[:len(s)-4] + "Z"
+	}
+
+	for _, layout := range timestampLayouts {
+		for _, zone := range zoneLayouts {
+			if t, err := time.Parse(layout+zone, s); err == nil {
+				return t, zone != "", nil
+			}
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid timestamp %q", s)
+}
+
+func castToInterval(v storage.Value) (storage.Value, error) {
+	s, ok := v.AsString()
+	if !ok {
+		return storage.NewNullValue(), cannotCast(v.Type, storage.TypeInterval)
+	}
+	iv, err := parseInterval(s)
+	if err != nil {
+		return storage.NewNullValue(), invalidInput(storage.TypeInterval, s)
+	}
+	return iv, nil
+}
+
+// intervalUnits maps each unit name to the part of an interval it counts
+// and its size in that part.
+var intervalUnits = map[string]struct {
+	part byte // 'M' months, 'D' days, 'u' microseconds
+	size float64
+}{
+	"millennium": {'M', 12000}, "millennia": {'M', 12000},
+	"century": {'M', 1200}, "centuries": {'M', 1200},
+	"decade": {'M', 120}, "decades": {'M', 120},
+	"y": {'M', 12}, "yr": {'M', 12}, "yrs": {'M', 12}, "year": {'M', 12}, "years": {'M', 12},
+	"mon": {'M', 1}, "mons": {'M', 1}, "month": {'M', 1}, "months": {'M', 1},
+	"w": {'D', 7}, "week": {'D', 7}, "weeks": {'D', 7},
+	"d": {'D', 1}, "day": {'D', 1}, "days": {'D', 1},
+	"h": {'u', 3600e6}, "hr": {'u', 3600e6}, "hrs": {'u', 3600e6}, "hour": {'u', 3600e6}, "hours": {'u', 3600e6},
+	"m": {'u', 60e6}, "min": {'u', 60e6}, "mins": {'u', 60e6}, "minute": {'u', 60e6}, "minutes": {'u', 60e6},
+	"s": {'u', 1e6}, "sec": {'u', 1e6}, "secs": {'u', 1e6}, "second": {'u', 1e6}, "seconds": {'u', 1e6},
+	"ms": {'u', 1e3}, "msec": {'u', 1e3}, "msecs": {'u', 1e3}, "millisecond": {'u', 1e3}, "milliseconds": {'u', 1e3},
+	"us": {'u', 1}, "usec": {'u', 1}, "usecs": {'u', 1}, "microsecond": {'u', 1}, "microseconds": {'u', 1},
+}
+
+// parseInterval parses an interval written as quantities with units, such
+// as '1 year 2 months', '-3 days', '1.5 hours' or '10m', optionally with a
+// time of day such as '04:05:06' and a trailing 'ago' that negates it. A
+// number without a unit counts seconds.
+func parseInterval(s string) (storage.Value, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	ago := len(fields) > 0 && fields[len(fields)-1] == "ago"
+	if ago {
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) == 0 {
+		return storage.NewNullValue(), fmt.Errorf("empty interval")
+	}
+
+	var months, days, micros float64
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.Contains(field, ":") {
+			clock, err := parseClock(field)
+			if err != nil {
+				return storage.NewNullValue(), err
+			}
+			micros += clock
+			continue
+		}
+
+		end := strings.IndexFunc(field, func(r rune) bool { return r >= 'a' && r <= 'z' })
+		number, unit := field, ""
+		if end > 0 {
+			number, unit = field[:end], field[end:]
+		} else if i+1 < len(fields) {
+			i++
+			unit = fields[i]
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		if unit == "" {
+			unit = "s"
+		}
+		u, ok := intervalUnits[unit]
+		if !ok {
+			return storage.NewNullValue(), fmt.Errorf("unknown unit %q", unit)
+		}
+		switch u.part {
+		case 'M':
+			months += n * u.size
+		case 'D':
+			days += n * u.size
+		default:
+			micros += n * u.size
+		}
+	}
+
+	// Fractions of a month carry into days and fractions of a day into time.
+	days += (months - math.Trunc(months)) * storage.DaysPerMonth
+	micros += (days - math.Trunc(days)) * float64(storage.MicrosPerDay)
+	if math.Abs(micros) >= math.MaxInt64 {
+		return storage.NewNullValue(), errIntervalOverflow
+	}
+	iv, err := newInterval(int64(months), int64(days), int64(math.Round(micros)))
+	if err != nil || !ago {
+		return iv, err
+	}
+	return evaluateNegate(iv)
+}
+
+// parseClock parses [-]h:mm[:ss[.ffffff]] as microseconds.
+func parseClock(s string) (float64, error) {
+	sign := 1.0
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = -1, rest
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	var total float64
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 || (i < len(parts)-1 && n != math.Trunc(n)) {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		total += n * []float64{3600e6, 60e6, 1e6}[i]
+	}
+	return sign * total, nil
+}
+
+// coerceForAssignment converts a value being stored into a column. Besides
+// exact matches it allows conversions that cannot change the meaning of the
+// value: numeric types convert to each other within the range of the
+// target, DATE and the TIMESTAMP types convert to each other, and any type
+// converts to STRING. A string literal is converted with the full CAST
+// rules, so '42' can be inserted into an INT64 column, '\xff' into a BYTES
+// column and 'happy' into a column of an ENUM type with that label.
+func coerceForAssignment(v storage.Value, col storage.ColumnDef, expr parser.Expression) (storage.Value, error) {
+	target := col.Type
+	if v.IsNull || v.Type == target {
+		return v, nil
+	}
+
+	if _, isLiteral := expr.(*parser.StringLiteral); isLiteral {
+		return castToColumn(v, col)
+	}
+
+	switch {
+	case target == storage.TypeString,
+		target.IsNumeric() && v.Type.IsNumeric(),
+		v.IsTemporal() && isTemporalType(target):
+		return castValue(v, target)
+	}
+
+	return storage.NewNullValue(), fmt.Errorf("expression is of type %s", v.Type)
+}
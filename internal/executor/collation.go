@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// collation looks up a collation by name.
+func collation(name string) (storage.Collation, error) {
+	c, ok := storage.ParseCollation(name)
+	if !ok {
+		return c, fmt.Errorf("collation %q does not exist", name)
+	}
+	return c, nil
+}
+
+// evaluateCollate applies an explicit collation to a STRING value.
+func evaluateCollate(v storage.Value, name string) (storage.Value, error) {
+	c, err := collation(name)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	if !v.IsNull && v.Type != storage.TypeString {
+		return storage.NewNullValue(), fmt.Errorf("collations are not supported by type %s", v.Type)
+	}
+	return v.WithCollation(c), nil
+}
+
+// collateOperands picks the collation two strings are compared by. A
+// COLLATE clause on either operand applies to both. Otherwise the strings
+// are compared by the collation of whichever is not binary, which is
+// usually that of its column; two different such collations conflict.
+func collateOperands(ex *parser.InfixExpression, left, right storage.Value) (storage.Value, storage.Value, error) {
+	if left.Type != storage.TypeString || right.Type != storage.TypeString {
+		return left, right, nil
+	}
+	leftExplicit, rightExplicit := isCollate(ex.Left), isCollate(ex.Right)
+	lc, rc := left.Collation(), right.Collation()
+	switch {
+	case leftExplicit && rightExplicit && lc != rc:
+		return left, right, fmt.Errorf("collation mismatch between explicit collations %q and %q", lc, rc)
+	case leftExplicit:
+		return left, right.WithCollation(lc), nil
+	case rightExplicit:
+		return left.WithCollation(rc), right, nil
+	case lc != storage.CollationBinary && rc != storage.CollationBinary && lc != rc:
+		return left, right, fmt.Errorf("could not determine which collation to use: %q or %q", lc, rc)
+	}
+	return left, right, nil
+}
+
+func isCollate(expr parser.Expression) bool {
+	_, ok := expr.(*parser.CollateExpression)
+	return ok
+}
@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// uniqueConstraints collects the PRIMARY KEY and UNIQUE constraints of a
+// new table from its column and table constraints. Unnamed constraints are
+// named as PostgreSQL does: <table>_pkey and <table>_<columns>_key.
+func uniqueConstraints(stmt *parser.CreateTableStatement) ([]storage.UniqueConstraint, error) {
+	constraints := slices.Clone(stmt.Constraints)
+	for _, col := range stmt.Columns {
+		if col.PrimaryKey {
+			constraints = append(constraints, parser.TableConstraint{Columns: []string{col.Name}, PrimaryKey: true})
+		}
+		if col.Unique {
+			constraints = append(constraints, parser.TableConstraint{Columns: []string{col.Name}})
+		}
+	}
+
+	var keys []storage.UniqueConstraint
+	names := make(map[string]bool)
+	hasPrimary := false
+	for _, c := range constraints {
+		if c.PrimaryKey {
+			if hasPrimary {
+				return nil, fmt.Errorf("multiple primary keys for table %q are not allowed", stmt.TableName)
+			}
+			hasPrimary = true
+		}
+
+		for i, name := range c.Columns {
+			if !slices.ContainsFunc(stmt.Columns, func(def parser.ColumnDefinition) bool { return def.Name == name }) {
+				return nil, fmt.Errorf("column %q named in key does not exist", name)
+			}
+			if slices.Contains(c.Columns[:i], name) {
+				return nil, fmt.Errorf("column %q appears twice in key", name)
+			}
+		}
+
+		name := c.Name
+		switch {
+		case name != "":
+		case c.PrimaryKey:
+			name = stmt.TableName + "_pkey"
+		default:
+			name = stmt.TableName + "_" + strings.Join(c.Columns, "_") + "_key"
+		}
+		if names[name] {
+			return nil, fmt.Errorf("constraint %q already exists", name)
+		}
+		names[name] = true
+
+		keys = append(keys, storage.UniqueConstraint{Name: name, Columns: c.Columns, Primary: c.PrimaryKey})
+	}
+	return keys, nil
+}
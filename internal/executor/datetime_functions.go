@@ -0,0 +1,465 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+var (
+	errIntervalOverflow = errors.New("INTERVAL out of range")
+	errDateOverflow     = errors.New("date out of range")
+)
+
+// maxDateDays is the number of days from 0001-01-01 to 9999-12-31, the
+// range of dates and timestamps.
+const maxDateDays = 3652058
+
+func init() {
+	registerFunctions(map[string]builtinFunction{
+		"NOW":               {minArgs: 0, maxArgs: 0, call: fnNow},
+		"CURRENT_TIMESTAMP": {minArgs: 0, maxArgs: 0, call: fnNow},
+		"CURRENT_DATE":      {minArgs: 0, maxArgs: 0, call: fnCurrentDate},
+		"LOCALTIMESTAMP":    {minArgs: 0, maxArgs: 0, call: fnLocalTimestamp},
+		"DATE_TRUNC":        {minArgs: 2, maxArgs: 2, call: fnDateTrunc},
+		"EXTRACT":           {minArgs: 2, maxArgs: 2, call: fnExtract},
+		"DATE_PART":         {minArgs: 2, maxArgs: 2, call: fnExtract},
+		"DATE_ADD":          {minArgs: 2, maxArgs: 2, call: fnDateAdd},
+		"STRFTIME":          {minArgs: 2, maxArgs: 2, call: fnStrftime},
+	})
+}
+
+// Times are handled in UTC: TIMESTAMP values are wall clock times read as
+// UTC, and TIMESTAMPTZ values are shown in UTC.
+
+func fnNow(args []storage.Value) (storage.Value, error) {
+	return storage.NewTimestampTZValue(time.Now()), nil
+}
+
+func fnCurrentDate(args []storage.Value) (storage.Value, error) {
+	return storage.NewDateValue(time.Now().UTC()), nil
+}
+
+func fnLocalTimestamp(args []storage.Value) (storage.Value, error) {
+	return storage.NewTimestampValue(time.Now().UTC()), nil
+}
+
+func isTemporalType(t storage.DataType) bool {
+	return t == storage.TypeDate || t == storage.TypeTimestamp || t == storage.TypeTimestampTZ
+}
+
+// timeArg returns args[i] as a time or an error naming its position.
+func timeArg(args []storage.Value, i int) (time.Time, error) {
+	t, ok := args[i].AsTime()
+	if !ok {
+		return time.Time{}, fmt.Errorf("argument %d must be DATE or TIMESTAMP, not %s", i+1, args[i].Type)
+	}
+	return t, nil
+}
+
+// temporalValue creates a value of the given type, which is TIMESTAMP for
+// a DATE, as the result of an operation on a value of that type.
+func temporalValue(t time.Time, like storage.DataType) storage.Value {
+	if like == storage.TypeTimestampTZ {
+		return storage.NewTimestampTZValue(t)
+	}
+	return storage.NewTimestampValue(t)
+}
+
+// fnDateTrunc implements DATE_TRUNC(field, source), which truncates a
+// timestamp to the start of its second, minute, hour, day, week (Monday),
+// month, quarter, year, decade, century or millennium.
+func fnDateTrunc(args []storage.Value) (storage.Value, error) {
+	field, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	t, err := timeArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+
+	y, m, d := t.Date()
+	switch strings.ToLower(field) {
+	case "microsecond", "microseconds":
+	case "millisecond", "milliseconds":
+		t = t.Truncate(time.Millisecond)
+	case "second", "seconds":
+		t = t.Truncate(time.Second)
+	case "minute", "minutes":
+		t = t.Truncate(time.Minute)
+	case "hour", "hours":
+		t = t.Truncate(time.Hour)
+	case "day", "days":
+		t = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case "week", "weeks":
+		offset := (int(t.Weekday()) + 6) % 7
+		t = time.Date(y, m, d-offset, 0, 0, 0, 0, time.UTC)
+	case "month", "months":
+		t = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case "quarter", "quarters":
+		t = time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case "year", "years":
+		t = time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "decade", "decades":
+		t = time.Date(floorDiv(y, 10)*10, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "century", "centuries":
+		t = time.Date(floorDiv(y-1, 100)*100+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "millennium", "millennia":
+		t = time.Date(floorDiv(y-1, 1000)*1000+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return storage.NewNullValue(), fmt.Errorf("unit %q not recognized", field)
+	}
+	return temporalValue(t, args[1].Type), nil
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// fnExtract implements EXTRACT(field FROM source) and DATE_PART. Whole
+// fields are INT64; SECOND, MILLISECONDS and EPOCH are FLOAT64 and include
+// the fraction of a second.
+func fnExtract(args []storage.Value) (storage.Value, error) {
+	field, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	field = strings.ToLower(field)
+
+	if iv, ok := args[1].AsInterval(); ok {
+		return extractInterval(field, iv)
+	}
+	t, err := timeArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+
+	micros := int64(t.Second())*storage.MicrosPerSecond + int64(t.Nanosecond()/1000)
+	var n int
+	switch field {
+	case "year", "years":
+		n = t.Year()
+	case "quarter":
+		n = (int(t.Month())-1)/3 + 1
+	case "month", "months":
+		n = int(t.Month())
+	case "week":
+		_, n = t.ISOWeek()
+	case "isoyear":
+		n, _ = t.ISOWeek()
+	case "day", "days":
+		n = t.Day()
+	case "dow":
+		n = int(t.Weekday())
+	case "isodow":
+		n = (int(t.Weekday())+6)%7 + 1
+	case "doy":
+		n = t.YearDay()
+	case "hour", "hours":
+		n = t.Hour()
+	case "minute", "minutes":
+		n = t.Minute()
+	case "second", "seconds":
+		return storage.NewFloat64Value(float64(micros) / 1e6), nil
+	case "millisecond", "milliseconds":
+		return storage.NewFloat64Value(float64(micros) / 1e3), nil
+	case "microsecond", "microseconds":
+		return storage.NewInt64Value(micros), nil
+	case "epoch":
+		return storage.NewFloat64Value(float64(t.UnixMicro()) / 1e6), nil
+	case "decade", "decades":
+		n = floorDiv(t.Year(), 10)
+	case "century", "centuries":
+		n = floorDiv(t.Year()-1, 100) + 1
+	case "millennium", "millennia":
+		n = floorDiv(t.Year()-1, 1000) + 1
+	default:
+		return storage.NewNullValue(), fmt.Errorf("unit %q not recognized", field)
+	}
+	return storage.NewInt64Value(int64(n)), nil
+}
+
+func extractInterval(field string, iv storage.Interval) (storage.Value, error) {
+	switch field {
+	case "year", "years":
+		return storage.NewInt64Value(int64(iv.Months / 12)), nil
+	case "month", "months":
+		return storage.NewInt64Value(int64(iv.Months % 12)), nil
+	case "day", "days":
+		return storage.NewInt64Value(int64(iv.Days)), nil
+	case "hour", "hours":
+		return storage.NewInt64Value(iv.Micros / (3600 * storage.MicrosPerSecond)), nil
+	case "minute", "minutes":
+		return storage.NewInt64Value(iv.Micros / (60 * storage.MicrosPerSecond) % 60), nil
+	case "second", "seconds":
+		return storage.NewFloat64Value(float64(iv.Micros%(60*storage.MicrosPerSecond)) / 1e6), nil
+	case "epoch":
+		// A year is 365.25 days and a month 30 days, as in PostgreSQL.
+		years, months := iv.Months/12, iv.Months%12
+		secs := float64(years)*365.25*86400 + float64(months)*30*86400 + float64(iv.Days)*86400
+		return storage.NewFloat64Value(secs + float64(iv.Micros)/1e6), nil
+	default:
+		return storage.NewNullValue(), fmt.Errorf("unit %q not recognized for type INTERVAL", field)
+	}
+}
+
+// fnDateAdd implements DATE_ADD(source, interval), the same as source +
+// interval.
+func fnDateAdd(args []storage.Value) (storage.Value, error) {
+	return evaluateArithmetic("+", args[0], args[1])
+}
+
+// fnStrftime implements STRFTIME(source, format) with the C conversion
+// specifications %Y %y %m %d %e %H %I %M %S %f %p %j %a %A %b %B %u %w %F
+// %T %z %Z and %%. %f is microseconds.
+func fnStrftime(args []storage.Value) (storage.Value, error) {
+	t, err := timeArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	format, err := stringArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			sb.WriteByte(format[i])
+			continue
+		}
+		if i+1 == len(format) {
+			return storage.NewNullValue(), fmt.Errorf("format ends with %%")
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			fmt.Fprintf(&sb, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&sb, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&sb, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&sb, "%2d", t.Day())
+		case 'H':
+			fmt.Fprintf(&sb, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&sb, "%02d", (t.Hour()+11)%12+1)
+		case 'M':
+			fmt.Fprintf(&sb, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&sb, "%02d", t.Second())
+		case 'f':
+			fmt.Fprintf(&sb, "%06d", t.Nanosecond()/1000)
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'j':
+			fmt.Fprintf(&sb, "%03d", t.YearDay())
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'b':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'u':
+			sb.WriteString(strconv.Itoa((int(t.Weekday())+6)%7 + 1))
+		case 'w':
+			sb.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case 'z':
+			sb.WriteString("+0000")
+		case 'Z':
+			sb.WriteString("UTC")
+		case '%':
+			sb.WriteByte('%')
+		default:
+			return storage.NewNullValue(), fmt.Errorf("unknown format specifier %%%c", format[i])
+		}
+	}
+	return storage.NewStringValue(sb.String()), nil
+}
+
+// evaluateDatetimeArithmetic applies + - * / when an operand is a DATE,
+// TIMESTAMP or INTERVAL:
+//
+//   - DATE ± integer adds days and yields DATE; DATE - DATE yields the
+//     number of days as INT64.
+//   - DATE or TIMESTAMP ± INTERVAL yields TIMESTAMP (TIMESTAMPTZ for a
+//     TIMESTAMPTZ). Months are added first, clamping to the end of a
+//     shorter month, then days, then the time.
+//   - TIMESTAMP - TIMESTAMP yields an INTERVAL of days and time.
+//   - INTERVAL ± INTERVAL, INTERVAL * number and INTERVAL / number yield
+//     INTERVAL.
+//
+// A DATE or TIMESTAMP result outside the years 1 to 9999 is an error.
+func evaluateDatetimeArithmetic(op string, left, right storage.Value) (storage.Value, error) {
+	liv, lIsInterval := left.AsInterval()
+	riv, rIsInterval := right.AsInterval()
+	additive := op == "+" || op == "-"
+
+	switch {
+	case left.Type == storage.TypeDate && right.Type.IsInteger() && additive:
+		n, ok := right.AsInt64()
+		if !ok {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+		if op == "-" {
+			n = -n
+		}
+		if n > maxDateDays || n < -maxDateDays {
+			return storage.NewNullValue(), errDateOverflow
+		}
+		t, _ := left.AsTime()
+		t, err := checkDateRange(t.AddDate(0, 0, int(n)))
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return storage.NewDateValue(t), nil
+
+	case left.Type.IsInteger() && right.Type == storage.TypeDate && op == "+":
+		return evaluateDatetimeArithmetic(op, right, left)
+
+	case left.Type == storage.TypeDate && right.Type == storage.TypeDate && op == "-":
+		l, _ := left.AsTime()
+		r, _ := right.AsTime()
+		return storage.NewInt64Value(int64(l.Sub(r).Hours() / 24)), nil
+
+	case left.IsTemporal() && rIsInterval && additive:
+		if op == "-" {
+			var err error
+			if riv, err = negateInterval(riv); err != nil {
+				return storage.NewNullValue(), err
+			}
+		}
+		t, _ := left.AsTime()
+		t, err := checkDateRange(addInterval(t, riv))
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return temporalValue(t, left.Type), nil
+
+	case lIsInterval && right.IsTemporal() && op == "+":
+		return evaluateDatetimeArithmetic(op, right, left)
+
+	case left.IsTemporal() && right.IsTemporal() && op == "-":
+		l, _ := left.AsTime()
+		r, _ := right.AsTime()
+		diff := l.UnixMicro() - r.UnixMicro()
+		days := diff / storage.MicrosPerDay
+		if days > math.MaxInt32 || days < math.MinInt32 {
+			return storage.NewNullValue(), errIntervalOverflow
+		}
+		return storage.NewIntervalValue(storage.Interval{Days: int32(days), Micros: diff % storage.MicrosPerDay}), nil
+
+	case lIsInterval && rIsInterval && additive:
+		if op == "-" {
+			var err error
+			if riv, err = negateInterval(riv); err != nil {
+				return storage.NewNullValue(), err
+			}
+		}
+		return addIntervals(liv, riv)
+
+	case lIsInterval && (op == "*" || op == "/"):
+		f, ok := numericValue(right)
+		if !ok {
+			break
+		}
+		if op == "/" {
+			if f == 0 {
+				return storage.NewNullValue(), errDivisionByZero
+			}
+			f = 1 / f
+		}
+		return scaleInterval(liv, f)
+
+	case rIsInterval && op == "*":
+		return evaluateDatetimeArithmetic(op, right, left)
+	}
+
+	return storage.NewNullValue(), fmt.Errorf("operator %s is not defined for %s and %s",
+		op, left.Type, right.Type)
+}
+
+// addInterval adds an interval to a time. Adding months keeps the day of
+// the month unless the target month is shorter, as in PostgreSQL, where
+// Jan 31 + 1 month is Feb 28 (or 29).
+func addInterval(t time.Time, iv storage.Interval) time.Time {
+	if iv.Months != 0 {
+		y, m, d := t.Date()
+		total := y*12 + int(m) - 1 + int(iv.Months)
+		ny, nm := floorDiv(total, 12), time.Month(total-floorDiv(total, 12)*12+1)
+		if last := time.Date(ny, nm+1, 0, 0, 0, 0, 0, time.UTC).Day(); d > last {
+			d = last
+		}
+		t = time.Date(ny, nm, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	// Whole days of the time are added as days, as a time.Duration holds
+	// only about 292 years.
+	days := int64(iv.Days) + iv.Micros/storage.MicrosPerDay
+	micros := iv.Micros % storage.MicrosPerDay
+	return t.AddDate(0, 0, int(days)).Add(time.Duration(micros) * time.Microsecond)
+}
+
+// checkDateRange returns t, or an error if it falls outside the years 1
+// to 9999.
+func checkDateRange(t time.Time) (time.Time, error) {
+	if y := t.Year(); y < 1 || y > 9999 {
+		return t, errDateOverflow
+	}
+	return t, nil
+}
+
+func addIntervals(a, b storage.Interval) (storage.Value, error) {
+	months := int64(a.Months) + int64(b.Months)
+	days := int64(a.Days) + int64(b.Days)
+	micros := a.Micros + b.Micros
+	if (micros > a.Micros) != (b.Micros > 0) {
+		return storage.NewNullValue(), errIntervalOverflow
+	}
+	return newInterval(months, days, micros)
+}
+
+func negateInterval(iv storage.Interval) (storage.Interval, error) {
+	if iv.Months == math.MinInt32 || iv.Days == math.MinInt32 || iv.Micros == math.MinInt64 {
+		return storage.Interval{}, errIntervalOverflow
+	}
+	return storage.Interval{Months: -iv.Months, Days: -iv.Days, Micros: -iv.Micros}, nil
+}
+
+// scaleInterval multiplies an interval by f. Fractional months carry into
+// days of 30 days each and fractional days into time, as in PostgreSQL.
+func scaleInterval(iv storage.Interval, f float64) (storage.Value, error) {
+	months := float64(iv.Months) * f
+	days := float64(iv.Days)*f + (months-math.Trunc(months))*storage.DaysPerMonth
+	micros := float64(iv.Micros)*f + (days-math.Trunc(days))*float64(storage.MicrosPerDay)
+	if math.IsNaN(micros) || math.Abs(micros) >= math.MaxInt64 {
+		return storage.NewNullValue(), errIntervalOverflow
+	}
+	return newInterval(int64(math.Trunc(months)), int64(math.Trunc(days)), int64(math.Round(micros)))
+}
+
+// newInterval builds an interval, checking that months and days fit.
+func newInterval(months, days, micros int64) (storage.Value, error) {
+	if months > math.MaxInt32 || months < math.MinInt32 || days > math.MaxInt32 || days < math.MinInt32 {
+		return storage.NewNullValue(), errIntervalOverflow
+	}
+	return storage.NewIntervalValue(storage.Interval{Months: int32(months), Days: int32(days), Micros: micros}), nil
+}
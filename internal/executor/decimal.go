@@ -0,0 +1,211 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+var errDecimalOverflow = errors.New("DECIMAL out of range")
+
+// decimalDivisionScale is the least scale of a DECIMAL quotient.
+const decimalDivisionScale = 6
+
+// decimalModifiers returns the precision and scale of a DECIMAL type name
+// such as DECIMAL(10,2). DECIMAL(p) has scale 0 and a bare DECIMAL uses
+// the defaults.
+func decimalModifiers(name string) (precision, scale int, err error) {
+	_, mods, ok := strings.Cut(strings.TrimSuffix(name, ")"), "(")
+	if !ok {
+		return storage.DefaultDecimalPrecision, storage.DefaultDecimalScale, nil
+	}
+	p, s, hasScale := strings.Cut(mods, ",")
+	if precision, err = strconv.Atoi(p); err != nil {
+		return 0, 0, fmt.Errorf("invalid DECIMAL precision %q", p)
+	}
+	if hasScale {
+		if scale, err = strconv.Atoi(s); err != nil || strings.Contains(s, ",") {
+			return 0, 0, fmt.Errorf("invalid DECIMAL scale %q", s)
+		}
+	}
+	if precision < 1 || precision > storage.MaxDecimalPrecision {
+		return 0, 0, fmt.Errorf("DECIMAL precision %d must be between 1 and %d", precision, storage.MaxDecimalPrecision)
+	}
+	if scale < 0 || scale > precision {
+		return 0, 0, fmt.Errorf("DECIMAL scale %d must be between 0 and precision %d", scale, precision)
+	}
+	return precision, scale, nil
+}
+
+// fitDecimal rounds a DECIMAL value half away from zero to scale and
+// checks that it has at most precision digits.
+func fitDecimal(v storage.Value, precision, scale int) (storage.Value, error) {
+	d, ok := v.AsDecimal()
+	if !ok {
+		return v, nil
+	}
+	d = d.Rescale(scale, storage.RoundHalfUp)
+	if d.Precision() > precision {
+		return storage.NewNullValue(), fmt.Errorf("numeric field overflow: DECIMAL(%d,%d) must round to an absolute value less than 10^%d",
+			precision, scale, precision-scale)
+	}
+	return storage.NewDecimalValue(d), nil
+}
+
+// castToDecimal converts a number or string to a DECIMAL with the scale it
+// is written with. A float is taken at its shortest exact representation.
+func castToDecimal(v storage.Value) (storage.Value, error) {
+	if d, ok := exactValue(v); ok {
+		return storage.NewDecimalValue(d), nil
+	}
+	switch v.Type {
+	case storage.TypeFloat64, storage.TypeFloat32:
+		f, _ := v.AsFloat64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return storage.NewNullValue(), fmt.Errorf("cannot convert %s to DECIMAL", formatFloat(f))
+		}
+		d, err := storage.ParseDecimal(formatFloatBits(f, floatBits(v.Type)))
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return checkedDecimal(d)
+	case storage.TypeString:
+		s, _ := v.AsString()
+		d, err := storage.ParseDecimal(s)
+		if err != nil {
+			return storage.NewNullValue(), invalidInput(storage.TypeDecimal, s)
+		}
+		return checkedDecimal(d)
+	}
+	return storage.NewNullValue(), cannotCast(v.Type, storage.TypeDecimal)
+}
+
+// checkedDecimal rejects decimals with more digits than a DECIMAL holds.
+func checkedDecimal(d storage.Decimal) (storage.Value, error) {
+	if d.Precision() > storage.MaxDecimalPrecision {
+		return storage.NewNullValue(), errDecimalOverflow
+	}
+	return storage.NewDecimalValue(d), nil
+}
+
+// exactValue returns an integer or DECIMAL value as a Decimal.
+func exactValue(v storage.Value) (storage.Decimal, bool) {
+	if n, ok := v.AsBigInt(); ok {
+		return storage.NewDecimal(n, 0), true
+	}
+	return v.AsDecimal()
+}
+
+// decimalArithmetic applies + - * / % exactly. Sums and differences take
+// the larger scale of the operands and products the sum of their scales.
+// Quotients are rounded half away from zero to the larger scale, but to
+// at least decimalDivisionScale digits.
+func decimalArithmetic(op string, l, r storage.Decimal) (storage.Value, error) {
+	var result storage.Decimal
+	switch op {
+	case "+":
+		result = l.Add(r)
+	case "-":
+		result = l.Add(r.Neg())
+	case "*":
+		result = l.Mul(r)
+	case "/":
+		if r.Sign() == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		scale := max(decimalDivisionScale, l.Scale(), r.Scale())
+		result = l.Quo(r, scale, storage.RoundHalfUp)
+	case "%":
+		if r.Sign() == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		result = l.Rem(r)
+	default:
+		return storage.NewNullValue(), fmt.Errorf("unknown operator: %s", op)
+	}
+	return checkedDecimal(result)
+}
+
+// roundingModeArg returns args[i] as a rounding mode name.
+func roundingModeArg(args []storage.Value, i int) (storage.RoundingMode, error) {
+	s, ok := args[i].AsString()
+	if !ok {
+		return 0, fmt.Errorf("argument %d must be a string, not %s", i+1, args[i].Type)
+	}
+	mode, ok := storage.ParseRoundingMode(s)
+	if !ok {
+		return 0, fmt.Errorf("unknown rounding mode %q", s)
+	}
+	return mode, nil
+}
+
+// roundExact rounds a number to places decimal digits with the given mode,
+// computing in decimal so that halves are detected exactly. The result
+// keeps the type of the input; a DECIMAL gets scale places, or 0 when
+// places is negative.
+func roundExact(v storage.Value, places int64, mode storage.RoundingMode) (storage.Value, error) {
+	if places > storage.MaxDecimalPrecision || places < -storage.MaxDecimalPrecision {
+		if places > 0 {
+			return v, nil
+		}
+		return castValue(storage.NewInt64Value(0), v.Type)
+	}
+
+	dec, err := castValue(v, storage.TypeDecimal)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	d, _ := dec.AsDecimal()
+	d = d.Rescale(int(places), mode).Rescale(max(int(places), 0), storage.RoundDown)
+	if v.Type == storage.TypeDecimal {
+		return checkedDecimal(d)
+	}
+	return castValue(storage.NewDecimalValue(d), v.Type)
+}
+
+// widerNumeric returns the type that mixing two numeric types promotes to:
+// FLOAT64 over DECIMAL over INT64.
+func widerNumeric(a, b storage.DataType) storage.DataType {
+	switch {
+	case a == b:
+		return a
+	case a.IsFloat() || b.IsFloat():
+		return storage.TypeFloat64
+	case a == storage.TypeDecimal || b == storage.TypeDecimal:
+		return storage.TypeDecimal
+	default:
+		return storage.TypeInt64
+	}
+}
+
+// integerDigits is the most digits an integer type holds.
+const integerDigits = 20
+
+// widerColumnType returns the type that mixing two numeric column types
+// promotes to. A DECIMAL keeps enough integer digits and the largest scale
+// of both, or has no precision if either has none or they do not fit.
+func widerColumnType(a, b storage.ColumnDef) storage.ColumnDef {
+	t := widerNumeric(a.Type, b.Type)
+	if t != storage.TypeDecimal {
+		return storage.ColumnDef{Type: t}
+	}
+	intDigits, scale := 0, 0
+	for _, c := range []storage.ColumnDef{a, b} {
+		switch {
+		case c.Type != storage.TypeDecimal:
+			intDigits = max(intDigits, integerDigits)
+		case c.Precision == 0:
+			return storage.ColumnDef{Type: t}
+		default:
+			intDigits, scale = max(intDigits, c.Precision-c.Scale), max(scale, c.Scale)
+		}
+	}
+	if intDigits+scale > storage.MaxDecimalPrecision {
+		return storage.ColumnDef{Type: t}
+	}
+	return storage.ColumnDef{Type: t, Precision: intDigits + scale, Scale: scale}
+}
@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// storedExpression parses an expression saved as SQL text in the catalog,
+// such as a column default. Parsed expressions are cached by their text.
+func (e *Executor) storedExpression(sql string) (parser.Expression, error) {
+	if expr, ok := e.exprCache[sql]; ok {
+		return expr, nil
+	}
+	expr, err := parser.ParseExpression(sql)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored expression %q: %w", sql, err)
+	}
+	e.exprCache[sql] = expr
+	return expr, nil
+}
+
+// checkColumnExpressions validates DEFAULT and GENERATED expressions of a
+// new table. Defaults may not refer to columns. Generation expressions may
+// refer only to ordinary columns of the same table.
+func checkColumnExpressions(defs []parser.ColumnDefinition) error {
+	ordinary := &rowScope{}
+	for _, def := range defs {
+		if def.Generated == nil {
+			ordinary.names = append(ordinary.names, def.Name)
+			ordinary.tables = append(ordinary.tables, "")
+		}
+	}
+
+	for _, def := range defs {
+		if err := checkColumnExpression(def, ordinary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkColumnExpression validates the DEFAULT and GENERATED expressions of
+// one column against the ordinary columns of its table.
+func checkColumnExpression(def parser.ColumnDefinition, ordinary *rowScope) error {
+	if def.Default != nil && def.Generated != nil {
+		return fmt.Errorf("both default and generation expression specified for column %q", def.Name)
+	}
+	if def.Default != nil && hasColumnReference(def.Default) {
+		return fmt.Errorf("cannot use column reference in DEFAULT expression of column %q", def.Name)
+	}
+	if def.Generated != nil {
+		if err := checkColumns(def.Generated, ordinary); err != nil {
+			return fmt.Errorf("generation expression of column %q: %w", def.Name, err)
+		}
+	}
+	return nil
+}
+
+func hasColumnReference(expr parser.Expression) bool {
+	found := false
+	parser.Inspect(expr, func(node parser.Expression) bool {
+		if _, ok := node.(*parser.Identifier); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// columnDefault evaluates the default of a column, which is NULL if the
+// column has no DEFAULT clause. Identity columns take the next value of
+// their sequence.
+func (e *Executor) columnDefault(col storage.ColumnDef) (storage.Value, error) {
+	if col.Identity != "" {
+		val, err := e.catalog.NextVal(col.Sequence)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		e.currvals[col.Sequence] = val
+		return castValue(storage.NewInt64Value(val), col.Type)
+	}
+	if col.Default == "" {
+		return storage.NewNullValue(), nil
+	}
+	expr, err := e.storedExpression(col.Default)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	val, err := e.evaluate(expr, nil)
+	if err != nil {
+		return storage.NewNullValue(), fmt.Errorf("default of column %q: %w", col.Name, err)
+	}
+	return e.assign(col, val, expr)
+}
+
+// computeGenerated fills in the generated columns of a row from the values
+// of its ordinary columns.
+func (e *Executor) computeGenerated(schema *storage.TableSchema, row []storage.Value) error {
+	scope := newRowScope(schema, row)
+	for i, col := range schema.Columns {
+		if col.Generated == "" {
+			continue
+		}
+		expr, err := e.storedExpression(col.Generated)
+		if err != nil {
+			return err
+		}
+		val, err := e.evaluate(expr, scope)
+		if err != nil {
+			return fmt.Errorf("generated column %q: %w", col.Name, err)
+		}
+		if row[i], err = e.assign(col, val, expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assign coerces a value for storage in a column.
+func (e *Executor) assign(col storage.ColumnDef, val storage.Value, expr parser.Expression) (storage.Value, error) {
+	v, err := coerceForAssignment(val, col, expr)
+	if err == nil && (col.Type == storage.TypeDecimal || col.Type == storage.TypeEnum || col.Type.IsNested()) {
+		v, err = castToColumn(v, col)
+	}
+	if err != nil {
+		return storage.NewNullValue(), fmt.Errorf("column %q is of type %s: %w", col.Name, col.Type, err)
+	}
+	return v, nil
+}
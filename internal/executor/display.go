@@ -0,0 +1,168 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// DefaultMaxColumnWidth is the display width the shell truncates cells to
+// unless configured otherwise.
+const DefaultMaxColumnWidth = 40
+
+// ellipsis ends a truncated cell.
+const ellipsis = "…"
+
+// displayWidth returns the number of terminal columns s takes up.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth returns two for East Asian wide and full-width characters and
+// for emoji, zero for combining marks, format and control characters and
+// emoji skin tone modifiers, and one for everything else.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf, unicode.Cc) || (r >= 0x1F3FB && r <= 0x1F3FF):
+		return 0
+	case unicode.Is(wideRunes, r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// escapeControl replaces the control characters in s, which would break
+// the table layout, with escape sequences such as \n, \t and \x1b.
+func escapeControl(s string) string {
+	if !strings.ContainsFunc(s, unicode.IsControl) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// truncatable reports whether cells of type t are text that may be cut
+// short. Numbers, dates and the like are always shown in full.
+func truncatable(t storage.DataType) bool {
+	switch t {
+	case storage.TypeString, storage.TypeBytes, storage.TypeJSON, storage.TypeList, storage.TypeStruct, storage.TypeEnum:
+		return true
+	default:
+		return false
+	}
+}
+
+// truncateWidth shortens s to at most width columns, replacing the end
+// with an ellipsis if anything is cut. A width below one means no limit.
+func truncateWidth(s string, width int) string {
+	if width < 1 || displayWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	return b.String() + ellipsis
+}
+
+// padWidth pads s with spaces to width columns, on the left if alignRight
+// is set and on the right otherwise.
+func padWidth(s string, width int, alignRight bool) string {
+	padding := strings.Repeat(" ", max(width-displayWidth(s), 0))
+	if alignRight {
+		return padding + s
+	}
+	return s + padding
+}
+
+// wideRunes holds the characters with East Asian Width W or F, which
+// terminals show two columns wide, including the emoji shown as pictures
+// by default.
+var wideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115F, Stride: 1}, // Hangul Jamo initial consonants
+		{Lo: 0x231A, Hi: 0x231B, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232A, Stride: 1},
+		{Lo: 0x23E9, Hi: 0x23EC, Stride: 1},
+		{Lo: 0x23F0, Hi: 0x23F3, Stride: 3},
+		{Lo: 0x25FD, Hi: 0x25FE, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x267F, Hi: 0x2693, Stride: 20},
+		{Lo: 0x26A1, Hi: 0x26A1, Stride: 1},
+		{Lo: 0x26AA, Hi: 0x26AB, Stride: 1},
+		{Lo: 0x26BD, Hi: 0x26BE, Stride: 1},
+		{Lo: 0x26C4, Hi: 0x26C5, Stride: 1},
+		{Lo: 0x26CE, Hi: 0x26D4, Stride: 6},
+		{Lo: 0x26EA, Hi: 0x26EA, Stride: 1},
+		{Lo: 0x26F2, Hi: 0x26F3, Stride: 1},
+		{Lo: 0x26F5, Hi: 0x26FA, Stride: 5},
+		{Lo: 0x26FD, Hi: 0x26FD, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270A, Hi: 0x270B, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x274C, Hi: 0x274E, Stride: 2},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27B0, Hi: 0x27BF, Stride: 15},
+		{Lo: 0x2B1B, Hi: 0x2B1C, Stride: 1},
+		{Lo: 0x2B50, Hi: 0x2B55, Stride: 5},
+		{Lo: 0x2E80, Hi: 0x303E, Stride: 1}, // CJK radicals, symbols and punctuation
+		{Lo: 0x3041, Hi: 0x33FF, Stride: 1}, // kana, Bopomofo, CJK compatibility
+		{Lo: 0x3400, Hi: 0x4DBF, Stride: 1}, // CJK Extension A
+		{Lo: 0x4E00, Hi: 0x9FFF, Stride: 1}, // CJK Unified Ideographs
+		{Lo: 0xA000, Hi: 0xA4CF, Stride: 1}, // Yi
+		{Lo: 0xA960, Hi: 0xA97F, Stride: 1},
+		{Lo: 0xAC00, Hi: 0xD7A3, Stride: 1}, // Hangul syllables
+		{Lo: 0xF900, Hi: 0xFAFF, Stride: 1}, // CJK compatibility ideographs
+		{Lo: 0xFE10, Hi: 0xFE19, Stride: 1},
+		{Lo: 0xFE30, Hi: 0xFE6F, Stride: 1},
+		{Lo: 0xFF00, Hi: 0xFF60, Stride: 1}, // full-width forms
+		{Lo: 0xFFE0, Hi: 0xFFE6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16FE0, Hi: 0x16FE4, Stride: 1},
+		{Lo: 0x17000, Hi: 0x18CFF, Stride: 1}, // Tangut
+		{Lo: 0x1B000, Hi: 0x1B2FF, Stride: 1}, // kana supplements
+		{Lo: 0x1F004, Hi: 0x1F004, Stride: 1},
+		{Lo: 0x1F0CF, Hi: 0x1F0CF, Stride: 1},
+		{Lo: 0x1F18E, Hi: 0x1F18E, Stride: 1},
+		{Lo: 0x1F191, Hi: 0x1F19A, Stride: 1},
+		{Lo: 0x1F200, Hi: 0x1F251, Stride: 1},
+		{Lo: 0x1F300, Hi: 0x1F64F, Stride: 1}, // pictographs and emoticons
+		{Lo: 0x1F680, Hi: 0x1F6FF, Stride: 1}, // transport and map symbols
+		{Lo: 0x1F7E0, Hi: 0x1F7EB, Stride: 1},
+		{Lo: 0x1F90C, Hi: 0x1F9FF, Stride: 1},
+		{Lo: 0x1FA70, Hi: 0x1FAFF, Stride: 1},
+		{Lo: 0x20000, Hi: 0x2FFFD, Stride: 1}, // CJK Extensions B and later
+		{Lo: 0x30000, Hi: 0x3FFFD, Stride: 1},
+	},
+}
@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+func (e *Executor) executeCreateType(stmt *parser.CreateTypeStatement) (*Result, error) {
+	_, exists := e.catalog.GetType(stmt.Name)
+	builtin := storage.ParseDataType(strings.ToUpper(stmt.Name)) != storage.TypeNull
+	if (exists || builtin) && stmt.IfNotExists {
+		return &Result{
+			Message: fmt.Sprintf("Type %q already exists, skipping", stmt.Name),
+		}, nil
+	}
+	if builtin {
+		return nil, fmt.Errorf("type %q already exists", stmt.Name)
+	}
+	if len(stmt.Labels) > storage.MaxEnumLabels {
+		return nil, fmt.Errorf("type %q has more than %d labels", stmt.Name, storage.MaxEnumLabels)
+	}
+	for i, label := range stmt.Labels {
+		if slices.Contains(stmt.Labels[:i], label) {
+			return nil, fmt.Errorf("enum label %q used more than once", label)
+		}
+	}
+	if err := e.catalog.CreateType(&storage.EnumType{Name: stmt.Name, Labels: stmt.Labels}); err != nil {
+		return nil, err
+	}
+	return &Result{
+		Message: fmt.Sprintf("Type %q created successfully", stmt.Name),
+	}, nil
+}
+
+// executeDropType drops an ENUM type. The columns that use it are dropped
+// with CASCADE; otherwise they make the statement fail.
+func (e *Executor) executeDropType(stmt *parser.DropTypeStatement) (*Result, error) {
+	enum, ok := e.catalog.GetType(stmt.Name)
+	if !ok {
+		if stmt.IfExists {
+			return &Result{
+				Message: fmt.Sprintf("Type %q does not exist, skipping", stmt.Name),
+			}, nil
+		}
+		return nil, fmt.Errorf("type %q does not exist", stmt.Name)
+	}
+	type dependent struct{ table, column string }
+	var deps []dependent
+	for _, name := range e.catalog.ListTables() {
+		schema, _ := e.catalog.GetTable(name)
+		for _, col := range schema.Columns {
+			if usesEnum(col, enum.Name) {
+				deps = append(deps, dependent{table: name, column: col.Name})
+			}
+		}
+	}
+	if len(deps) > 0 && !stmt.Cascade {
+		d := deps[0]
+		return nil, fmt.Errorf("cannot drop type %q because column %q of table %q depends on it",
+			enum.Name, d.column, d.table)
+	}
+	for _, d := range deps {
+		table, err := e.getTable(d.table)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.alterDropColumn(table, d.column); err != nil {
+			return nil, err
+		}
+		if err := e.catalog.ReplaceTable(d.table, table.Schema); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.catalog.DropType(enum.Name); err != nil {
+		return nil, err
+	}
+	return &Result{
+		Message: fmt.Sprintf("Type %q dropped successfully", enum.Name),
+	}, nil
+}
+
+// usesEnum reports whether a column, or an element or field of it, is of
+// the named ENUM type.
+func usesEnum(col storage.ColumnDef, name string) bool {
+	if col.Enum != nil && col.Enum.Name == name {
+		return true
+	}
+	return slices.ContainsFunc(col.Children, func(child storage.ColumnDef) bool { return usesEnum(child, name) })
+}
+
+// castToEnum converts a label, or a value of the same ENUM type, to a value
+// of an ENUM type.
+func castToEnum(v storage.Value, enum *storage.EnumType) (storage.Value, error) {
+	if t, _, ok := v.AsEnum(); ok {
+		if t.Name != enum.Name {
+			return storage.NewNullValue(), fmt.Errorf("cannot cast %s to %s", t.Name, enum.Name)
+		}
+		return v, nil
+	}
+	label, ok := v.AsString()
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("cannot cast %s to %s", v.Type, enum.Name)
+	}
+	code, ok := enum.Code(label)
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("invalid input value for enum %s: %q", enum.Name, label)
+	}
+	return storage.NewEnumValue(enum, code), nil
+}
+
+// castToTypeOf converts v to the type of other, taking the labels of an
+// ENUM type from other.
+func castToTypeOf(v, other storage.Value) (storage.Value, error) {
+	if enum, _, ok := other.AsEnum(); ok {
+		return castToEnum(v, enum)
+	}
+	return castValue(v, other.Type)
+}
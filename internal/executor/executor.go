@@ -3,6 +3,7 @@ package executor
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/taikicoco/tate/internal/parser"
@@ -14,6 +15,9 @@ type Result struct {
 	Columns []string
 	Rows    [][]storage.Value
 	Message string
+	// MaxColumnWidth is the display width beyond which String truncates
+	// headers and text cells with an ellipsis. Zero means no limit.
+	MaxColumnWidth int
 }
 
 // NewResult creates a new empty result.
@@ -29,27 +33,34 @@ func (r *Result) RowCount() int {
 	return len(r.Rows)
 }
 
-// String returns a formatted string representation of the result.
+// String formats the result as a table. Widths are measured in terminal
+// columns, so that wide characters line up, and columns holding only
+// numbers are aligned to the right.
 func (r *Result) String() string {
 	if len(r.Columns) == 0 {
 		return ""
 	}
 
-	var sb strings.Builder
-
-	// Calculate column widths
+	// Escape the cells and truncate the text ones, then size each column
+	// to its widest cell.
+	header := make([]string, len(r.Columns))
 	widths := make([]int, len(r.Columns))
 	for i, col := range r.Columns {
-		widths[i] = len(col)
+		header[i] = truncateWidth(escapeControl(col), r.MaxColumnWidth)
+		widths[i] = displayWidth(header[i])
 	}
-	for _, row := range r.Rows {
+	cells := make([][]string, len(r.Rows))
+	for j, row := range r.Rows {
+		cells[j] = make([]string, len(row))
 		for i, val := range row {
-			strLen := len(val.String())
-			if strLen > widths[i] {
-				widths[i] = strLen
+			cells[j][i] = escapeControl(val.String())
+			if truncatable(val.Type) {
+				cells[j][i] = truncateWidth(cells[j][i], r.MaxColumnWidth)
 			}
+			widths[i] = max(widths[i], displayWidth(cells[j][i]))
 		}
 	}
+	numeric := r.numericColumns()
 
 	// Build separator
 	separator := "+"
@@ -57,43 +68,64 @@ func (r *Result) String() string {
 		separator += strings.Repeat("-", w+2) + "+"
 	}
 
-	// Header
-	sb.WriteString(separator)
-	sb.WriteString("\n|")
-	for i, col := range r.Columns {
-		sb.WriteString(fmt.Sprintf(" %-*s |", widths[i], col))
-	}
-	sb.WriteString("\n")
-	sb.WriteString(separator)
-	sb.WriteString("\n")
-
-	// Data rows
-	for _, row := range r.Rows {
+	var sb strings.Builder
+	writeRow := func(values []string) {
 		sb.WriteString("|")
-		for i, val := range row {
-			sb.WriteString(fmt.Sprintf(" %-*s |", widths[i], val.String()))
+		for i, v := range values {
+			sb.WriteString(" " + padWidth(v, widths[i], numeric[i]) + " |")
 		}
 		sb.WriteString("\n")
 	}
 
+	sb.WriteString(separator)
+	sb.WriteString("\n")
+	writeRow(header)
+	sb.WriteString(separator)
+	sb.WriteString("\n")
+	for _, row := range cells {
+		writeRow(row)
+	}
 	sb.WriteString(separator)
 
 	return sb.String()
 }
 
+// numericColumns reports for each column whether it holds numbers, and at
+// least one that is not NULL.
+func (r *Result) numericColumns() []bool {
+	numeric := make([]bool, len(r.Columns))
+	for i := range numeric {
+		for _, row := range r.Rows {
+			if v := row[i]; !v.IsNull {
+				numeric[i] = v.Type.IsNumeric()
+				if !numeric[i] {
+					break
+				}
+			}
+		}
+	}
+	return numeric
+}
+
 // Executor executes SQL statements.
 type Executor struct {
-	catalog *storage.Catalog
-	tables  map[string]*storage.Table
-	dataDir string
+	catalog   *storage.Catalog
+	tables    map[string]*storage.Table
+	dataDir   string
+	exprCache map[string]parser.Expression
+	// currvals holds the value each sequence last returned in this
+	// session, for CURRVAL.
+	currvals map[string]int64
 }
 
 // New creates a new Executor.
 func New(cat *storage.Catalog, dataDir string) *Executor {
 	return &Executor{
-		catalog: cat,
-		tables:  make(map[string]*storage.Table),
-		dataDir: dataDir,
+		catalog:   cat,
+		tables:    make(map[string]*storage.Table),
+		dataDir:   dataDir,
+		exprCache: make(map[string]parser.Expression),
+		currvals:  make(map[string]int64),
 	}
 }
 
@@ -104,8 +136,26 @@ func (e *Executor) Execute(stmt parser.Statement) (*Result, error) {
 		return e.executeCreateTable(s)
 	case *parser.DropTableStatement:
 		return e.executeDropTable(s)
+	case *parser.AlterTableStatement:
+		return e.executeAlterTable(s)
+	case *parser.TruncateStatement:
+		return e.executeTruncate(s)
+	case *parser.CreateSequenceStatement:
+		return e.executeCreateSequence(s)
+	case *parser.DropSequenceStatement:
+		return e.executeDropSequence(s)
+	case *parser.CreateTypeStatement:
+		return e.executeCreateType(s)
+	case *parser.DropTypeStatement:
+		return e.executeDropType(s)
 	case *parser.InsertStatement:
 		return e.executeInsert(s)
+	case *parser.UpdateStatement:
+		return e.executeUpdate(s)
+	case *parser.DeleteStatement:
+		return e.executeDelete(s)
+	case *parser.MergeStatement:
+		return e.executeMerge(s)
 	case *parser.SelectStatement:
 		return e.executeSelect(s)
 	default:
@@ -115,22 +165,62 @@ func (e *Executor) Execute(stmt parser.Statement) (*Result, error) {
 
 func (e *Executor) executeCreateTable(stmt *parser.CreateTableStatement) (*Result, error) {
 	if e.catalog.TableExists(stmt.TableName) {
+		if stmt.IfNotExists {
+			return &Result{
+				Message: fmt.Sprintf("Table %q already exists, skipping", stmt.TableName),
+			}, nil
+		}
 		return nil, fmt.Errorf("table %q already exists", stmt.TableName)
 	}
 
+	if err := checkColumnExpressions(stmt.Columns); err != nil {
+		return nil, err
+	}
+
+	uniqueKeys, err := uniqueConstraints(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	sequences, err := e.identitySequences(stmt)
+	if err != nil {
+		return nil, err
+	}
+
 	schema := storage.NewTableSchema(stmt.TableName)
+	schema.UniqueKeys = uniqueKeys
 	for _, col := range stmt.Columns {
-		dataType := storage.ParseDataType(col.DataType)
-		schema.AddColumn(col.Name, dataType, col.Nullable)
+		def, err := e.columnDef(col)
+		if err != nil {
+			return nil, err
+		}
+		if pk, ok := schema.PrimaryKey(); ok && slices.Contains(pk.Columns, col.Name) {
+			def.Nullable = false
+		}
+		if seq, ok := sequences[col.Name]; ok {
+			def.Identity = col.Identity
+			def.Sequence = seq.Name
+			def.Nullable = false
+		}
+		schema.AddColumnDef(def)
+	}
+
+	for _, seq := range sequences {
+		if err := e.catalog.CreateSequence(seq); err != nil {
+			e.dropSequences(sequences)
+			return nil, err
+		}
 	}
 
 	if err := e.catalog.RegisterTable(schema); err != nil {
+		e.dropSequences(sequences)
 		return nil, err
 	}
 
 	table, err := storage.CreateTable(e.dataDir, schema)
 	if err != nil {
 		_ = e.catalog.DropTable(stmt.TableName)
+		e.dropSequences(sequences)
 		return nil, err
 	}
 
@@ -141,21 +231,68 @@ func (e *Executor) executeCreateTable(stmt *parser.CreateTableStatement) (*Resul
 	}, nil
 }
 
+// columnDef converts a parsed column definition to a catalog column. Key
+// and identity properties are filled in by the caller.
+func (e *Executor) columnDef(col parser.ColumnDefinition) (storage.ColumnDef, error) {
+	def, err := e.columnType(col.DataType)
+	if err != nil {
+		return storage.ColumnDef{}, fmt.Errorf("column %q: %w", col.Name, err)
+	}
+	def.Name, def.Nullable = col.Name, col.Nullable
+	if col.Default != nil {
+		def.Default = col.Default.String()
+	}
+	if col.Generated != nil {
+		def.Generated = col.Generated.String()
+	}
+	if col.Collation != "" {
+		if def.Type != storage.TypeString {
+			return storage.ColumnDef{}, fmt.Errorf("column %q: collations are not supported by type %s", col.Name, def.TypeName())
+		}
+		if def.Collation, err = collation(col.Collation); err != nil {
+			return storage.ColumnDef{}, fmt.Errorf("column %q: %w", col.Name, err)
+		}
+	}
+	return def, nil
+}
+
+// dropSequences removes the sequences created for a table that could not
+// be created.
+func (e *Executor) dropSequences(sequences map[string]*storage.Sequence) {
+	for _, seq := range sequences {
+		_ = e.catalog.DropSequence(seq.Name)
+	}
+}
+
 func (e *Executor) executeDropTable(stmt *parser.DropTableStatement) (*Result, error) {
 	if !e.catalog.TableExists(stmt.TableName) {
 		if stmt.IfExists {
-			return &Result{Message: "OK"}, nil
+			return &Result{
+				Message: fmt.Sprintf("Table %q does not exist, skipping", stmt.TableName),
+			}, nil
 		}
 		return nil, fmt.Errorf("table %q does not exist", stmt.TableName)
 	}
 
-	if table, exists := e.tables[stmt.TableName]; exists {
-		if err := table.Drop(); err != nil {
-			return nil, err
+	// The sequences of identity columns are dropped with the table.
+	schema, _ := e.catalog.GetTable(stmt.TableName)
+	var owned []string
+	for _, col := range schema.Columns {
+		if col.Sequence != "" {
+			owned = append(owned, col.Sequence)
 		}
-		delete(e.tables, stmt.TableName)
+	}
+	if err := e.dropSequenceDependents(owned, stmt.TableName, stmt.Cascade); err != nil {
+		return nil, err
 	}
 
+	// The files are removed even if the table was never loaded, so that a
+	// new table of the same name starts empty.
+	if err := storage.DropTable(e.dataDir, stmt.TableName); err != nil {
+		return nil, err
+	}
+	delete(e.tables, stmt.TableName)
+
 	if err := e.catalog.DropTable(stmt.TableName); err != nil {
 		return nil, err
 	}
@@ -165,101 +302,515 @@ func (e *Executor) executeDropTable(stmt *parser.DropTableStatement) (*Result, e
 	}, nil
 }
 
+// executeTruncate removes all rows from each listed table. Every table is
+// looked up before any is emptied.
+func (e *Executor) executeTruncate(stmt *parser.TruncateStatement) (*Result, error) {
+	tables := make([]*storage.Table, len(stmt.TableNames))
+	for i, name := range stmt.TableNames {
+		if slices.Contains(stmt.TableNames[:i], name) {
+			continue
+		}
+		table, err := e.getTable(name)
+		if err != nil {
+			return nil, err
+		}
+		tables[i] = table
+	}
+
+	truncated := 0
+	for _, table := range tables {
+		if table == nil {
+			continue
+		}
+		if err := table.Truncate(); err != nil {
+			return nil, err
+		}
+		if stmt.RestartIdentity {
+			if err := e.restartIdentity(table.Schema); err != nil {
+				return nil, err
+			}
+		}
+		truncated++
+	}
+
+	return &Result{Message: fmt.Sprintf("%d table(s) truncated", truncated)}, nil
+}
+
+// restartIdentity resets the sequences owned by a table's identity columns.
+func (e *Executor) restartIdentity(schema *storage.TableSchema) error {
+	for _, col := range schema.Columns {
+		if seq, ok := e.catalog.GetSequence(col.Sequence); ok && seq.OwnedBy == schema.Name {
+			if err := e.catalog.RestartSequence(seq.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (e *Executor) executeInsert(stmt *parser.InsertStatement) (*Result, error) {
 	table, err := e.getTable(stmt.TableName)
 	if err != nil {
 		return nil, err
 	}
 
+	if _, _, err := projectColumns(table.Schema, stmt.Returning); err != nil {
+		return nil, err
+	}
+
+	values, err := e.newRow(table.Schema, stmt.Columns, stmt.Values, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.OnConflict != nil {
+		return e.executeOnConflict(table, stmt, values)
+	}
+
+	if err := table.Insert(values); err != nil {
+		return nil, err
+	}
+
+	if err := table.Save(); err != nil {
+		return nil, err
+	}
+
+	return e.returning(table.Schema, stmt.Returning, [][]storage.Value{values}, "1 row inserted")
+}
+
+// newRow builds a row to insert from a list of target columns and their
+// value expressions, evaluated in scope. Columns not listed get their
+// defaults and generated columns are computed. With no target columns the
+// values are for all columns in order.
+func (e *Executor) newRow(schema *storage.TableSchema, targets []string, exprs []parser.Expression, scope *rowScope) ([]storage.Value, error) {
+	if len(targets) == 0 {
+		targets = schema.ColumnNames()
+	}
+	if len(exprs) != len(targets) {
+		return nil, fmt.Errorf("column count mismatch: expected %d, got %d",
+			len(targets), len(exprs))
+	}
+
+	values := make([]storage.Value, len(schema.Columns))
+	seen := make([]bool, len(schema.Columns))
+	assigned := make([]bool, len(schema.Columns))
+	for i, colName := range targets {
+		idx := schema.GetColumnIndex(colName)
+		if idx == -1 {
+			return nil, fmt.Errorf("column %q not found", colName)
+		}
+		if seen[idx] {
+			return nil, fmt.Errorf("column %q specified more than once", colName)
+		}
+		seen[idx] = true
+
+		col := schema.Columns[idx]
+		if _, isDefault := exprs[i].(*parser.DefaultKeyword); isDefault {
+			continue
+		}
+		if col.Generated != "" {
+			return nil, fmt.Errorf("cannot insert a non-DEFAULT value into generated column %q", col.Name)
+		}
+		if col.Identity == "ALWAYS" {
+			return nil, fmt.Errorf("cannot insert a non-DEFAULT value into identity column %q defined as GENERATED ALWAYS", col.Name)
+		}
+
+		val, err := e.evaluate(exprs[i], scope)
+		if err != nil {
+			return nil, err
+		}
+		if values[idx], err = e.assign(col, val, exprs[i]); err != nil {
+			return nil, err
+		}
+		assigned[idx] = true
+	}
+
+	for i, col := range schema.Columns {
+		if assigned[i] || col.Generated != "" {
+			continue
+		}
+		var err error
+		if values[i], err = e.columnDefault(col); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.computeGenerated(schema, values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (e *Executor) executeUpdate(stmt *parser.UpdateStatement) (*Result, error) {
+	table, err := e.getTable(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
 	schema := table.Schema
-	var values []storage.Value
+	scope := newRowScope(schema, nil)
+
+	targets, err := resolveAssignments(schema, stmt.Assignments, scope)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(stmt.Columns) > 0 {
-		values = make([]storage.Value, len(schema.Columns))
-		for i := range values {
-			values[i] = storage.NewNullValue()
+	if stmt.Where != nil {
+		if err := checkColumns(stmt.Where, scope); err != nil {
+			return nil, err
 		}
+	}
+	if _, _, err := projectColumns(schema, stmt.Returning); err != nil {
+		return nil, err
+	}
+
+	// SET expressions see the old row, so every row is computed before the
+	// table is rewritten.
+	var rows, updated [][]storage.Value
+	var evalErr error
+	_ = table.Scan(func(rowIndex uint64, row []storage.Value) bool {
+		rows = append(rows, row)
+		scope := newRowScope(schema, row)
 
-		for i, colName := range stmt.Columns {
-			idx := schema.GetColumnIndex(colName)
-			if idx == -1 {
-				return nil, fmt.Errorf("column %q not found", colName)
+		if stmt.Where != nil {
+			var matched bool
+			if matched, evalErr = e.matches(stmt.Where, scope); evalErr != nil {
+				return false
 			}
-			val, err := e.evaluateLiteral(stmt.Values[i])
-			if err != nil {
-				return nil, err
+			if !matched {
+				return true
 			}
-			values[idx] = val
 		}
-	} else {
-		if len(stmt.Values) != len(schema.Columns) {
-			return nil, fmt.Errorf("column count mismatch: expected %d, got %d",
-				len(schema.Columns), len(stmt.Values))
+
+		if rows[len(rows)-1], evalErr = e.applyAssignments(schema, stmt.Assignments, targets, scope, row); evalErr != nil {
+			return false
 		}
+		updated = append(updated, rows[len(rows)-1])
+		return true
+	})
+	if evalErr != nil {
+		return nil, evalErr
+	}
 
-		values = make([]storage.Value, len(stmt.Values))
-		for i, expr := range stmt.Values {
-			val, err := e.evaluateLiteral(expr)
-			if err != nil {
-				return nil, err
-			}
-			values[i] = val
+	if len(updated) > 0 {
+		if err := table.ReplaceRows(rows); err != nil {
+			return nil, err
+		}
+		if err := table.Save(); err != nil {
+			return nil, err
 		}
 	}
 
-	if err := table.Insert(values); err != nil {
+	return e.returning(schema, stmt.Returning, updated, fmt.Sprintf("%d row(s) updated", len(updated)))
+}
+
+func (e *Executor) executeDelete(stmt *parser.DeleteStatement) (*Result, error) {
+	table, err := e.getTable(stmt.TableName)
+	if err != nil {
 		return nil, err
 	}
 
-	if err := table.Save(); err != nil {
+	schema := table.Schema
+	if stmt.Where != nil {
+		if err := checkColumns(stmt.Where, newRowScope(schema, nil)); err != nil {
+			return nil, err
+		}
+	}
+	if _, _, err := projectColumns(schema, stmt.Returning); err != nil {
 		return nil, err
 	}
 
-	return &Result{Message: "1 row inserted"}, nil
+	var kept, deleted [][]storage.Value
+	var evalErr error
+	_ = table.Scan(func(rowIndex uint64, row []storage.Value) bool {
+		matched := true
+		if stmt.Where != nil {
+			if matched, evalErr = e.matches(stmt.Where, newRowScope(schema, row)); evalErr != nil {
+				return false
+			}
+		}
+		if matched {
+			deleted = append(deleted, row)
+		} else {
+			kept = append(kept, row)
+		}
+		return true
+	})
+	if evalErr != nil {
+		return nil, evalErr
+	}
+
+	if len(deleted) > 0 {
+		if err := table.ReplaceRows(kept); err != nil {
+			return nil, err
+		}
+		if err := table.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.returning(schema, stmt.Returning, deleted, fmt.Sprintf("%d row(s) deleted", len(deleted)))
+}
+
+// resolveAssignments checks the SET list of an update against the schema
+// and returns the position of each assigned column. Values are checked
+// against scope.
+func resolveAssignments(schema *storage.TableSchema, assignments []parser.Assignment, scope *rowScope) ([]int, error) {
+	targets := make([]int, len(assignments))
+	for i, a := range assignments {
+		idx := schema.GetColumnIndex(a.Column)
+		if idx == -1 {
+			return nil, fmt.Errorf("column %q not found", a.Column)
+		}
+		if slices.Contains(targets[:i], idx) {
+			return nil, fmt.Errorf("column %q specified more than once", a.Column)
+		}
+		if _, isDefault := a.Value.(*parser.DefaultKeyword); !isDefault {
+			if schema.Columns[idx].Generated != "" || schema.Columns[idx].Identity == "ALWAYS" {
+				return nil, fmt.Errorf("column %q can only be updated to DEFAULT", a.Column)
+			}
+			if err := checkColumns(a.Value, scope); err != nil {
+				return nil, err
+			}
+		}
+		targets[i] = idx
+	}
+	return targets, nil
+}
+
+// applyAssignments returns a copy of row with the SET list applied. Values
+// are evaluated in scope, which sees the old row. Generated columns are
+// recomputed.
+func (e *Executor) applyAssignments(schema *storage.TableSchema, assignments []parser.Assignment, targets []int, scope *rowScope, row []storage.Value) ([]storage.Value, error) {
+	newRow := slices.Clone(row)
+	for i, a := range assignments {
+		col := schema.Columns[targets[i]]
+		if _, isDefault := a.Value.(*parser.DefaultKeyword); isDefault {
+			if col.Generated == "" {
+				val, err := e.columnDefault(col)
+				if err != nil {
+					return nil, err
+				}
+				newRow[targets[i]] = val
+			}
+			continue
+		}
+
+		val, err := e.evaluate(a.Value, scope)
+		if err != nil {
+			return nil, err
+		}
+		if newRow[targets[i]], err = e.assign(col, val, a.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.computeGenerated(schema, newRow); err != nil {
+		return nil, err
+	}
+	return newRow, nil
 }
 
 func (e *Executor) executeSelect(stmt *parser.SelectStatement) (*Result, error) {
-	table, err := e.getTable(stmt.TableName)
+	src, err := e.selectSource(stmt)
 	if err != nil {
 		return nil, err
 	}
 
-	schema := table.Schema
+	schema := src.schema
 	result := NewResult()
 
-	var selectColumns []string
-	var columnIndices []int
+	var projections []parser.Expression
+	if result.Columns, projections, err = projectColumns(schema, stmt.Columns); err != nil {
+		return nil, err
+	}
+	if err := checkDistinctOn(stmt, result.Columns, projections); err != nil {
+		return nil, err
+	}
 
-	for _, col := range stmt.Columns {
-		if col.IsWildcard {
-			selectColumns = append(selectColumns, schema.ColumnNames()...)
-			for i := range schema.Columns {
-				columnIndices = append(columnIndices, i)
+	if stmt.Where != nil {
+		if err := checkColumns(stmt.Where, newRowScope(schema, nil)); err != nil {
+			return nil, err
+		}
+		if containsAggregate(stmt.Where) {
+			return nil, fmt.Errorf("aggregate functions are not allowed in WHERE")
+		}
+	}
+
+	exprs := slices.Clone(projections)
+	for _, item := range stmt.OrderBy {
+		exprs = append(exprs, item.Expression)
+	}
+	calls, err := collectAggregates(append(exprs, stmt.DistinctOn...))
+	if err != nil {
+		return nil, err
+	}
+	if len(calls) > 0 {
+		return e.selectAggregate(src, stmt, result, projections, calls)
+	}
+
+	var rows []outputRow
+	var evalErr error
+	err = src.scan(func(row []storage.Value) bool {
+		var out outputRow
+		scope := newRowScope(schema, row)
+
+		if stmt.Where != nil {
+			var matched bool
+			if matched, evalErr = e.matches(stmt.Where, scope); evalErr != nil {
+				return false
 			}
-		} else if ident, ok := col.Expression.(*parser.Identifier); ok {
-			idx := schema.GetColumnIndex(ident.Name)
-			if idx == -1 {
-				return nil, fmt.Errorf("column %q not found", ident.Name)
+			if !matched {
+				return true
 			}
-			selectColumns = append(selectColumns, ident.Name)
-			columnIndices = append(columnIndices, idx)
 		}
-	}
 
-	result.Columns = selectColumns
+		if out.values, evalErr = e.evaluateList(projections, scope); evalErr != nil {
+			return false
+		}
+
+		// ORDER BY and DISTINCT ON may refer to output columns by alias,
+		// which take precedence over input columns of the same name.
+		outScope := (&rowScope{
+			names:  result.Columns,
+			tables: make([]string, len(result.Columns)),
+			values: out.values,
+		}).join(scope)
+		if out.sortKeys, evalErr = e.evaluateSortKeys(stmt.OrderBy, outScope, out.values); evalErr != nil {
+			return false
+		}
 
-	_ = table.Scan(func(rowIndex uint64, row []storage.Value) bool {
-		resultRow := make([]storage.Value, len(columnIndices))
-		for i, idx := range columnIndices {
-			resultRow[i] = row[idx]
+		switch {
+		case len(stmt.DistinctOn) > 0:
+			out.distinctKey, evalErr = e.evaluateList(stmt.DistinctOn, outScope)
+			if evalErr != nil {
+				return false
+			}
+		case stmt.Distinct:
+			out.distinctKey = out.values
 		}
-		result.Rows = append(result.Rows, resultRow)
+
+		rows = append(rows, out)
 		return true
 	})
+	if evalErr != nil {
+		return nil, evalErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmt.OrderBy) > 0 {
+		sortRows(rows, stmt.OrderBy)
+	}
+	if stmt.Distinct {
+		rows = distinctRows(rows)
+	}
+
+	for _, row := range rows {
+		result.Rows = append(result.Rows, row.values)
+	}
 
 	return result, nil
 }
 
+// projectColumns expands a select list against a table, returning the
+// result column names and the expression computing each column.
+func projectColumns(schema *storage.TableSchema, columns []parser.SelectColumn) ([]string, []parser.Expression, error) {
+	var headers []string
+	var projections []parser.Expression
+
+	for _, col := range columns {
+		if col.IsWildcard {
+			for _, name := range schema.ColumnNames() {
+				headers = append(headers, name)
+				projections = append(projections, &parser.Identifier{Name: name})
+			}
+			continue
+		}
+
+		if err := checkColumns(col.Expression, newRowScope(schema, nil)); err != nil {
+			return nil, nil, err
+		}
+		headers = append(headers, columnHeader(col))
+		projections = append(projections, col.Expression)
+	}
+
+	return headers, projections, nil
+}
+
+// returning builds the result of a data-modifying statement. Without a
+// RETURNING list it holds just the message; otherwise the list is
+// evaluated against each affected row.
+func (e *Executor) returning(schema *storage.TableSchema, columns []parser.SelectColumn, rows [][]storage.Value, message string) (*Result, error) {
+	if len(columns) == 0 {
+		return &Result{Message: message}, nil
+	}
+
+	headers, projections, err := projectColumns(schema, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	result := NewResult()
+	result.Columns = headers
+	result.Message = message
+	for _, row := range rows {
+		values, err := e.evaluateList(projections, newRowScope(schema, row))
+		if err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, nil
+}
+
+// columnHeader returns the result column name for a select list entry.
+func columnHeader(col parser.SelectColumn) string {
+	if col.Alias != "" {
+		return col.Alias
+	}
+	if ident, ok := col.Expression.(*parser.Identifier); ok && ident.Table == "" {
+		return ident.Name
+	}
+	return col.Expression.String()
+}
+
+func (e *Executor) evaluateList(exprs []parser.Expression, scope *rowScope) ([]storage.Value, error) {
+	values := make([]storage.Value, len(exprs))
+	for i, expr := range exprs {
+		val, err := e.evaluate(expr, scope)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = val
+	}
+	return values, nil
+}
+
+// evaluateSortKeys evaluates ORDER BY keys. A bare integer literal refers
+// to an output column by its 1-based position.
+func (e *Executor) evaluateSortKeys(items []parser.OrderByItem, scope *rowScope, output []storage.Value) ([]storage.Value, error) {
+	keys := make([]storage.Value, len(items))
+	for i, item := range items {
+		if pos, ok := item.Expression.(*parser.IntegerLiteral); ok {
+			if pos.Value < 1 || pos.Value > int64(len(output)) {
+				return nil, fmt.Errorf("ORDER BY position %d is not in select list", pos.Value)
+			}
+			keys[i] = output[pos.Value-1]
+			continue
+		}
+
+		val, err := e.evaluate(item.Expression, scope)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = val
+	}
+	return keys, nil
+}
+
 func (e *Executor) evaluateLiteral(expr parser.Expression) (storage.Value, error) {
 	switch ex := expr.(type) {
 	case *parser.IntegerLiteral:
@@ -268,6 +819,8 @@ func (e *Executor) evaluateLiteral(expr parser.Expression) (storage.Value, error
 		return storage.NewFloat64Value(ex.Value), nil
 	case *parser.StringLiteral:
 		return storage.NewStringValue(ex.Value), nil
+	case *parser.BytesLiteral:
+		return storage.NewBytesValue(ex.Value), nil
 	case *parser.BoolLiteral:
 		return storage.NewBoolValue(ex.Value), nil
 	case *parser.NullLiteral:
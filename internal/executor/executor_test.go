@@ -1,8 +1,12 @@
 package executor
 
 import (
+	"encoding/binary"
+	"math"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/taikicoco/tate/internal/parser"
 	"github.com/taikicoco/tate/internal/storage"
@@ -42,6 +46,18 @@ func (e *testEnv) cleanup() {
 	os.RemoveAll(e.dataDir)
 }
 
+// reopen discards in-memory state and reloads the catalog from disk, as
+// happens when the shell is restarted.
+func (e *testEnv) reopen(t *testing.T) {
+	t.Helper()
+	catalog, err := storage.NewCatalog(e.dataDir)
+	if err != nil {
+		t.Fatalf("failed to reopen catalog: %v", err)
+	}
+	e.catalog = catalog
+	e.exec = New(catalog, e.dataDir)
+}
+
 func (e *testEnv) execute(t *testing.T, sql string) (*Result, error) {
 	t.Helper()
 	l := parser.NewLexer(sql)
@@ -152,6 +168,46 @@ func TestDropTableNotExists(t *testing.T) {
 	}
 }
 
+func TestDropTableRemovesFiles(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1)")
+
+	// After reopening the table is not loaded when it is dropped.
+	env.reopen(t)
+	env.mustExecute(t, "DROP TABLE users")
+	env.reopen(t)
+	env.mustExecute(t, "CREATE TABLE users (id INT64)")
+	env.reopen(t)
+	if result := env.mustExecute(t, "SELECT * FROM users"); result.RowCount() != 0 {
+		t.Errorf("expected an empty table, got %d rows", result.RowCount())
+	}
+}
+
+func TestDropTableList(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE t (id INT64)")
+	env.mustExecute(t, "CREATE TABLE u (id INT64)")
+
+	// Only one table can be dropped at a time; anything after the
+	// statement is a syntax error.
+	for _, sql := range []string{"DROP TABLE t, u", "DROP TABLE t u", "SELECT * FROM t; SELECT * FROM u"} {
+		p := parser.NewParser(parser.NewLexer(sql))
+		p.Parse()
+		if len(p.Errors()) == 0 || !strings.Contains(p.Errors()[0], "syntax error at or near") {
+			t.Errorf("%s: expected syntax error, got %v", sql, p.Errors())
+		}
+	}
+	if !env.catalog.TableExists("t") || !env.catalog.TableExists("u") {
+		t.Error("expected both tables to remain")
+	}
+	env.mustExecute(t, "DROP TABLE t;")
+}
+
 // ============================================
 // INSERT Tests
 // ============================================
@@ -406,3 +462,3273 @@ func TestNullValues(t *testing.T) {
 		t.Error("expected NULL value")
 	}
 }
+
+// ============================================
+// ORDER BY / DISTINCT Tests
+// ============================================
+
+func TestSelectOrderBy(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64, name STRING)")
+	env.mustExecute(t, "INSERT INTO users VALUES (2, 'Bob')")
+	env.mustExecute(t, "INSERT INTO users VALUES (NULL, 'Nobody')")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alice')")
+	env.mustExecute(t, "INSERT INTO users VALUES (3, 'Charlie')")
+
+	result := env.mustExecute(t, "SELECT name FROM users ORDER BY id")
+	expected := []string{"Alice", "Bob", "Charlie", "Nobody"}
+	for i, want := range expected {
+		if got, _ := result.Rows[i][0].AsString(); got != want {
+			t.Errorf("row %d: expected %q, got %q", i, want, got)
+		}
+	}
+
+	result = env.mustExecute(t, "SELECT name FROM users ORDER BY id DESC")
+	expected = []string{"Nobody", "Charlie", "Bob", "Alice"}
+	for i, want := range expected {
+		if got, _ := result.Rows[i][0].AsString(); got != want {
+			t.Errorf("row %d: expected %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestSelectDistinct(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE events (kind STRING, score FLOAT64, ok BOOL)")
+	env.mustExecute(t, "INSERT INTO events VALUES ('click', 1.5, TRUE)")
+	env.mustExecute(t, "INSERT INTO events VALUES ('click', 1.5, TRUE)")
+	env.mustExecute(t, "INSERT INTO events VALUES ('click', NULL, TRUE)")
+	env.mustExecute(t, "INSERT INTO events VALUES ('click', NULL, TRUE)")
+	env.mustExecute(t, "INSERT INTO events VALUES ('view', 1.5, FALSE)")
+	env.mustExecute(t, "INSERT INTO events VALUES (NULL, NULL, NULL)")
+	env.mustExecute(t, "INSERT INTO events VALUES (NULL, NULL, NULL)")
+
+	result := env.mustExecute(t, "SELECT DISTINCT * FROM events")
+	if result.RowCount() != 4 {
+		t.Errorf("expected 4 distinct rows, got %d", result.RowCount())
+	}
+
+	result = env.mustExecute(t, "SELECT DISTINCT kind FROM events ORDER BY kind")
+	if result.RowCount() != 3 {
+		t.Fatalf("expected 3 distinct kinds, got %d", result.RowCount())
+	}
+	if kind, _ := result.Rows[0][0].AsString(); kind != "click" {
+		t.Errorf("expected 'click' first, got %v", result.Rows[0][0])
+	}
+	if !result.Rows[2][0].IsNull {
+		t.Errorf("expected NULL last, got %v", result.Rows[2][0])
+	}
+}
+
+func TestSelectDistinctOn(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE readings (sensor STRING, ts INT64, value FLOAT64)")
+	env.mustExecute(t, "INSERT INTO readings VALUES ('a', 1, 10.0)")
+	env.mustExecute(t, "INSERT INTO readings VALUES ('b', 1, 20.0)")
+	env.mustExecute(t, "INSERT INTO readings VALUES ('a', 3, 12.0)")
+	env.mustExecute(t, "INSERT INTO readings VALUES ('b', 2, 21.0)")
+	env.mustExecute(t, "INSERT INTO readings VALUES ('a', 2, 11.0)")
+
+	result := env.mustExecute(t,
+		"SELECT DISTINCT ON (sensor) sensor, ts, value FROM readings ORDER BY sensor, ts DESC")
+
+	if result.RowCount() != 2 {
+		t.Fatalf("expected 2 rows, got %d", result.RowCount())
+	}
+
+	expected := []struct {
+		sensor string
+		ts     int64
+	}{{"a", 3}, {"b", 2}}
+	for i, want := range expected {
+		sensor, _ := result.Rows[i][0].AsString()
+		ts, _ := result.Rows[i][1].AsInt64()
+		if sensor != want.sensor || ts != want.ts {
+			t.Errorf("row %d: expected (%s, %d), got %v", i, want.sensor, want.ts, result.Rows[i])
+		}
+	}
+
+	// ORDER BY must start with the DISTINCT ON expressions, in any order.
+	for _, sql := range []string{
+		"SELECT DISTINCT ON (sensor) sensor, ts FROM readings ORDER BY ts",
+		"SELECT DISTINCT ON (sensor, ts) sensor, ts FROM readings ORDER BY sensor, value, ts",
+		"SELECT DISTINCT ON (sensor) sensor, ts FROM readings ORDER BY 2, 1",
+	} {
+		if _, err := env.execute(t, sql); err == nil || !strings.Contains(err.Error(), "DISTINCT ON expressions must match initial ORDER BY expressions") {
+			t.Errorf("%s: expected DISTINCT ON error, got %v", sql, err)
+		}
+	}
+	for _, sql := range []string{
+		"SELECT DISTINCT ON (sensor) sensor, ts FROM readings",
+		"SELECT DISTINCT ON (sensor, ts) sensor, ts FROM readings ORDER BY ts DESC, sensor",
+		"SELECT DISTINCT ON (sensor, ts) sensor, ts FROM readings ORDER BY sensor",
+		"SELECT DISTINCT ON (s) sensor AS s, ts FROM readings ORDER BY sensor, ts DESC",
+		"SELECT DISTINCT ON (sensor) sensor, ts FROM readings ORDER BY 1, 2 DESC",
+	} {
+		env.mustExecute(t, sql)
+	}
+}
+
+// ============================================
+// Expression / Alias Tests
+// ============================================
+
+func TestSelectComputedColumnWithAlias(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE orders (id INT64, price FLOAT64, qty INT64)")
+	env.mustExecute(t, "INSERT INTO orders VALUES (1, 2.5, 4)")
+	env.mustExecute(t, "INSERT INTO orders VALUES (2, 10.0, 3)")
+	env.mustExecute(t, "INSERT INTO orders VALUES (3, 1.0, NULL)")
+
+	result := env.mustExecute(t, "SELECT id, price * qty AS total, qty + 1 FROM orders ORDER BY total DESC")
+
+	if result.Columns[1] != "total" || result.Columns[2] != "qty + 1" {
+		t.Errorf("unexpected columns: %v", result.Columns)
+	}
+
+	if !result.Rows[0][1].IsNull {
+		t.Errorf("expected NULL total first in descending order, got %v", result.Rows[0][1])
+	}
+	total, ok := result.Rows[1][1].AsFloat64()
+	if !ok || total != 30 {
+		t.Errorf("expected total 30, got %v", result.Rows[1][1])
+	}
+	next, ok := result.Rows[1][2].AsInt64()
+	if !ok || next != 4 {
+		t.Errorf("expected qty + 1 = 4, got %v", result.Rows[1][2])
+	}
+}
+
+func TestSelectExpressionPrecedence(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE nums (a INT64, b INT64)")
+	env.mustExecute(t, "INSERT INTO nums VALUES (7, -2)")
+
+	result := env.mustExecute(t, "SELECT a + b * 3, (a + b) * 3, -a, a / b, a % b, a-1 FROM nums")
+
+	expected := []int64{1, 15, -7, -3, 1, 6}
+	for i, want := range expected {
+		got, ok := result.Rows[0][i].AsInt64()
+		if !ok || got != want {
+			t.Errorf("column %s: expected %d, got %v", result.Columns[i], want, result.Rows[0][i])
+		}
+	}
+}
+
+func TestSelectOrderByPosition(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64, name STRING)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Bob')")
+	env.mustExecute(t, "INSERT INTO users VALUES (2, 'Alice')")
+
+	result := env.mustExecute(t, "SELECT id, name n FROM users ORDER BY 2")
+	if name, _ := result.Rows[0][1].AsString(); name != "Alice" {
+		t.Errorf("expected 'Alice' first, got %v", result.Rows[0][1])
+	}
+
+	if _, err := env.execute(t, "SELECT id FROM users ORDER BY 3"); err == nil {
+		t.Error("expected error for out-of-range ORDER BY position")
+	}
+}
+
+func TestSelectDivisionByZero(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE nums (a INT64)")
+	env.mustExecute(t, "INSERT INTO nums VALUES (1)")
+
+	if _, err := env.execute(t, "SELECT a / 0 FROM nums"); err == nil {
+		t.Error("expected division by zero error")
+	}
+}
+
+// ============================================
+// NULL Handling / CASE Tests
+// ============================================
+
+func setupNullableTable(t *testing.T, env *testEnv) {
+	t.Helper()
+	env.mustExecute(t, "CREATE TABLE people (id INT64, name STRING, nickname STRING, age INT64)")
+	env.mustExecute(t, "INSERT INTO people VALUES (1, 'Alice', 'Ally', 30)")
+	env.mustExecute(t, "INSERT INTO people VALUES (2, 'Bob', NULL, NULL)")
+	env.mustExecute(t, "INSERT INTO people VALUES (3, 'Carol', 'Carol', 17)")
+}
+
+func TestWhereIsNull(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+	setupNullableTable(t, env)
+
+	result := env.mustExecute(t, "SELECT id FROM people WHERE age IS NULL")
+	if result.RowCount() != 1 {
+		t.Fatalf("expected 1 row, got %d", result.RowCount())
+	}
+	if id, _ := result.Rows[0][0].AsInt64(); id != 2 {
+		t.Errorf("expected id 2, got %v", result.Rows[0][0])
+	}
+
+	result = env.mustExecute(t, "SELECT id FROM people WHERE age IS NOT NULL ORDER BY id")
+	if result.RowCount() != 2 {
+		t.Errorf("expected 2 rows, got %d", result.RowCount())
+	}
+}
+
+func TestWhereThreeValuedLogic(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+	setupNullableTable(t, env)
+
+	tests := []struct {
+		where string
+		want  int
+	}{
+		{"age > 18", 1},
+		{"NOT age > 18", 1},
+		{"age > 18 OR age <= 18", 2},
+		{"age > 18 OR id = 2", 2},
+		{"age > 100 AND id = 2", 0},
+		{"NOT (age > 100 AND id = 2)", 2},
+		{"NOT (age > 100 AND id = 3)", 3},
+		{"NULL OR TRUE", 3},
+		{"NULL AND TRUE", 0},
+		{"nickname <> name", 1},
+		{"nickname IS DISTINCT FROM name", 2},
+		{"nickname IS NOT DISTINCT FROM name", 1},
+	}
+
+	for _, tt := range tests {
+		result := env.mustExecute(t, "SELECT id FROM people WHERE "+tt.where)
+		if result.RowCount() != tt.want {
+			t.Errorf("WHERE %s: expected %d rows, got %d", tt.where, tt.want, result.RowCount())
+		}
+	}
+}
+
+func TestCaseExpression(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+	setupNullableTable(t, env)
+
+	result := env.mustExecute(t, `
+		SELECT
+			CASE WHEN age >= 18 THEN 'adult' WHEN age < 18 THEN 'minor' ELSE 'unknown' END AS category,
+			CASE id WHEN 1 THEN 'one' WHEN 2 THEN 'two' END AS word
+		FROM people ORDER BY id
+	`)
+
+	categories := []string{"adult", "unknown", "minor"}
+	for i, want := range categories {
+		if got, _ := result.Rows[i][0].AsString(); got != want {
+			t.Errorf("row %d: expected category %q, got %v", i, want, result.Rows[i][0])
+		}
+	}
+
+	if !result.Rows[2][1].IsNull {
+		t.Errorf("expected NULL for CASE without ELSE, got %v", result.Rows[2][1])
+	}
+}
+
+func TestCoalesceAndNullIf(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+	setupNullableTable(t, env)
+
+	result := env.mustExecute(t,
+		"SELECT COALESCE(nickname, name), NULLIF(nickname, name), coalesce(age, -1) FROM people ORDER BY id")
+
+	if got, _ := result.Rows[1][0].AsString(); got != "Bob" {
+		t.Errorf("expected COALESCE to fall back to 'Bob', got %v", result.Rows[1][0])
+	}
+	if got, _ := result.Rows[0][1].AsString(); got != "Ally" {
+		t.Errorf("expected NULLIF to keep 'Ally', got %v", result.Rows[0][1])
+	}
+	if !result.Rows[2][1].IsNull {
+		t.Errorf("expected NULLIF to return NULL for equal values, got %v", result.Rows[2][1])
+	}
+	if got, _ := result.Rows[1][2].AsInt64(); got != -1 {
+		t.Errorf("expected -1, got %v", result.Rows[1][2])
+	}
+}
+
+func TestCaseAndCoalesceTypes(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+	setupNullableTable(t, env)
+
+	// Branches of numeric types promote to the wider type, whichever
+	// branch is chosen.
+	tests := []struct {
+		sql  string
+		want storage.DataType
+	}{
+		{"SELECT CASE WHEN id = 1 THEN 1 ELSE 2.5 END FROM people WHERE id = 1", storage.TypeFloat64},
+		{"SELECT CASE WHEN id = 1 THEN age ELSE CAST(1 AS DECIMAL(4,1)) END FROM people WHERE id = 1", storage.TypeDecimal},
+		{"SELECT COALESCE(age, 0.5) FROM people WHERE id = 1", storage.TypeFloat64},
+		{"SELECT COALESCE(NULL, '2026-01-02', CAST('2026-01-01' AS DATE)) FROM people WHERE id = 1", storage.TypeDate},
+		{"SELECT CASE WHEN id = 1 THEN 'x' ELSE name END FROM people WHERE id = 1", storage.TypeString},
+	}
+	for _, tt := range tests {
+		result := env.mustExecute(t, tt.sql)
+		if got := result.Rows[0][0].Type; got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.sql, tt.want, got)
+		}
+	}
+
+	// Incompatible branches fail even for rows where the other branch is
+	// chosen or the column is NULL.
+	for _, sql := range []string{
+		"SELECT CASE WHEN id = 1 THEN 1 ELSE 'a' END FROM people",
+		"SELECT CASE WHEN id = 1 THEN 'a' ELSE 1 END FROM people",
+		"SELECT CASE WHEN id = 1 THEN name ELSE age END FROM people",
+		"SELECT COALESCE(age, 'x') FROM people WHERE id = 2",
+		"SELECT COALESCE(age, TRUE) FROM people WHERE id = 1",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+
+	result := env.mustExecute(t, "SELECT COALESCE(age, '5') FROM people WHERE id = 2")
+	if got, _ := result.Rows[0][0].AsInt64(); got != 5 {
+		t.Errorf("expected the string literal to convert to 5, got %v", result.Rows[0][0])
+	}
+}
+
+func TestCaseAndCoalesceDecimalScale(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE prices (id INT64, price DECIMAL(10,2), cost DECIMAL(5,3))")
+	env.mustExecute(t, "INSERT INTO prices VALUES (1, 1.5, 0.25)")
+	env.mustExecute(t, "INSERT INTO prices VALUES (2, NULL, NULL)")
+
+	// The DECIMAL keeps its scale whichever branch is chosen, and widens to
+	// the other branches.
+	tests := []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT COALESCE(price, 0) FROM prices ORDER BY id", []string{"1.50", "0.00"}},
+		{"SELECT CASE WHEN price IS NULL THEN 0 ELSE price END FROM prices ORDER BY id", []string{"1.50", "0.00"}},
+		{"SELECT COALESCE(price, cost) FROM prices ORDER BY id", []string{"1.500", "NULL"}},
+		{"SELECT COALESCE(price, 12345678901) FROM prices ORDER BY id", []string{"1.50", "12345678901.00"}},
+		{"SELECT COALESCE(price, 0.125) FROM prices ORDER BY id", []string{"1.500", "0.125"}},
+	}
+	for _, tt := range tests {
+		result := env.mustExecute(t, tt.sql)
+		for i, want := range tt.want {
+			if got := result.Rows[i][0].String(); got != want {
+				t.Errorf("%s: row %d: expected %s, got %s", tt.sql, i, want, got)
+			}
+		}
+	}
+}
+
+func TestWhereNonBooleanCondition(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+	setupNullableTable(t, env)
+
+	if _, err := env.execute(t, "SELECT id FROM people WHERE age"); err == nil {
+		t.Error("expected error for non-boolean WHERE condition")
+	}
+	if _, err := env.execute(t, "SELECT id FROM people WHERE name = 1"); err == nil {
+		t.Error("expected error comparing STRING with INT64")
+	}
+}
+
+// ============================================
+// String Function Tests
+// ============================================
+
+func TestLikePatterns(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE logs (msg STRING)")
+	env.mustExecute(t, "INSERT INTO logs VALUES ('ERROR: disk full')")
+	env.mustExecute(t, "INSERT INTO logs VALUES ('error: timeout')")
+	env.mustExecute(t, "INSERT INTO logs VALUES ('100% done')")
+	env.mustExecute(t, "INSERT INTO logs VALUES ('warn_1')")
+	env.mustExecute(t, "INSERT INTO logs VALUES (NULL)")
+
+	tests := []struct {
+		where string
+		want  int
+	}{
+		{"msg LIKE 'ERROR%'", 1},
+		{"msg ILIKE 'error%'", 2},
+		{"msg NOT LIKE 'ERROR%'", 3},
+		{"msg LIKE '%!%%' ESCAPE '!'", 1},
+		{"msg LIKE '%\\%%'", 1},
+		{"msg LIKE 'warn__'", 1},
+		{"msg LIKE 'warn\\_1'", 1},
+		{"msg LIKE '_____: %'", 2},
+		{"msg ~ '^[a-z]+:'", 1},
+		{"msg !~ 'o'", 2},
+	}
+
+	for _, tt := range tests {
+		result := env.mustExecute(t, "SELECT msg FROM logs WHERE "+tt.where)
+		if result.RowCount() != tt.want {
+			t.Errorf("WHERE %s: expected %d rows, got %d", tt.where, tt.want, result.RowCount())
+		}
+	}
+}
+
+func TestStringFunctions(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE logs (path STRING)")
+	env.mustExecute(t, "INSERT INTO logs VALUES ('  /api/v1/Users  ')")
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"LOWER(TRIM(path))", "/api/v1/users"},
+		{"UPPER(TRIM(path))", "/API/V1/USERS"},
+		{"SUBSTRING(TRIM(path), 2, 3)", "api"},
+		{"SUBSTRING(TRIM(path) FROM 9)", "Users"},
+		{"SUBSTRING(TRIM(path) FROM 0 FOR 3)", "/a"},
+		{"TRIM(LEADING '/' FROM TRIM(path))", "api/v1/Users"},
+		{"RTRIM(path)", "  /api/v1/Users"},
+		{"REPLACE(TRIM(path), '/', '.')", ".api.v1.Users"},
+		{"SPLIT_PART(TRIM(path), '/', 3)", "v1"},
+		{"SPLIT_PART(TRIM(path), '/', -1)", "Users"},
+		{"CONCAT('GET ', NULL, TRIM(path), 1)", "GET /api/v1/Users1"},
+		{"'GET ' || TRIM(path) || ' ' || 2.5", "GET /api/v1/Users 2.5"},
+	}
+
+	for _, tt := range tests {
+		result := env.mustExecute(t, "SELECT "+tt.expr+" FROM logs")
+		got, ok := result.Rows[0][0].AsString()
+		if !ok || got != tt.want {
+			t.Errorf("%s: expected %q, got %v", tt.expr, tt.want, result.Rows[0][0])
+		}
+	}
+
+	result := env.mustExecute(t,
+		"SELECT LENGTH('日本語'), STARTS_WITH(TRIM(path), '/api'), 'x' || NULL FROM logs")
+	if n, _ := result.Rows[0][0].AsInt64(); n != 3 {
+		t.Errorf("expected LENGTH 3, got %v", result.Rows[0][0])
+	}
+	if ok, _ := result.Rows[0][1].AsBool(); !ok {
+		t.Errorf("expected STARTS_WITH to be true, got %v", result.Rows[0][1])
+	}
+	if !result.Rows[0][2].IsNull {
+		t.Errorf("expected || with NULL to be NULL, got %v", result.Rows[0][2])
+	}
+
+	if _, err := env.execute(t, "SELECT LOWER(1) FROM logs"); err == nil {
+		t.Error("expected error for LOWER on INT64")
+	}
+	if _, err := env.execute(t, "SELECT NO_SUCH_FUNCTION(path) FROM logs"); err == nil {
+		t.Error("expected error for unknown function")
+	}
+}
+
+// ============================================
+// Numeric Function Tests
+// ============================================
+
+func TestArithmeticPromotion(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE nums (i INT64, f FLOAT64)")
+	env.mustExecute(t, "INSERT INTO nums VALUES (7, 2.0)")
+
+	result := env.mustExecute(t, "SELECT i / 2, i / f, i + f, -i * 2, i % 3 FROM nums")
+
+	if v, ok := result.Rows[0][0].AsInt64(); !ok || v != 3 {
+		t.Errorf("expected INT64 division to truncate to 3, got %v", result.Rows[0][0])
+	}
+	if v, ok := result.Rows[0][1].AsFloat64(); !ok || v != 3.5 {
+		t.Errorf("expected FLOAT64 3.5, got %v", result.Rows[0][1])
+	}
+	if v, ok := result.Rows[0][2].AsFloat64(); !ok || v != 9 {
+		t.Errorf("expected FLOAT64 9, got %v", result.Rows[0][2])
+	}
+	if v, ok := result.Rows[0][3].AsInt64(); !ok || v != -14 {
+		t.Errorf("expected -14, got %v", result.Rows[0][3])
+	}
+	if v, ok := result.Rows[0][4].AsInt64(); !ok || v != 1 {
+		t.Errorf("expected 1, got %v", result.Rows[0][4])
+	}
+}
+
+func TestArithmeticErrors(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE nums (i INT64, f FLOAT64)")
+	env.mustExecute(t, "INSERT INTO nums VALUES (9223372036854775807, 1e308)")
+
+	queries := []string{
+		"SELECT i + 1 FROM nums",
+		"SELECT i * 2 FROM nums",
+		"SELECT -i - 2 FROM nums",
+		"SELECT -9223372036854775808 / -1 FROM nums",
+		"SELECT f * 10 FROM nums",
+		"SELECT f / 0 FROM nums",
+		"SELECT i % 0 FROM nums",
+		"SELECT ABS(-9223372036854775808) FROM nums",
+		"SELECT SQRT(-1) FROM nums",
+		"SELECT LN(0) FROM nums",
+		"SELECT 'a' + 1 FROM nums",
+	}
+	for _, q := range queries {
+		if _, err := env.execute(t, q); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+}
+
+func TestMathFunctions(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE nums (i INT64, f FLOAT64)")
+	env.mustExecute(t, "INSERT INTO nums VALUES (-15, 2.345)")
+
+	floatTests := []struct {
+		expr string
+		want float64
+	}{
+		{"ABS(-f)", 2.345},
+		{"ROUND(f, 2)", 2.35},
+		{"ROUND(f)", 2},
+		{"CEIL(f)", 3},
+		{"FLOOR(-f)", -3},
+		{"POWER(2, 10)", 1024},
+		{"SQRT(16)", 4},
+		{"LN(1)", 0},
+		{"GREATEST(i, f, NULL)", 2.345},
+		{"MOD(f, 1)", 0.345},
+	}
+	for _, tt := range floatTests {
+		result := env.mustExecute(t, "SELECT "+tt.expr+" FROM nums")
+		got, ok := result.Rows[0][0].AsFloat64()
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, result.Rows[0][0])
+		}
+	}
+
+	intTests := []struct {
+		expr string
+		want int64
+	}{
+		{"ABS(i)", 15},
+		{"ROUND(i, -1)", -20},
+		{"CEIL(i)", -15},
+		{"MOD(i, 4)", -3},
+		{"LEAST(i, 3, NULL)", -15},
+		{"GREATEST(i, 3)", 3},
+	}
+	for _, tt := range intTests {
+		result := env.mustExecute(t, "SELECT "+tt.expr+" FROM nums")
+		got, ok := result.Rows[0][0].AsInt64()
+		if !ok || got != tt.want {
+			t.Errorf("%s: expected %d, got %v", tt.expr, tt.want, result.Rows[0][0])
+		}
+	}
+
+	result := env.mustExecute(t, "SELECT ROUND(NULL, 2), GREATEST(NULL, NULL) FROM nums")
+	if !result.Rows[0][0].IsNull || !result.Rows[0][1].IsNull {
+		t.Errorf("expected NULL results, got %v", result.Rows[0])
+	}
+}
+
+// ============================================
+// CAST / Type Conversion Tests
+// ============================================
+
+func TestCastExpressions(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE t (s STRING, f FLOAT64)")
+	env.mustExecute(t, "INSERT INTO t VALUES (' 42 ', 2.5)")
+
+	result := env.mustExecute(t,
+		"SELECT CAST(s AS INT64), s::FLOAT64, f::INT64, CAST(f AS STRING), 'yes'::BOOL, CAST(TRUE AS INT64), NULL::INT64 FROM t")
+	row := result.Rows[0]
+
+	if v, ok := row[0].AsInt64(); !ok || v != 42 {
+		t.Errorf("CAST(s AS INT64): expected 42, got %v", row[0])
+	}
+	if v, ok := row[1].AsFloat64(); !ok || v != 42 {
+		t.Errorf("s::FLOAT64: expected 42, got %v", row[1])
+	}
+	if v, ok := row[2].AsInt64(); !ok || v != 3 {
+		t.Errorf("f::INT64: expected 3, got %v", row[2])
+	}
+	if v, ok := row[3].AsString(); !ok || v != "2.5" {
+		t.Errorf("CAST(f AS STRING): expected '2.5', got %v", row[3])
+	}
+	if v, ok := row[4].AsBool(); !ok || !v {
+		t.Errorf("'yes'::BOOL: expected true, got %v", row[4])
+	}
+	if v, ok := row[5].AsInt64(); !ok || v != 1 {
+		t.Errorf("CAST(TRUE AS INT64): expected 1, got %v", row[5])
+	}
+	if !row[6].IsNull {
+		t.Errorf("NULL::INT64: expected NULL, got %v", row[6])
+	}
+
+	for _, q := range []string{
+		"SELECT CAST('abc' AS INT64) FROM t",
+		"SELECT CAST(f AS BOOL) FROM t",
+		"SELECT CAST('99999999999999999999' AS INT64) FROM t",
+		"SELECT CAST(s AS WIDGET) FROM t",
+	} {
+		if _, err := env.execute(t, q); err == nil {
+			t.Errorf("%s: expected error", q)
+		}
+	}
+}
+
+func TestInsertAssignmentCoercion(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE t (i INT64, f FLOAT64, s STRING, b BOOL)")
+	env.mustExecute(t, "INSERT INTO t VALUES ('42', 1, 3.5, 'true')")
+	env.mustExecute(t, "INSERT INTO t VALUES (2.6, '1.25', 7, NULL)")
+
+	result := env.mustExecute(t, "SELECT * FROM t")
+
+	if v, ok := result.Rows[0][0].AsInt64(); !ok || v != 42 {
+		t.Errorf("expected 42, got %v", result.Rows[0][0])
+	}
+	if v, ok := result.Rows[0][1].AsFloat64(); !ok || v != 1 {
+		t.Errorf("expected 1.0, got %v", result.Rows[0][1])
+	}
+	if v, ok := result.Rows[0][2].AsString(); !ok || v != "3.5" {
+		t.Errorf("expected '3.5', got %v", result.Rows[0][2])
+	}
+	if v, ok := result.Rows[0][3].AsBool(); !ok || !v {
+		t.Errorf("expected true, got %v", result.Rows[0][3])
+	}
+	if v, ok := result.Rows[1][0].AsInt64(); !ok || v != 3 {
+		t.Errorf("expected 3, got %v", result.Rows[1][0])
+	}
+	if v, ok := result.Rows[1][1].AsFloat64(); !ok || v != 1.25 {
+		t.Errorf("expected 1.25, got %v", result.Rows[1][1])
+	}
+}
+
+func TestInsertRejectsImpossibleConversion(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE t (age INT64, active BOOL)")
+
+	_, err := env.execute(t, "INSERT INTO t VALUES ('abc', TRUE)")
+	if err == nil || !strings.Contains(err.Error(), `"age"`) {
+		t.Errorf("expected error naming column age, got %v", err)
+	}
+
+	_, err = env.execute(t, "INSERT INTO t (active) VALUES (1.5)")
+	if err == nil || !strings.Contains(err.Error(), `"active"`) {
+		t.Errorf("expected error naming column active, got %v", err)
+	}
+
+	if _, err := env.execute(t, "INSERT INTO t (age, age) VALUES (1, 2)"); err == nil {
+		t.Error("expected error for duplicate column")
+	}
+	if _, err := env.execute(t, "INSERT INTO t (age, active) VALUES (1)"); err == nil {
+		t.Error("expected error for column count mismatch")
+	}
+}
+
+// ============================================
+// Constraint Tests
+// ============================================
+
+func TestCreateTableNotNull(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 NOT NULL, name STRING NULL, email STRING)")
+
+	schema, _ := env.catalog.GetTable("users")
+	expected := []bool{false, true, true}
+	for i, want := range expected {
+		if schema.Columns[i].Nullable != want {
+			t.Errorf("column %s: expected nullable=%v", schema.Columns[i].Name, want)
+		}
+	}
+}
+
+func TestInsertNullIntoNotNullColumn(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 NOT NULL, name STRING)")
+
+	_, err := env.execute(t, "INSERT INTO users VALUES (NULL, 'Alice')")
+	if err == nil || !strings.Contains(err.Error(), `"id"`) {
+		t.Errorf("expected not-null violation naming id, got %v", err)
+	}
+
+	_, err = env.execute(t, "INSERT INTO users (name) VALUES ('Bob')")
+	if err == nil {
+		t.Error("expected not-null violation for omitted column")
+	}
+
+	result := env.mustExecute(t, "SELECT * FROM users")
+	if result.RowCount() != 0 {
+		t.Errorf("expected rejected rows to leave table empty, got %d rows", result.RowCount())
+	}
+}
+
+func TestTableInsertRejectsWrongType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64, name STRING)")
+	table, err := env.exec.getTable("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = table.Insert([]storage.Value{storage.NewStringValue("1"), storage.NewStringValue("Alice")})
+	if err == nil || !strings.Contains(err.Error(), `"id"`) {
+		t.Errorf("expected type error naming id, got %v", err)
+	}
+	if table.RowCount() != 0 {
+		t.Errorf("expected no rows after rejected insert, got %d", table.RowCount())
+	}
+}
+
+// ============================================
+// DEFAULT / Generated Column Tests
+// ============================================
+
+func TestColumnDefaults(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, `
+		CREATE TABLE tasks (
+			id INT64 NOT NULL,
+			status STRING DEFAULT 'todo' NOT NULL,
+			priority INT64 DEFAULT 1 + 2,
+			score FLOAT64 DEFAULT '0.5',
+			note STRING
+		)
+	`)
+	env.mustExecute(t, "INSERT INTO tasks (id) VALUES (1)")
+	env.mustExecute(t, "INSERT INTO tasks (id, status, priority) VALUES (2, 'done', NULL)")
+	env.mustExecute(t, "INSERT INTO tasks VALUES (3, DEFAULT, DEFAULT, 1.5, 'x')")
+
+	result := env.mustExecute(t, "SELECT status, priority, score FROM tasks ORDER BY id")
+
+	if v, _ := result.Rows[0][0].AsString(); v != "todo" {
+		t.Errorf("expected default 'todo', got %v", result.Rows[0][0])
+	}
+	if v, _ := result.Rows[0][1].AsInt64(); v != 3 {
+		t.Errorf("expected default 3, got %v", result.Rows[0][1])
+	}
+	if v, _ := result.Rows[0][2].AsFloat64(); v != 0.5 {
+		t.Errorf("expected default 0.5, got %v", result.Rows[0][2])
+	}
+	if !result.Rows[1][1].IsNull {
+		t.Errorf("expected explicit NULL to override default, got %v", result.Rows[1][1])
+	}
+	if v, _ := result.Rows[2][0].AsString(); v != "todo" {
+		t.Errorf("expected DEFAULT keyword to use default, got %v", result.Rows[2][0])
+	}
+
+	schema, _ := env.catalog.GetTable("tasks")
+	if schema.Columns[1].Nullable {
+		t.Error("expected status to be NOT NULL")
+	}
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, `
+		CREATE TABLE items (
+			price FLOAT64,
+			qty INT64,
+			total FLOAT64 GENERATED ALWAYS AS (price * qty) STORED,
+			label STRING GENERATED ALWAYS AS (UPPER(COALESCE('x' || qty, '-'))) STORED
+		)
+	`)
+	env.mustExecute(t, "INSERT INTO items (price, qty) VALUES (2.5, 4)")
+	env.mustExecute(t, "INSERT INTO items VALUES (1.0, NULL, DEFAULT, DEFAULT)")
+
+	result := env.mustExecute(t, "SELECT total, label FROM items")
+	if v, _ := result.Rows[0][0].AsFloat64(); v != 10 {
+		t.Errorf("expected total 10, got %v", result.Rows[0][0])
+	}
+	if v, _ := result.Rows[0][1].AsString(); v != "X4" {
+		t.Errorf("expected label 'X4', got %v", result.Rows[0][1])
+	}
+	if !result.Rows[1][0].IsNull {
+		t.Errorf("expected NULL total, got %v", result.Rows[1][0])
+	}
+
+	if _, err := env.execute(t, "INSERT INTO items VALUES (1.0, 1, 5.0, 'y')"); err == nil {
+		t.Error("expected error inserting into generated column")
+	}
+
+	for _, ddl := range []string{
+		"CREATE TABLE bad1 (a INT64 DEFAULT b, b INT64)",
+		"CREATE TABLE bad2 (a INT64, b INT64 GENERATED ALWAYS AS (c) STORED)",
+		"CREATE TABLE bad3 (a INT64, b INT64 GENERATED ALWAYS AS (a) STORED, c INT64 GENERATED ALWAYS AS (b) STORED)",
+	} {
+		if _, err := env.execute(t, ddl); err == nil {
+			t.Errorf("%s: expected error", ddl)
+		}
+	}
+}
+
+func TestColumnDefaultsPersist(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE t (a INT64, b STRING DEFAULT 'it''s', c INT64 GENERATED ALWAYS AS (a * 2) STORED)")
+
+	catalog, err := storage.NewCatalog(env.dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened := New(catalog, env.dataDir)
+	stmt := parser.NewParser(parser.NewLexer("INSERT INTO t (a) VALUES (21)")).Parse()
+	if _, err := reopened.Execute(stmt); err != nil {
+		t.Fatalf("insert after reopen: %v", err)
+	}
+
+	stmt = parser.NewParser(parser.NewLexer("SELECT b, c FROM t")).Parse()
+	result, err := reopened.Execute(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := result.Rows[0][0].AsString(); v != "it's" {
+		t.Errorf("expected default \"it's\", got %v", result.Rows[0][0])
+	}
+	if v, _ := result.Rows[0][1].AsInt64(); v != 42 {
+		t.Errorf("expected generated 42, got %v", result.Rows[0][1])
+	}
+}
+
+// ============================================
+// PRIMARY KEY / UNIQUE Tests
+// ============================================
+
+func TestPrimaryKeyRejectsDuplicates(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 PRIMARY KEY, email STRING UNIQUE)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'a@example.com')")
+
+	_, err := env.execute(t, "INSERT INTO users VALUES (1, 'b@example.com')")
+	if err == nil || !strings.Contains(err.Error(), `unique constraint "users_pkey"`) {
+		t.Fatalf("expected primary key violation, got %v", err)
+	}
+	_, err = env.execute(t, "INSERT INTO users VALUES (2, 'a@example.com')")
+	if err == nil || !strings.Contains(err.Error(), `unique constraint "users_email_key"`) {
+		t.Fatalf("expected unique violation, got %v", err)
+	}
+
+	if _, err := env.execute(t, "INSERT INTO users VALUES (NULL, 'c@example.com')"); err == nil {
+		t.Error("expected error for NULL primary key")
+	}
+
+	// NULLs never conflict in a UNIQUE column.
+	env.mustExecute(t, "INSERT INTO users VALUES (2, NULL)")
+	env.mustExecute(t, "INSERT INTO users VALUES (3, NULL)")
+
+	result := env.mustExecute(t, "SELECT id FROM users")
+	if result.RowCount() != 3 {
+		t.Errorf("expected 3 rows, got %d", result.RowCount())
+	}
+}
+
+func TestTableConstraints(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE memberships (user_id INT64, group_id INT64, role STRING, PRIMARY KEY (user_id, group_id), CONSTRAINT one_role UNIQUE (group_id, role))")
+
+	schema, _ := env.catalog.GetTable("memberships")
+	if len(schema.UniqueKeys) != 2 || schema.UniqueKeys[1].Name != "one_role" {
+		t.Fatalf("unexpected unique keys: %+v", schema.UniqueKeys)
+	}
+	if schema.Columns[0].Nullable || schema.Columns[1].Nullable {
+		t.Error("primary key columns should be NOT NULL")
+	}
+
+	env.mustExecute(t, "INSERT INTO memberships VALUES (1, 1, 'owner')")
+	env.mustExecute(t, "INSERT INTO memberships VALUES (1, 2, 'owner')")
+	if _, err := env.execute(t, "INSERT INTO memberships VALUES (1, 1, 'member')"); err == nil {
+		t.Error("expected composite primary key violation")
+	}
+	if _, err := env.execute(t, "INSERT INTO memberships VALUES (2, 1, 'owner')"); err == nil {
+		t.Error("expected named unique constraint violation")
+	}
+
+	for _, ddl := range []string{
+		"CREATE TABLE bad1 (a INT64 PRIMARY KEY, b INT64 PRIMARY KEY)",
+		"CREATE TABLE bad2 (a INT64, PRIMARY KEY (c))",
+		"CREATE TABLE bad3 (a INT64, UNIQUE (a, a))",
+	} {
+		if _, err := env.execute(t, ddl); err == nil {
+			t.Errorf("%s: expected error", ddl)
+		}
+	}
+}
+
+func TestUniqueIndexPersistence(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (sku STRING PRIMARY KEY, qty INT64)")
+	env.mustExecute(t, "INSERT INTO items VALUES ('a', 1)")
+	env.mustExecute(t, "INSERT INTO items VALUES ('b', 2)")
+
+	indexPath := env.dataDir + "/tables/items/idx_items_pkey.dat"
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatalf("index file not written: %v", err)
+	}
+
+	env.reopen(t)
+	if _, err := env.execute(t, "INSERT INTO items VALUES ('a', 3)"); err == nil {
+		t.Error("expected violation after reopen")
+	}
+
+	// A missing index file is rebuilt from the column data.
+	if err := os.Remove(indexPath); err != nil {
+		t.Fatal(err)
+	}
+	env.reopen(t)
+	if _, err := env.execute(t, "INSERT INTO items VALUES ('b', 3)"); err == nil {
+		t.Error("expected violation after index rebuild")
+	}
+	env.mustExecute(t, "INSERT INTO items VALUES ('c', 3)")
+
+	result := env.mustExecute(t, "SELECT sku FROM items")
+	if result.RowCount() != 3 {
+		t.Errorf("expected 3 rows, got %d", result.RowCount())
+	}
+}
+
+// ============================================
+// UPDATE Tests
+// ============================================
+
+func TestUpdate(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 PRIMARY KEY, name STRING, age INT64 DEFAULT 0, next_age INT64 GENERATED ALWAYS AS (age + 1) STORED)")
+	env.mustExecute(t, "INSERT INTO users (id, name, age) VALUES (1, 'Alice', 30)")
+	env.mustExecute(t, "INSERT INTO users (id, name, age) VALUES (2, 'Bob', 25)")
+	env.mustExecute(t, "INSERT INTO users (id, name, age) VALUES (3, 'Charlie', 35)")
+
+	result := env.mustExecute(t, "UPDATE users SET age = age + 1, name = UPPER(name) WHERE age >= 30")
+	if result.Message != "2 row(s) updated" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+
+	result = env.mustExecute(t, "SELECT name, age, next_age FROM users ORDER BY id")
+	expected := []struct {
+		name         string
+		age, nextAge int64
+	}{{"ALICE", 31, 32}, {"Bob", 25, 26}, {"CHARLIE", 36, 37}}
+	for i, want := range expected {
+		name, _ := result.Rows[i][0].AsString()
+		age, _ := result.Rows[i][1].AsInt64()
+		nextAge, _ := result.Rows[i][2].AsInt64()
+		if name != want.name || age != want.age || nextAge != want.nextAge {
+			t.Errorf("row %d: got (%s, %d, %d), want %+v", i, name, age, nextAge, want)
+		}
+	}
+
+	env.mustExecute(t, "UPDATE users SET age = DEFAULT WHERE id = 2")
+	result = env.mustExecute(t, "SELECT age FROM users WHERE id = 2")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 0 {
+		t.Errorf("expected default 0, got %v", result.Rows[0][0])
+	}
+
+	result = env.mustExecute(t, "UPDATE users SET age = 1 WHERE id = 99")
+	if result.Message != "0 row(s) updated" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+}
+
+func TestUpdateConstraintViolation(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 PRIMARY KEY, name STRING NOT NULL)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alice')")
+	env.mustExecute(t, "INSERT INTO users VALUES (2, 'Bob')")
+
+	if _, err := env.execute(t, "UPDATE users SET id = 1 WHERE id = 2"); err == nil {
+		t.Error("expected primary key violation")
+	}
+	if _, err := env.execute(t, "UPDATE users SET name = NULL"); err == nil {
+		t.Error("expected not-null violation")
+	}
+
+	// Keys may be swapped in one statement since uniqueness is checked
+	// against the final rows.
+	env.mustExecute(t, "UPDATE users SET id = 3 - id")
+
+	result := env.mustExecute(t, "SELECT name FROM users WHERE id = 1")
+	if v, _ := result.Rows[0][0].AsString(); v != "Bob" {
+		t.Errorf("expected Bob, got %v", result.Rows[0][0])
+	}
+
+	for _, sql := range []string{
+		"UPDATE users SET missing = 1",
+		"UPDATE users SET id = 1, id = 2",
+		"UPDATE users SET name = 'x' WHERE missing = 1",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestUpdateGeneratedColumn(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE t (a INT64, b INT64 GENERATED ALWAYS AS (a * 2) STORED)")
+	env.mustExecute(t, "INSERT INTO t (a) VALUES (1)")
+
+	if _, err := env.execute(t, "UPDATE t SET b = 5"); err == nil {
+		t.Error("expected error updating generated column")
+	}
+	env.mustExecute(t, "UPDATE t SET b = DEFAULT")
+}
+
+// ============================================
+// ON CONFLICT / MERGE Tests
+// ============================================
+
+func TestInsertOnConflictDoNothing(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 PRIMARY KEY, name STRING)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alice')")
+
+	result := env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alicia') ON CONFLICT (id) DO NOTHING")
+	if result.Message != "0 rows inserted" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alicia') ON CONFLICT DO NOTHING")
+	env.mustExecute(t, "INSERT INTO users VALUES (2, 'Bob') ON CONFLICT ON CONSTRAINT users_pkey DO NOTHING")
+
+	result = env.mustExecute(t, "SELECT name FROM users ORDER BY id")
+	if result.RowCount() != 2 {
+		t.Fatalf("expected 2 rows, got %d", result.RowCount())
+	}
+	if v, _ := result.Rows[0][0].AsString(); v != "Alice" {
+		t.Errorf("expected Alice, got %v", result.Rows[0][0])
+	}
+}
+
+func TestInsertOnConflictDoUpdate(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE counters (name STRING PRIMARY KEY, hits INT64, note STRING)")
+	env.mustExecute(t, "INSERT INTO counters VALUES ('home', 1, 'first')")
+
+	upsert := "INSERT INTO counters VALUES ('home', 5, 'again') ON CONFLICT (name) DO UPDATE SET hits = counters.hits + excluded.hits, note = excluded.note"
+	result := env.mustExecute(t, upsert)
+	if result.Message != "1 row updated" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+	env.mustExecute(t, "INSERT INTO counters VALUES ('about', 1, NULL) ON CONFLICT (name) DO UPDATE SET hits = counters.hits + 1")
+
+	result = env.mustExecute(t, "SELECT name, hits, note FROM counters ORDER BY name")
+	if result.RowCount() != 2 {
+		t.Fatalf("expected 2 rows, got %d", result.RowCount())
+	}
+	if v, _ := result.Rows[1][1].AsInt64(); v != 6 {
+		t.Errorf("expected hits 6, got %v", result.Rows[1][1])
+	}
+	if v, _ := result.Rows[1][2].AsString(); v != "again" {
+		t.Errorf("expected note again, got %v", result.Rows[1][2])
+	}
+
+	// The WHERE clause can veto the update.
+	env.mustExecute(t, "INSERT INTO counters VALUES ('home', 1, 'x') ON CONFLICT (name) DO UPDATE SET hits = 0 WHERE counters.hits > 100")
+	result = env.mustExecute(t, "SELECT hits FROM counters WHERE name = 'home'")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 6 {
+		t.Errorf("expected hits unchanged at 6, got %v", result.Rows[0][0])
+	}
+
+	for _, sql := range []string{
+		"INSERT INTO counters VALUES ('home', 1, 'x') ON CONFLICT DO UPDATE SET hits = 0",
+		"INSERT INTO counters VALUES ('home', 1, 'x') ON CONFLICT (hits) DO NOTHING",
+		"INSERT INTO counters VALUES ('home', 1, 'x') ON CONFLICT ON CONSTRAINT nope DO NOTHING",
+		"INSERT INTO counters VALUES ('home', 1, 'x') ON CONFLICT (name) DO UPDATE SET hits = hits + 1",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE stock (sku STRING PRIMARY KEY, qty INT64)")
+	env.mustExecute(t, "INSERT INTO stock VALUES ('a', 10)")
+	env.mustExecute(t, "INSERT INTO stock VALUES ('b', 5)")
+	env.mustExecute(t, "INSERT INTO stock VALUES ('c', 1)")
+
+	env.mustExecute(t, "CREATE TABLE delivery (sku STRING, qty INT64)")
+	env.mustExecute(t, "INSERT INTO delivery VALUES ('a', 3)")
+	env.mustExecute(t, "INSERT INTO delivery VALUES ('c', -1)")
+	env.mustExecute(t, "INSERT INTO delivery VALUES ('d', 7)")
+	env.mustExecute(t, "INSERT INTO delivery VALUES ('e', 0)")
+
+	result := env.mustExecute(t, `MERGE INTO stock s USING delivery AS d ON s.sku = d.sku
+		WHEN MATCHED AND s.qty + d.qty = 0 THEN DELETE
+		WHEN MATCHED THEN UPDATE SET qty = s.qty + d.qty
+		WHEN NOT MATCHED AND d.qty = 0 THEN DO NOTHING
+		WHEN NOT MATCHED THEN INSERT (sku, qty) VALUES (d.sku, d.qty)`)
+	if result.Message != "3 row(s) merged" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+
+	result = env.mustExecute(t, "SELECT sku, qty FROM stock ORDER BY sku")
+	expected := map[string]int64{"a": 13, "b": 5, "d": 7}
+	if result.RowCount() != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), result.RowCount())
+	}
+	for _, row := range result.Rows {
+		sku, _ := row[0].AsString()
+		qty, _ := row[1].AsInt64()
+		if want, ok := expected[sku]; !ok || qty != want {
+			t.Errorf("unexpected row (%s, %d)", sku, qty)
+		}
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE target (id INT64 PRIMARY KEY, v INT64)")
+	env.mustExecute(t, "INSERT INTO target VALUES (1, 0)")
+	env.mustExecute(t, "CREATE TABLE src (id INT64, v INT64)")
+	env.mustExecute(t, "INSERT INTO src VALUES (1, 1)")
+	env.mustExecute(t, "INSERT INTO src VALUES (1, 2)")
+	env.mustExecute(t, "INSERT INTO src VALUES (2, 1)")
+	env.mustExecute(t, "INSERT INTO src VALUES (2, 2)")
+
+	for _, sql := range []string{
+		// Two source rows update the same target row.
+		"MERGE INTO target t USING src s ON t.id = s.id WHEN MATCHED THEN UPDATE SET v = s.v",
+		// Two inserted rows violate the primary key.
+		"MERGE INTO target t USING src s ON t.id = s.id WHEN NOT MATCHED THEN INSERT VALUES (s.id, s.v)",
+		"MERGE INTO target USING target ON id = id WHEN MATCHED THEN DELETE",
+		"MERGE INTO target t USING src s ON t.id = s.id WHEN MATCHED THEN UPDATE SET v = missing",
+		"MERGE INTO target t USING src s ON id = s.id WHEN MATCHED THEN DELETE",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+
+	result := env.mustExecute(t, "SELECT v FROM target")
+	if result.RowCount() != 1 {
+		t.Fatalf("expected target unchanged, got %d rows", result.RowCount())
+	}
+	if v, _ := result.Rows[0][0].AsInt64(); v != 0 {
+		t.Errorf("expected target unchanged, got v = %v", result.Rows[0][0])
+	}
+}
+
+func TestQualifiedColumnReference(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64, name STRING)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alice')")
+
+	result := env.mustExecute(t, "SELECT users.name FROM users WHERE users.id = 1")
+	if result.Columns[0] != "users.name" || result.RowCount() != 1 {
+		t.Errorf("unexpected result: %v %v", result.Columns, result.Rows)
+	}
+	if _, err := env.execute(t, "SELECT other.name FROM users"); err == nil {
+		t.Error("expected error for unknown table qualifier")
+	}
+}
+
+// ============================================
+// DELETE / RETURNING Tests
+// ============================================
+
+func TestDelete(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 PRIMARY KEY, name STRING)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alice')")
+	env.mustExecute(t, "INSERT INTO users VALUES (2, 'Bob')")
+	env.mustExecute(t, "INSERT INTO users VALUES (3, 'Charlie')")
+
+	result := env.mustExecute(t, "DELETE FROM users WHERE id >= 2")
+	if result.Message != "2 row(s) deleted" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+
+	// Deleted keys may be reused.
+	env.mustExecute(t, "INSERT INTO users VALUES (2, 'Bea')")
+
+	result = env.mustExecute(t, "SELECT name FROM users ORDER BY id")
+	if result.RowCount() != 2 {
+		t.Fatalf("expected 2 rows, got %d", result.RowCount())
+	}
+	if v, _ := result.Rows[1][0].AsString(); v != "Bea" {
+		t.Errorf("expected Bea, got %v", result.Rows[1][0])
+	}
+
+	result = env.mustExecute(t, "DELETE FROM users")
+	if result.Message != "2 row(s) deleted" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+	if _, err := env.execute(t, "DELETE FROM users WHERE missing = 1"); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestInsertReturning(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (id INT64 PRIMARY KEY, qty INT64 DEFAULT 1, price FLOAT64, total FLOAT64 GENERATED ALWAYS AS (price * qty) STORED)")
+
+	result := env.mustExecute(t, "INSERT INTO items (id, price) VALUES (1, 2.5) RETURNING *")
+	if len(result.Columns) != 4 || result.RowCount() != 1 {
+		t.Fatalf("unexpected result: %v %v", result.Columns, result.Rows)
+	}
+	if v, _ := result.Rows[0][1].AsInt64(); v != 1 {
+		t.Errorf("expected default qty 1, got %v", result.Rows[0][1])
+	}
+	if v, _ := result.Rows[0][3].AsFloat64(); v != 2.5 {
+		t.Errorf("expected total 2.5, got %v", result.Rows[0][3])
+	}
+	if result.Message != "1 row inserted" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+
+	result = env.mustExecute(t, "INSERT INTO items (id, price) VALUES (1, 4) ON CONFLICT (id) DO UPDATE SET qty = items.qty + 1 RETURNING id, qty AS new_qty")
+	if result.Columns[1] != "new_qty" || result.RowCount() != 1 {
+		t.Fatalf("unexpected result: %v %v", result.Columns, result.Rows)
+	}
+	if v, _ := result.Rows[0][1].AsInt64(); v != 2 {
+		t.Errorf("expected qty 2, got %v", result.Rows[0][1])
+	}
+
+	result = env.mustExecute(t, "INSERT INTO items (id, price) VALUES (1, 4) ON CONFLICT DO NOTHING RETURNING id")
+	if len(result.Columns) != 1 || result.RowCount() != 0 {
+		t.Errorf("expected no rows, got %v", result.Rows)
+	}
+
+	if _, err := env.execute(t, "INSERT INTO items (id) VALUES (9) RETURNING missing"); err == nil {
+		t.Error("expected error for unknown column")
+	}
+	result = env.mustExecute(t, "SELECT id FROM items")
+	if result.RowCount() != 1 {
+		t.Errorf("failed RETURNING should not insert, got %d rows", result.RowCount())
+	}
+}
+
+func TestUpdateDeleteReturning(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64, name STRING)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1, 'Alice')")
+	env.mustExecute(t, "INSERT INTO users VALUES (2, 'Bob')")
+
+	result := env.mustExecute(t, "UPDATE users SET name = LOWER(name) WHERE id = 2 RETURNING id, name")
+	if result.RowCount() != 1 {
+		t.Fatalf("expected 1 row, got %d", result.RowCount())
+	}
+	if v, _ := result.Rows[0][1].AsString(); v != "bob" {
+		t.Errorf("expected new value bob, got %v", result.Rows[0][1])
+	}
+
+	result = env.mustExecute(t, "DELETE FROM users WHERE id = 1 RETURNING name || '!' AS farewell")
+	if result.Columns[0] != "farewell" || result.RowCount() != 1 {
+		t.Fatalf("unexpected result: %v %v", result.Columns, result.Rows)
+	}
+	if v, _ := result.Rows[0][0].AsString(); v != "Alice!" {
+		t.Errorf("expected Alice!, got %v", result.Rows[0][0])
+	}
+}
+
+// ============================================
+// Sequence / Identity Tests
+// ============================================
+
+func TestSequence(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE dual (x INT64)")
+	env.mustExecute(t, "INSERT INTO dual VALUES (1)")
+	env.mustExecute(t, "CREATE SEQUENCE order_no START WITH 100 INCREMENT BY 10")
+
+	if _, err := env.execute(t, "SELECT CURRVAL('order_no') FROM dual"); err == nil {
+		t.Error("expected error for currval before nextval")
+	}
+
+	for _, want := range []int64{100, 110, 120} {
+		result := env.mustExecute(t, "SELECT NEXTVAL('order_no') FROM dual")
+		if v, _ := result.Rows[0][0].AsInt64(); v != want {
+			t.Errorf("expected %d, got %v", want, result.Rows[0][0])
+		}
+	}
+	result := env.mustExecute(t, "SELECT CURRVAL('order_no') FROM dual")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 120 {
+		t.Errorf("expected currval 120, got %v", result.Rows[0][0])
+	}
+
+	// Values are never reused after a restart.
+	env.reopen(t)
+	result = env.mustExecute(t, "SELECT NEXTVAL('order_no') FROM dual")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 130 {
+		t.Errorf("expected 130 after reopen, got %v", result.Rows[0][0])
+	}
+
+	env.mustExecute(t, "SELECT SETVAL('order_no', 500) FROM dual")
+	result = env.mustExecute(t, "SELECT NEXTVAL('order_no') FROM dual")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 510 {
+		t.Errorf("expected 510 after setval, got %v", result.Rows[0][0])
+	}
+
+	env.mustExecute(t, "DROP SEQUENCE order_no")
+	if _, err := env.execute(t, "SELECT NEXTVAL('order_no') FROM dual"); err == nil {
+		t.Error("expected error for dropped sequence")
+	}
+}
+
+func TestSequenceBounds(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE dual (x INT64)")
+	env.mustExecute(t, "INSERT INTO dual VALUES (1)")
+	env.mustExecute(t, "CREATE SEQUENCE small MAXVALUE 2")
+	env.mustExecute(t, "CREATE SEQUENCE countdown INCREMENT -1")
+
+	env.mustExecute(t, "SELECT NEXTVAL('small') FROM dual")
+	env.mustExecute(t, "SELECT NEXTVAL('small') FROM dual")
+	if _, err := env.execute(t, "SELECT NEXTVAL('small') FROM dual"); err == nil {
+		t.Error("expected error past MAXVALUE")
+	}
+
+	result := env.mustExecute(t, "SELECT NEXTVAL('countdown'), NEXTVAL('countdown') FROM dual")
+	if v, _ := result.Rows[0][1].AsInt64(); v != -2 {
+		t.Errorf("expected -2, got %v", result.Rows[0][1])
+	}
+
+	for _, sql := range []string{
+		"CREATE SEQUENCE small",
+		"CREATE SEQUENCE bad1 INCREMENT 0",
+		"CREATE SEQUENCE bad2 MINVALUE 10 MAXVALUE 5",
+		"CREATE SEQUENCE bad3 START 0",
+		"DROP SEQUENCE missing",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestIdentityColumn(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, name STRING)")
+
+	result := env.mustExecute(t, "INSERT INTO users (name) VALUES ('Alice') RETURNING id")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 1 {
+		t.Errorf("expected id 1, got %v", result.Rows[0][0])
+	}
+	env.mustExecute(t, "INSERT INTO users VALUES (DEFAULT, 'Bob')")
+	env.mustExecute(t, "INSERT INTO users VALUES (100, 'Explicit')")
+
+	env.reopen(t)
+	result = env.mustExecute(t, "INSERT INTO users (name) VALUES ('Carol') RETURNING id")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 3 {
+		t.Errorf("expected id 3 after reopen, got %v", result.Rows[0][0])
+	}
+
+	schema, _ := env.catalog.GetTable("users")
+	if schema.Columns[0].Nullable || schema.Columns[0].Sequence != "users_id_seq" {
+		t.Errorf("unexpected identity column: %+v", schema.Columns[0])
+	}
+	if _, err := env.execute(t, "DROP SEQUENCE users_id_seq"); err == nil {
+		t.Error("expected error dropping an owned sequence")
+	}
+
+	env.mustExecute(t, "DROP TABLE users")
+	if _, ok := env.catalog.GetSequence("users_id_seq"); ok {
+		t.Error("owned sequence should be dropped with its table")
+	}
+}
+
+func TestIdentityAlways(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE events (id INT64 GENERATED ALWAYS AS IDENTITY (START WITH 10 INCREMENT BY 5), note STRING)")
+	env.mustExecute(t, "INSERT INTO events (note) VALUES ('a')")
+	env.mustExecute(t, "INSERT INTO events (note) VALUES ('b')")
+
+	if _, err := env.execute(t, "INSERT INTO events VALUES (1, 'c')"); err == nil {
+		t.Error("expected error inserting into GENERATED ALWAYS identity")
+	}
+	if _, err := env.execute(t, "UPDATE events SET id = 1"); err == nil {
+		t.Error("expected error updating GENERATED ALWAYS identity")
+	}
+
+	result := env.mustExecute(t, "SELECT id FROM events ORDER BY id")
+	if v, _ := result.Rows[1][0].AsInt64(); v != 15 {
+		t.Errorf("expected 15, got %v", result.Rows[1][0])
+	}
+
+	for _, ddl := range []string{
+		"CREATE TABLE bad1 (id STRING GENERATED ALWAYS AS IDENTITY)",
+		"CREATE TABLE bad2 (id INT64 GENERATED ALWAYS AS IDENTITY DEFAULT 1)",
+	} {
+		if _, err := env.execute(t, ddl); err == nil {
+			t.Errorf("%s: expected error", ddl)
+		}
+	}
+}
+
+func TestIdentityNarrowType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE small (id INT8 GENERATED ALWAYS AS IDENTITY (START WITH 126), note STRING)")
+	env.mustExecute(t, "CREATE TABLE down (id INT16 GENERATED ALWAYS AS IDENTITY (INCREMENT BY -1), note STRING)")
+
+	if seq, _ := env.catalog.GetSequence("small_id_seq"); seq.MaxValue != 127 {
+		t.Errorf("expected MAXVALUE 127, got %d", seq.MaxValue)
+	}
+	if seq, _ := env.catalog.GetSequence("down_id_seq"); seq.MinValue != -32768 || seq.Start != -1 {
+		t.Errorf("expected MINVALUE -32768 and start -1, got %d and %d", seq.MinValue, seq.Start)
+	}
+
+	env.mustExecute(t, "INSERT INTO small (note) VALUES ('a')")
+	env.mustExecute(t, "INSERT INTO small (note) VALUES ('b')")
+	_, err := env.execute(t, "INSERT INTO small (note) VALUES ('c')")
+	if err == nil || !strings.Contains(err.Error(), "reached maximum value of sequence") {
+		t.Errorf("expected sequence exhausted error, got %v", err)
+	}
+
+	for _, ddl := range []string{
+		"CREATE TABLE bad1 (id INT16 GENERATED ALWAYS AS IDENTITY (MAXVALUE 40000))",
+		"CREATE TABLE bad2 (id INT32 GENERATED ALWAYS AS IDENTITY (START WITH 3000000000))",
+		"CREATE TABLE bad3 (id UINT8 GENERATED ALWAYS AS IDENTITY (MINVALUE -1))",
+	} {
+		if _, err := env.execute(t, ddl); err == nil {
+			t.Errorf("%s: expected error", ddl)
+		}
+	}
+}
+
+// ============================================
+// ALTER TABLE Tests
+// ============================================
+
+func TestAlterTableAddColumn(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (id INT64, price INT64)")
+	env.mustExecute(t, "INSERT INTO items VALUES (1, 10)")
+	env.mustExecute(t, "INSERT INTO items VALUES (2, 20)")
+
+	env.mustExecute(t, "ALTER TABLE items ADD COLUMN note STRING")
+	env.mustExecute(t, "ALTER TABLE items ADD qty INT64 NOT NULL DEFAULT 1")
+	env.mustExecute(t, "ALTER TABLE items ADD COLUMN total INT64 GENERATED ALWAYS AS (price * qty) STORED")
+	env.mustExecute(t, "INSERT INTO items (id, price, qty) VALUES (3, 30, 2)")
+
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT id, note, qty, total FROM items ORDER BY id")
+	if result.RowCount() != 3 {
+		t.Fatalf("expected 3 rows, got %d", result.RowCount())
+	}
+	if !result.Rows[0][1].IsNull {
+		t.Errorf("expected NULL note, got %v", result.Rows[0][1])
+	}
+	if v, _ := result.Rows[1][2].AsInt64(); v != 1 {
+		t.Errorf("expected backfilled qty 1, got %v", result.Rows[1][2])
+	}
+	if v, _ := result.Rows[2][3].AsInt64(); v != 60 {
+		t.Errorf("expected total 60, got %v", result.Rows[2][3])
+	}
+
+	for _, sql := range []string{
+		"ALTER TABLE items ADD COLUMN note STRING",
+		"ALTER TABLE items ADD COLUMN code STRING NOT NULL",
+		"ALTER TABLE items ADD COLUMN k INT64 PRIMARY KEY",
+		"ALTER TABLE items ADD COLUMN d INT64 DEFAULT price",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+func TestAlterTableDropColumn(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (id INT64 GENERATED ALWAYS AS IDENTITY, sku STRING UNIQUE, price INT64, tax INT64 GENERATED ALWAYS AS (price / 10) STORED)")
+	env.mustExecute(t, "INSERT INTO items (sku, price) VALUES ('a', 100)")
+
+	if _, err := env.execute(t, "ALTER TABLE items DROP COLUMN price"); err == nil {
+		t.Error("expected error dropping a column used by a generated column")
+	}
+
+	env.mustExecute(t, "ALTER TABLE items DROP COLUMN sku")
+	env.mustExecute(t, "ALTER TABLE items DROP id")
+	if _, err := os.Stat(env.dataDir + "/tables/items/col_sku.dat"); !os.IsNotExist(err) {
+		t.Error("column file should be removed")
+	}
+	if _, ok := env.catalog.GetSequence("items_id_seq"); ok {
+		t.Error("identity sequence should be dropped with its column")
+	}
+
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT * FROM items")
+	if len(result.Columns) != 2 || result.Columns[0] != "price" {
+		t.Errorf("unexpected columns: %v", result.Columns)
+	}
+	schema, _ := env.catalog.GetTable("items")
+	if len(schema.UniqueKeys) != 0 {
+		t.Errorf("unique constraint should be dropped, got %v", schema.UniqueKeys)
+	}
+}
+
+func TestAlterTableRenameColumn(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (sku STRING PRIMARY KEY, price INT64, tax INT64 GENERATED ALWAYS AS (price / 10) STORED)")
+	env.mustExecute(t, "INSERT INTO items (sku, price) VALUES ('a', 100)")
+
+	env.mustExecute(t, "ALTER TABLE items RENAME COLUMN price TO cost")
+	env.mustExecute(t, "ALTER TABLE items RENAME sku TO code")
+	if _, err := os.Stat(env.dataDir + "/tables/items/col_cost.dat"); err != nil {
+		t.Errorf("column file not renamed: %v", err)
+	}
+
+	env.reopen(t)
+	env.mustExecute(t, "INSERT INTO items (code, cost) VALUES ('b', 50)")
+	if _, err := env.execute(t, "INSERT INTO items (code, cost) VALUES ('a', 1)"); err == nil {
+		t.Error("expected primary key violation on renamed column")
+	}
+	result := env.mustExecute(t, "SELECT code, tax FROM items ORDER BY code")
+	if v, _ := result.Rows[1][1].AsInt64(); v != 5 {
+		t.Errorf("expected tax 5, got %v", result.Rows[1][1])
+	}
+
+	if _, err := env.execute(t, "ALTER TABLE items RENAME COLUMN cost TO code"); err == nil {
+		t.Error("expected error renaming to an existing column")
+	}
+}
+
+func TestAlterColumnType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (code STRING UNIQUE, qty STRING)")
+	env.mustExecute(t, "INSERT INTO items VALUES ('1', '5')")
+	env.mustExecute(t, "INSERT INTO items VALUES ('01', 'many')")
+
+	if _, err := env.execute(t, "ALTER TABLE items ALTER COLUMN qty TYPE INT64"); err == nil {
+		t.Error("expected error converting 'many' to INT64")
+	}
+	if _, err := env.execute(t, "ALTER TABLE items ALTER COLUMN code TYPE INT64"); err == nil {
+		t.Error("expected unique violation after conversion")
+	}
+
+	env.mustExecute(t, "UPDATE items SET qty = '7' WHERE code = '01'")
+	env.mustExecute(t, "ALTER TABLE items ALTER COLUMN qty SET DATA TYPE INT64")
+
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT qty + 1 FROM items ORDER BY qty")
+	if v, _ := result.Rows[1][0].AsInt64(); v != 8 {
+		t.Errorf("expected 8, got %v", result.Rows[1][0])
+	}
+	result = env.mustExecute(t, "SELECT code FROM items WHERE code = '01'")
+	if result.RowCount() != 1 {
+		t.Error("failed conversion should leave the column unchanged")
+	}
+}
+
+func TestAlterTableRenameTo(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (id INT64 GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, name STRING)")
+	env.mustExecute(t, "CREATE TABLE other (id INT64)")
+	env.mustExecute(t, "INSERT INTO items (name) VALUES ('a')")
+
+	if _, err := env.execute(t, "ALTER TABLE items RENAME TO other"); err == nil {
+		t.Error("expected error renaming to an existing table")
+	}
+	env.mustExecute(t, "ALTER TABLE items RENAME TO products")
+
+	if _, err := os.Stat(env.dataDir + "/tables/items"); !os.IsNotExist(err) {
+		t.Error("old table directory should be gone")
+	}
+	if _, err := env.execute(t, "SELECT * FROM items"); err == nil {
+		t.Error("expected error selecting from the old name")
+	}
+
+	env.reopen(t)
+	env.mustExecute(t, "INSERT INTO products (name) VALUES ('b')")
+	if _, err := env.execute(t, "INSERT INTO products VALUES (1, 'c')"); err == nil {
+		t.Error("expected primary key violation after rename")
+	}
+	result := env.mustExecute(t, "SELECT id FROM products ORDER BY id")
+	if result.RowCount() != 2 {
+		t.Errorf("expected 2 rows, got %d", result.RowCount())
+	}
+
+	seq, _ := env.catalog.GetSequence("items_id_seq")
+	if seq == nil || seq.OwnedBy != "products" {
+		t.Errorf("sequence should follow the table, got %+v", seq)
+	}
+	env.mustExecute(t, "DROP TABLE products")
+	if _, ok := env.catalog.GetSequence("items_id_seq"); ok {
+		t.Error("owned sequence should be dropped with the renamed table")
+	}
+}
+
+// ============================================
+// TRUNCATE Tests
+// ============================================
+
+func TestTruncate(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (sku STRING PRIMARY KEY, qty INT64 DEFAULT 1)")
+	env.mustExecute(t, "CREATE TABLE logs (msg STRING)")
+	env.mustExecute(t, "INSERT INTO items VALUES ('a', 1)")
+	env.mustExecute(t, "INSERT INTO items VALUES ('b', 2)")
+	env.mustExecute(t, "INSERT INTO logs VALUES ('x')")
+
+	if _, err := env.execute(t, "TRUNCATE items, missing"); err == nil {
+		t.Error("expected error for a missing table")
+	}
+	result := env.mustExecute(t, "SELECT * FROM items")
+	if result.RowCount() != 2 {
+		t.Fatalf("failed TRUNCATE should not remove rows, got %d", result.RowCount())
+	}
+
+	result = env.mustExecute(t, "TRUNCATE TABLE items, logs")
+	if result.Message != "2 table(s) truncated" {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+
+	env.reopen(t)
+	for _, table := range []string{"items", "logs"} {
+		result = env.mustExecute(t, "SELECT * FROM "+table)
+		if result.RowCount() != 0 {
+			t.Errorf("%s: expected 0 rows, got %d", table, result.RowCount())
+		}
+	}
+
+	// The schema, defaults and constraints survive.
+	env.mustExecute(t, "INSERT INTO items (sku) VALUES ('a')")
+	if _, err := env.execute(t, "INSERT INTO items VALUES ('a', 2)"); err == nil {
+		t.Error("expected primary key violation after TRUNCATE")
+	}
+	result = env.mustExecute(t, "SELECT qty FROM items")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 1 {
+		t.Errorf("expected default 1, got %v", result.Rows[0][0])
+	}
+	if _, err := os.Stat(env.dataDir + "/tables/items/col_sku.dat.tmp"); !os.IsNotExist(err) {
+		t.Error("temporary column file should not remain")
+	}
+}
+
+func TestTruncateRestartIdentity(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE users (id INT64 GENERATED ALWAYS AS IDENTITY (START WITH 10), name STRING)")
+	env.mustExecute(t, "INSERT INTO users (name) VALUES ('a')")
+	env.mustExecute(t, "INSERT INTO users (name) VALUES ('b')")
+
+	env.mustExecute(t, "TRUNCATE users CONTINUE IDENTITY")
+	env.mustExecute(t, "INSERT INTO users (name) VALUES ('c')")
+	result := env.mustExecute(t, "SELECT id FROM users")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 12 {
+		t.Errorf("CONTINUE IDENTITY: expected id 12, got %v", result.Rows[0][0])
+	}
+
+	env.mustExecute(t, "TRUNCATE TABLE users RESTART IDENTITY")
+	env.reopen(t)
+	env.mustExecute(t, "INSERT INTO users (name) VALUES ('d')")
+	result = env.mustExecute(t, "SELECT id FROM users")
+	if v, _ := result.Rows[0][0].AsInt64(); v != 10 {
+		t.Errorf("RESTART IDENTITY: expected id 10, got %v", result.Rows[0][0])
+	}
+
+	p := parser.NewParser(parser.NewLexer("TRUNCATE users RESTART"))
+	p.Parse()
+	if len(p.Errors()) == 0 {
+		t.Error("expected error for RESTART without IDENTITY")
+	}
+}
+
+// ============================================
+// IF [NOT] EXISTS / CASCADE Tests
+// ============================================
+
+func TestIfExists(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE IF NOT EXISTS users (id INT64)")
+	env.mustExecute(t, "INSERT INTO users VALUES (1)")
+	result := env.mustExecute(t, "CREATE TABLE IF NOT EXISTS users (id INT64, name STRING)")
+	if !strings.Contains(result.Message, "skipping") {
+		t.Errorf("unexpected message: %q", result.Message)
+	}
+	result = env.mustExecute(t, "SELECT * FROM users")
+	if len(result.Columns) != 1 || result.RowCount() != 1 {
+		t.Error("existing table should be left unchanged")
+	}
+
+	env.mustExecute(t, "DROP TABLE IF EXISTS users")
+	env.mustExecute(t, "DROP TABLE IF EXISTS users")
+	if _, err := env.execute(t, "DROP TABLE users"); err == nil {
+		t.Error("expected error dropping a missing table")
+	}
+
+	env.mustExecute(t, "CREATE SEQUENCE IF NOT EXISTS seq START WITH 5")
+	env.mustExecute(t, "CREATE SEQUENCE IF NOT EXISTS seq START WITH 9")
+	if seq, _ := env.catalog.GetSequence("seq"); seq.Start != 5 {
+		t.Errorf("existing sequence should be kept, got start %d", seq.Start)
+	}
+	env.mustExecute(t, "DROP SEQUENCE IF EXISTS seq")
+	env.mustExecute(t, "DROP SEQUENCE IF EXISTS seq RESTRICT")
+}
+
+func TestDropCascade(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE SEQUENCE order_no")
+	env.mustExecute(t, "CREATE TABLE orders (no INT64 DEFAULT NEXTVAL('order_no'), item STRING)")
+	env.mustExecute(t, "CREATE TABLE users (id INT64 GENERATED BY DEFAULT AS IDENTITY, name STRING)")
+	env.mustExecute(t, "CREATE TABLE guests (id INT64 DEFAULT NEXTVAL('users_id_seq'))")
+
+	if _, err := env.execute(t, "DROP SEQUENCE order_no"); err == nil {
+		t.Error("expected error dropping a sequence used by a default")
+	}
+	if _, err := env.execute(t, "DROP SEQUENCE order_no RESTRICT"); err == nil {
+		t.Error("expected error with RESTRICT")
+	}
+	env.mustExecute(t, "DROP SEQUENCE order_no CASCADE")
+
+	env.reopen(t)
+	schema, _ := env.catalog.GetTable("orders")
+	if schema.Columns[0].Default != "" {
+		t.Errorf("default should be removed, got %q", schema.Columns[0].Default)
+	}
+	env.mustExecute(t, "INSERT INTO orders (item) VALUES ('pen')")
+
+	if _, err := env.execute(t, "DROP TABLE users"); err == nil {
+		t.Error("expected error dropping a table whose identity sequence is used elsewhere")
+	}
+	env.mustExecute(t, "DROP TABLE users CASCADE")
+	if _, ok := env.catalog.GetSequence("users_id_seq"); ok {
+		t.Error("identity sequence should be dropped")
+	}
+	result := env.mustExecute(t, "INSERT INTO guests VALUES (DEFAULT) RETURNING id")
+	if !result.Rows[0][0].IsNull {
+		t.Errorf("expected NULL after CASCADE, got %v", result.Rows[0][0])
+	}
+}
+
+// ============================================
+// DATE / TIMESTAMP / INTERVAL Tests
+// ============================================
+
+func TestDateTimeTypes(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, `CREATE TABLE events (
+		id INT64,
+		day DATE,
+		at TIMESTAMP,
+		at_tz TIMESTAMP WITH TIME ZONE,
+		took INTERVAL
+	)`)
+	env.mustExecute(t, "INSERT INTO events VALUES (1, '2026-01-31', '2026-01-31 09:30:00', '2026-01-31 09:30:00+09:00', '1 day 02:00:00')")
+	env.mustExecute(t, "INSERT INTO events VALUES (2, DATE '2025-12-25', TIMESTAMP '2025-12-25 23:59:59.5', NULL, INTERVAL '90 minutes')")
+
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT day, at, at_tz, took FROM events ORDER BY day")
+	want := [][]string{
+		{"2025-12-25", "2025-12-25 23:59:59.5", "NULL", "01:30:00"},
+		{"2026-01-31", "2026-01-31 09:30:00", "2026-01-31 00:30:00+00", "1 day 02:00:00"},
+	}
+	for i, row := range want {
+		for j, s := range row {
+			if got := result.Rows[i][j].String(); got != s {
+				t.Errorf("row %d col %d: expected %q, got %q", i, j, s, got)
+			}
+		}
+	}
+
+	result = env.mustExecute(t, "SELECT id FROM events WHERE day >= '2026-01-01' AND at < TIMESTAMP '2026-02-01' AND took > '1 hour'")
+	if result.RowCount() != 1 {
+		t.Errorf("expected 1 row, got %d", result.RowCount())
+	}
+
+	if _, err := env.execute(t, "INSERT INTO events (id, day) VALUES (3, '2026-02-30')"); err == nil {
+		t.Error("expected error for an invalid date")
+	}
+}
+
+func TestDateTimeArithmetic(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE dual (x INT64)")
+	env.mustExecute(t, "INSERT INTO dual VALUES (1)")
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"DATE '2026-01-31' + 1", "2026-02-01"},
+		{"DATE '2026-03-01' - DATE '2026-02-01'", "28"},
+		{"DATE '2026-01-31' + INTERVAL '1 month'", "2026-02-28 00:00:00"},
+		{"TIMESTAMP '2026-01-01 10:00:00' - INTERVAL '1 day 2 hours'", "2025-12-31 08:00:00"},
+		{"TIMESTAMP '2026-01-02 12:00:00' - TIMESTAMP '2026-01-01 00:00:00'", "1 day 12:00:00"},
+		{"INTERVAL '1 hour' * 3 + INTERVAL '30 minutes'", "03:30:00"},
+		{"INTERVAL '1 year 2 months' / 2", "7 mons"},
+		{"-INTERVAL '3 days'", "-3 days"},
+		{"INTERVAL '2 days ago'", "-2 days"},
+		{"DATE_ADD(DATE '2024-02-29', INTERVAL '1 year')", "2025-02-28 00:00:00"},
+		{"CAST('2026-05-04 03:02:01' AS DATE)", "2026-05-04"},
+		{"CAST(TIMESTAMPTZ '2026-05-04 03:00:00-05' AS TIMESTAMP)", "2026-05-04 08:00:00"},
+		{"CAST(DATE '2026-05-04' AS STRING) || 'Z'", "2026-05-04Z"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM dual")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, expr := range []string{
+		"DATE '2026-01-01' + TIMESTAMP '2026-01-01 00:00:00'",
+		"INTERVAL '1 day' * 'x'",
+		"CAST('yesterday-ish' AS TIMESTAMP)",
+	} {
+		if _, err := env.execute(t, "SELECT "+expr+" FROM dual"); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+
+	// Results must stay within the years 1 to 9999.
+	for _, expr := range []string{
+		"DATE '9999-12-31' + 1",
+		"DATE '0001-01-01' - 1",
+		"DATE '2026-01-01' + 9223372036854775807",
+		"DATE '9999-12-01' + INTERVAL '1 month'",
+		"DATE '0001-01-01' - INTERVAL '1 second'",
+		"TIMESTAMP '9999-12-31 23:59:59' + INTERVAL '1 second'",
+		"TIMESTAMP '0001-01-01 00:00:00' - INTERVAL '1 day'",
+		"TIMESTAMP '2026-01-01 00:00:00' + INTERVAL '10000 years'",
+		"TIMESTAMPTZ '2026-01-01 00:00:00+00' - INTERVAL '2000000 hours' * 1000",
+	} {
+		_, err := env.execute(t, "SELECT "+expr+" FROM dual")
+		if err == nil || !strings.Contains(err.Error(), "date out of range") {
+			t.Errorf("%s: expected date out of range, got %v", expr, err)
+		}
+	}
+	for _, tt := range []struct {
+		expr string
+		want string
+	}{
+		{"DATE '9999-12-30' + 1", "9999-12-31"},
+		{"DATE '0001-01-02' - 1", "0001-01-01"},
+		{"TIMESTAMP '9999-12-31 23:59:58' + INTERVAL '1 second'", "9999-12-31 23:59:59"},
+		{"DATE '0001-01-02' - INTERVAL '1 day'", "0001-01-01 00:00:00"},
+	} {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM dual")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestDateTimeFunctions(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE dual (ts TIMESTAMP)")
+	env.mustExecute(t, "INSERT INTO dual VALUES ('2026-08-19 14:05:09.25')")
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"DATE_TRUNC('month', ts)", "2026-08-01 00:00:00"},
+		{"DATE_TRUNC('week', ts)", "2026-08-17 00:00:00"},
+		{"DATE_TRUNC('quarter', DATE '2026-08-19')", "2026-07-01 00:00:00"},
+		{"EXTRACT(YEAR FROM ts)", "2026"},
+		{"EXTRACT(dow FROM ts)", "3"},
+		{"EXTRACT(SECOND FROM ts)", "9.250000"},
+		{"EXTRACT(EPOCH FROM TIMESTAMP '1970-01-02 00:00:00')", "86400.000000"},
+		{"EXTRACT(HOUR FROM INTERVAL '1 day 05:00:00')", "5"},
+		{"DATE_PART('doy', ts)", "231"},
+		{"STRFTIME(ts, '%Y/%m/%d %H:%M:%S %a %b %j')", "2026/08/19 14:05:09 Wed Aug 231"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM dual")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.expr, tt.want, got)
+		}
+	}
+
+	// EXTRACT is shown in its FROM form in column headers.
+	result := env.mustExecute(t, "SELECT EXTRACT(YEAR FROM ts), EXTRACT('month', ts), EXTRACT('Day', ts) FROM dual")
+	if got, want := strings.Join(result.Columns, ", "), "EXTRACT(year FROM ts), EXTRACT(month FROM ts), EXTRACT('Day', ts)"; got != want {
+		t.Errorf("expected columns %q, got %q", want, got)
+	}
+
+	result = env.mustExecute(t, "SELECT NOW(), CURRENT_TIMESTAMP, CURRENT_DATE FROM dual")
+	if result.Rows[0][0].Type != storage.TypeTimestampTZ || result.Rows[0][2].Type != storage.TypeDate {
+		t.Errorf("unexpected types: %v %v", result.Rows[0][0].Type, result.Rows[0][2].Type)
+	}
+	now, _ := result.Rows[0][1].AsTime()
+	if d := time.Since(now); d < 0 || d > time.Minute {
+		t.Errorf("CURRENT_TIMESTAMP is off by %v", d)
+	}
+
+	env.mustExecute(t, "CREATE TABLE log (msg STRING, at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP)")
+	env.mustExecute(t, "INSERT INTO log (msg) VALUES ('hi')")
+	result = env.mustExecute(t, "SELECT at FROM log WHERE at > NOW() - INTERVAL '1 minute'")
+	if result.RowCount() != 1 {
+		t.Errorf("expected default timestamp within the last minute")
+	}
+}
+
+// ============================================
+// DECIMAL / Aggregate Tests
+// ============================================
+
+func TestDecimalType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE prices (item STRING, price DECIMAL(10,2), rate NUMERIC(5))")
+	env.mustExecute(t, "INSERT INTO prices VALUES ('a', 19.99, 3)")
+	env.mustExecute(t, "INSERT INTO prices VALUES ('b', '0.125', 7.5)")
+	env.mustExecute(t, "INSERT INTO prices VALUES ('c', 100, NULL)")
+
+	result := env.mustExecute(t, "SELECT price, rate FROM prices ORDER BY item")
+	want := [][]string{{"19.99", "3"}, {"0.13", "8"}, {"100.00", "NULL"}}
+	for i, row := range want {
+		for j, v := range row {
+			if got := result.Rows[i][j].String(); got != v {
+				t.Errorf("row %d col %d: expected %s, got %s", i, j, v, got)
+			}
+		}
+	}
+
+	if _, err := env.execute(t, "INSERT INTO prices VALUES ('d', 123456789.99, 1)"); err == nil {
+		t.Error("expected numeric field overflow")
+	}
+	if _, err := env.execute(t, "CREATE TABLE bad (x DECIMAL(40,2))"); err == nil {
+		t.Error("expected error for precision 40")
+	}
+	if _, err := env.execute(t, "CREATE TABLE bad (x DECIMAL(4,5))"); err == nil {
+		t.Error("expected error for scale above precision")
+	}
+	if _, err := env.execute(t, "CREATE TABLE bad (x TEXT(4))"); err == nil {
+		t.Error("expected error for modifier on TEXT")
+	}
+
+	// Precision and scale survive reopening.
+	env.reopen(t)
+	result = env.mustExecute(t, "SELECT price FROM prices WHERE price = 19.99")
+	if result.RowCount() != 1 || result.Rows[0][0].String() != "19.99" {
+		t.Errorf("unexpected rows after reopen: %v", result.Rows)
+	}
+	if _, err := env.execute(t, "INSERT INTO prices VALUES ('e', 1000000000, 1)"); err == nil {
+		t.Error("expected numeric field overflow after reopen")
+	}
+
+	env.mustExecute(t, "ALTER TABLE prices ALTER COLUMN price TYPE DECIMAL(12,1)")
+	result = env.mustExecute(t, "SELECT price FROM prices ORDER BY item")
+	if got := result.Rows[0][0].String(); got != "20.0" {
+		t.Errorf("expected 20.0 after ALTER TYPE, got %s", got)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE dual (d DECIMAL(10,2))")
+	env.mustExecute(t, "INSERT INTO dual VALUES (10.10)")
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"d + 0.2", "10.30"},
+		{"d - 20", "-9.90"},
+		{"d * 1.1", "11.110"},
+		{"d / 3", "3.366667"},
+		{"d % 3", "1.10"},
+		{"-d", "-10.10"},
+		{"d * 0.1 = 1.01", "true"},
+		{"d * CAST(1.5 AS FLOAT64) > 15", "true"},
+		{"DECIMAL '0.1' + DECIMAL '0.2' = DECIMAL '0.3'", "true"},
+		{"CAST('1.005' AS DECIMAL(4,2))", "1.01"},
+		{"CAST('-1.005' AS DECIMAL(4,2))", "-1.01"},
+		{"CAST(d AS INT64)", "10"},
+		{"CAST(d AS STRING)", "10.10"},
+		{"CAST(2.5 AS DECIMAL)", "2.5"},
+		{"CAST(2.5 AS DECIMAL(5,3))", "2.500"},
+		{"ROUND(DECIMAL '2.345', 2)", "2.35"},
+		{"ROUND(DECIMAL '2.345', 2, 'half_even')", "2.34"},
+		{"ROUND(DECIMAL '2.355', 2, 'half_even')", "2.36"},
+		{"ROUND(DECIMAL '-2.341', 2, 'floor')", "-2.35"},
+		{"ROUND(DECIMAL '2.349', 2, 'down')", "2.34"},
+		{"ROUND(DECIMAL '1250', -2, 'half_even')", "1200"},
+		{"ROUND(2.5, 0, 'half_even')", "2.000000"},
+		{"CEIL(DECIMAL '1.01')", "2"},
+		{"FLOOR(DECIMAL '-1.01')", "-2"},
+		{"ABS(DECIMAL '-3.50')", "3.50"},
+		{"GREATEST(d, 11)", "11"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM dual")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.expr, tt.want, got)
+		}
+	}
+
+	result := env.mustExecute(t, "SELECT d + 1, d * CAST(1.5 AS FLOAT64), GREATEST(d, 11) FROM dual")
+	for i, want := range []storage.DataType{storage.TypeDecimal, storage.TypeFloat64, storage.TypeDecimal} {
+		if got := result.Rows[0][i].Type; got != want {
+			t.Errorf("column %d: expected %s, got %s", i, want, got)
+		}
+	}
+
+	for _, expr := range []string{
+		"d / 0",
+		"CAST('abc' AS DECIMAL)",
+		"ROUND(d, 1, 'sideways')",
+		"DECIMAL '99999999999999999999999999999999999999' * 10",
+	} {
+		if _, err := env.execute(t, "SELECT "+expr+" FROM dual"); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE sales (region STRING, qty INT64, amount DECIMAL(12,2), ratio FLOAT64)")
+	env.mustExecute(t, "INSERT INTO sales VALUES ('east', 3, 0.10, 0.5)")
+	env.mustExecute(t, "INSERT INTO sales VALUES ('west', 4, 0.20, 1.5)")
+	env.mustExecute(t, "INSERT INTO sales VALUES ('east', NULL, 0.30, NULL)")
+
+	result := env.mustExecute(t, "SELECT COUNT(*), COUNT(qty), SUM(qty), SUM(amount), AVG(amount), AVG(qty), MIN(region), MAX(amount), SUM(ratio) FROM sales")
+	if result.RowCount() != 1 {
+		t.Fatalf("expected 1 row, got %d", result.RowCount())
+	}
+	want := []string{"3", "2", "7", "0.60", "0.200000", "3.500000", "east", "0.30", "2.000000"}
+	for i, v := range want {
+		if got := result.Rows[0][i].String(); got != v {
+			t.Errorf("%s: expected %s, got %s", result.Columns[i], v, got)
+		}
+	}
+	if result.Columns[0] != "COUNT(*)" {
+		t.Errorf("expected header COUNT(*), got %s", result.Columns[0])
+	}
+
+	result = env.mustExecute(t, "SELECT SUM(amount) = 0.4 AS exact, SUM(qty) * 2 AS doubled FROM sales WHERE region = 'east' ORDER BY doubled")
+	if got := result.Rows[0][0].String(); got != "true" {
+		t.Errorf("expected true, got %s", got)
+	}
+	if got := result.Rows[0][1].String(); got != "6" {
+		t.Errorf("expected 6, got %s", got)
+	}
+
+	result = env.mustExecute(t, "SELECT COUNT(*), SUM(qty), MAX(region) FROM sales WHERE qty > 100")
+	if got := result.Rows[0][0].String() + " " + result.Rows[0][1].String() + " " + result.Rows[0][2].String(); got != "0 NULL NULL" {
+		t.Errorf("unexpected aggregates over no rows: %s", got)
+	}
+
+	for _, sql := range []string{
+		"SELECT region, SUM(qty) FROM sales",
+		"SELECT SUM(SUM(qty)) FROM sales",
+		"SELECT SUM(*) FROM sales",
+		"SELECT SUM(region) FROM sales",
+		"SELECT qty FROM sales WHERE SUM(qty) > 1",
+		"SELECT UPPER(*) FROM sales",
+		"UPDATE sales SET qty = MAX(qty)",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+// ============================================
+// Narrow Integer / FLOAT32 Tests
+// ============================================
+
+func TestNarrowIntegerTypes(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE readings (a TINYINT, b SMALLINT, c INT4, d UINT8, e UINT64, f FLOAT4)")
+
+	schema, _ := env.catalog.GetTable("readings")
+	wantTypes := []storage.DataType{
+		storage.TypeInt8, storage.TypeInt16, storage.TypeInt32,
+		storage.TypeUint8, storage.TypeUint64, storage.TypeFloat32,
+	}
+	for i, want := range wantTypes {
+		if got := schema.Columns[i].Type; got != want {
+			t.Errorf("column %s: expected %s, got %s", schema.Columns[i].Name, want, got)
+		}
+	}
+
+	// INT, INTEGER and REAL keep their 64-bit meaning.
+	env.mustExecute(t, "CREATE TABLE wide (a INT, b INTEGER, c REAL)")
+	schema, _ = env.catalog.GetTable("wide")
+	for i, want := range []storage.DataType{storage.TypeInt64, storage.TypeInt64, storage.TypeFloat64} {
+		if got := schema.Columns[i].Type; got != want {
+			t.Errorf("column %s: expected %s, got %s", schema.Columns[i].Name, want, got)
+		}
+	}
+
+	env.mustExecute(t, "INSERT INTO readings VALUES (-128, 32767, -2147483648, 255, 18446744073709551615, 0.1)")
+	env.mustExecute(t, "INSERT INTO readings VALUES (127, -32768, 2147483647, 0, 0, -2.5)")
+
+	for _, sql := range []string{
+		"INSERT INTO readings VALUES (128, 0, 0, 0, 0, 0)",
+		"INSERT INTO readings VALUES (0, 32768, 0, 0, 0, 0)",
+		"INSERT INTO readings VALUES (0, 0, 2147483648, 0, 0, 0)",
+		"INSERT INTO readings VALUES (0, 0, 0, -1, 0, 0)",
+		"INSERT INTO readings VALUES (0, 0, 0, 256, 0, 0)",
+		"INSERT INTO readings VALUES (0, 0, 0, 0, -1, 0)",
+		"UPDATE readings SET a = a + 1",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected out of range error", sql)
+		}
+	}
+
+	// Values keep their width and sign after reopening.
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT a, b, c, d, e, f FROM readings ORDER BY a")
+	want := [][]string{
+		{"-128", "32767", "-2147483648", "255", "18446744073709551615", "0.100000"},
+		{"127", "-32768", "2147483647", "0", "0", "-2.500000"},
+	}
+	for i, row := range want {
+		for j, v := range row {
+			if got := result.Rows[i][j].String(); got != v {
+				t.Errorf("row %d col %d: expected %s, got %s", i, j, v, got)
+			}
+		}
+	}
+
+	result = env.mustExecute(t, "SELECT COUNT(*) FROM readings WHERE e > 9223372036854775807")
+	if got := result.Rows[0][0].String(); got != "1" {
+		t.Errorf("expected 1 row with a large UINT64, got %s", got)
+	}
+}
+
+func TestNarrowIntegerArithmetic(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE dual (a INT8, b UINT16, u UINT64, f FLOAT32)")
+	env.mustExecute(t, "INSERT INTO dual VALUES (100, 60000, 10000000000000000000, 0.1)")
+
+	tests := []struct {
+		expr     string
+		wantType storage.DataType
+		want     string
+	}{
+		{"a + a", storage.TypeInt64, "200"},
+		{"a * b", storage.TypeInt64, "6000000"},
+		{"-a", storage.TypeInt64, "-100"},
+		{"u - u + u", storage.TypeUint64, "10000000000000000000"},
+		{"u / 4", storage.TypeUint64, "2500000000000000000"},
+		{"f + 1", storage.TypeFloat64, "1.100000"},
+		{"f || ''", storage.TypeString, "0.1"},
+		{"CAST(a AS DECIMAL(5,1))", storage.TypeDecimal, "100.0"},
+		{"CAST(2.5 AS INT16)", storage.TypeInt16, "3"},
+		{"CAST('250' AS UINT8)", storage.TypeUint8, "250"},
+		{"CAST(1.5 AS FLOAT32)", storage.TypeFloat32, "1.500000"},
+		{"SUM(a)", storage.TypeInt64, "100"},
+		{"SUM(u)", storage.TypeUint64, "10000000000000000000"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM dual")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		got := result.Rows[0][0]
+		if got.Type != tt.wantType {
+			t.Errorf("%s: expected type %s, got %s", tt.expr, tt.wantType, got.Type)
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.want, got.String())
+		}
+	}
+
+	for _, expr := range []string{
+		"u + u",
+		"u - 1 - u",
+		"u + a",
+		"-u",
+		"CAST(-1 AS UINT32)",
+		"CAST(300 AS TINYINT)",
+		"CAST('abc' AS INT8)",
+	} {
+		if _, err := env.execute(t, "SELECT "+expr+" FROM dual"); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
+
+// ============================================
+// BYTES Tests
+// ============================================
+
+func TestBytesType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE blobs (id INT64, digest BYTES, note BLOB)")
+	env.mustExecute(t, "INSERT INTO blobs VALUES (1, X'DEADBEEF', 'hi')")
+	env.mustExecute(t, "INSERT INTO blobs VALUES (2, x'00ff', '\\x6869')")
+	env.mustExecute(t, "INSERT INTO blobs VALUES (3, X'', NULL)")
+
+	schema, _ := env.catalog.GetTable("blobs")
+	if schema.Columns[2].Type != storage.TypeBytes {
+		t.Errorf("expected BLOB to be BYTES, got %s", schema.Columns[2].Type)
+	}
+
+	// Bytes persist and display as hex.
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT digest, note FROM blobs ORDER BY id")
+	want := [][]string{{`\xdeadbeef`, `\x6869`}, {`\x00ff`, `\x6869`}, {`\x`, "NULL"}}
+	for i, row := range want {
+		for j, v := range row {
+			if got := result.Rows[i][j].String(); got != v {
+				t.Errorf("row %d col %d: expected %s, got %s", i, j, v, got)
+			}
+		}
+	}
+
+	// Comparison is byte-wise, so 0x00ff sorts before 0xdeadbeef.
+	result = env.mustExecute(t, "SELECT id FROM blobs WHERE digest > X'' ORDER BY digest")
+	if result.RowCount() != 2 || result.Rows[0][0].String() != "2" || result.Rows[1][0].String() != "1" {
+		t.Errorf("unexpected byte-wise order: %v", result.Rows)
+	}
+	result = env.mustExecute(t, "SELECT id FROM blobs WHERE digest = X'deadBEEF'")
+	if result.RowCount() != 1 || result.Rows[0][0].String() != "1" {
+		t.Errorf("expected row 1, got %v", result.Rows)
+	}
+	result = env.mustExecute(t, "SELECT DISTINCT note FROM blobs WHERE note IS NOT NULL")
+	if result.RowCount() != 1 {
+		t.Errorf("expected 1 distinct note, got %d", result.RowCount())
+	}
+
+	if _, err := env.execute(t, "SELECT id FROM blobs WHERE digest = 'abc'"); err == nil {
+		t.Error("expected error comparing BYTES with STRING")
+	}
+	if _, err := env.execute(t, "INSERT INTO blobs VALUES (4, 42, NULL)"); err == nil {
+		t.Error("expected error inserting INT64 into BYTES")
+	}
+}
+
+func TestBytesFunctions(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE dual (b BYTES)")
+	env.mustExecute(t, "INSERT INTO dual VALUES (X'48656C6C6F')")
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"ENCODE(b, 'hex')", "48656c6c6f"},
+		{"ENCODE(b, 'base64')", "SGVsbG8="},
+		{"DECODE('SGVsbG8=', 'base64') = b", "true"},
+		{"DECODE('48656C6C6F', 'HEX')", `\x48656c6c6f`},
+		{"LENGTH(b)", "5"},
+		{"OCTET_LENGTH('héllo')", "6"},
+		{"b || X'21'", `\x48656c6c6f21`},
+		{"CAST(b AS STRING)", `\x48656c6c6f`},
+		{"CAST('Hi' AS BYTES)", `\x4869`},
+		{"'\\x4869'::BYTEA", `\x4869`},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM dual")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, expr := range []string{"X'ABC'", "X'zz'"} {
+		p := parser.NewParser(parser.NewLexer("SELECT " + expr))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%s: expected parse error", expr)
+		}
+	}
+
+	for _, expr := range []string{
+		"ENCODE(b, 'rot13')",
+		"ENCODE('text', 'hex')",
+		"DECODE('###', 'base64')",
+		"CAST('\\xzz' AS BYTES)",
+		"CAST(1 AS BYTES)",
+	} {
+		if _, err := env.execute(t, "SELECT "+expr+" FROM dual"); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
+
+// ============================================
+// JSON Tests
+// ============================================
+
+func TestJSONType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE events (id INT64, payload JSON)")
+	env.mustExecute(t, `INSERT INTO events VALUES (1, '{"user": {"name": "alice", "age": 30}, "tags": ["a", "b"], "n": 1.5}')`)
+	env.mustExecute(t, `INSERT INTO events VALUES (2, '{"user": {"name": "bob"}, "tags": [], "n": 2}')`)
+	env.mustExecute(t, `INSERT INTO events VALUES (3, '[1, 2, 3]')`)
+
+	if _, err := env.execute(t, `INSERT INTO events VALUES (4, '{"user": ')`); err == nil {
+		t.Error("expected error inserting invalid JSON")
+	}
+	if _, err := env.execute(t, `INSERT INTO events VALUES (4, '{} {}')`); err == nil {
+		t.Error("expected error inserting trailing data")
+	}
+
+	// Documents are stored compactly and survive reopening.
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT payload FROM events WHERE id = 2")
+	if got := result.Rows[0][0].String(); got != `{"user":{"name":"bob"},"tags":[],"n":2}` {
+		t.Errorf("unexpected stored JSON: %s", got)
+	}
+	result = env.mustExecute(t, `SELECT id FROM events WHERE payload = '[1,2,3]'`)
+	if result.RowCount() != 1 || result.Rows[0][0].String() != "3" {
+		t.Errorf("expected row 3, got %v", result.Rows)
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"payload -> 'user'", `{"name":"alice","age":30}`},
+		{"payload -> 'user' -> 'name'", `"alice"`},
+		{"payload -> 'user' ->> 'name'", "alice"},
+		{"payload -> 'tags' -> 1", `"b"`},
+		{"payload -> 'tags' ->> -1", "b"},
+		{"payload ->> 'n'", "1.5"},
+		{"payload -> 'missing'", "NULL"},
+		{"payload -> 'tags' -> 5", "NULL"},
+		{"JSON_EXTRACT(payload, '$.user.age')", "30"},
+		{`JSON_EXTRACT(payload, '$.tags[0]')`, `"a"`},
+		{`JSON_EXTRACT(payload, '$["user"].name')`, `"alice"`},
+		{"JSON_EXTRACT(payload, '$.nope.deeper')", "NULL"},
+		{"JSON_EXTRACT(payload, '$')", `{"user":{"name":"alice","age":30},"tags":["a","b"],"n":1.5}`},
+		{"JSON_ARRAY_LENGTH(payload -> 'tags')", "2"},
+		{"JSON_ARRAY_LENGTH(payload, '$.tags')", "2"},
+		{"JSON_KEYS(payload)", `["user","tags","n"]`},
+		{"JSON_KEYS(payload, '$.user')", `["name","age"]`},
+		{"JSON_KEYS(payload -> 'tags')", "NULL"},
+		{"CAST(payload -> 'user' -> 'age' AS INT64) + 1", "31"},
+		{"(payload ->> 'n')::FLOAT64 * 2", "3.000000"},
+		{"CAST(payload -> 'n' AS DECIMAL(4,2))", "1.50"},
+		{"CAST(payload -> 'user' ->> 'name' AS STRING)", "alice"},
+		{"JSON '{\"a\": true}' -> 'a'", "true"},
+		{"CAST(JSON '{\"a\": true}' -> 'a' AS BOOL)", "true"},
+		{"JSON_EXTRACT('{\"a\": [10, 20]}', '$.a[1]')", "20"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM events WHERE id = 1")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+
+	// Extracted values can be filtered and aggregated.
+	result = env.mustExecute(t, "SELECT SUM(CAST(payload -> 'n' AS FLOAT64)), COUNT(*) FROM events WHERE payload -> 'user' ->> 'name' IS NOT NULL")
+	if got := result.Rows[0][0].String() + " " + result.Rows[0][1].String(); got != "3.500000 2" {
+		t.Errorf("unexpected aggregate over JSON: %s", got)
+	}
+	result = env.mustExecute(t, "SELECT id FROM events WHERE CAST(payload ->> 'n' AS FLOAT64) = 2")
+	if result.RowCount() != 1 || result.Rows[0][0].String() != "2" {
+		t.Errorf("expected row 2, got %v", result.Rows)
+	}
+
+	for _, expr := range []string{
+		"CAST(payload AS INT64)",
+		"CAST(payload -> 'user' -> 'name' AS INT64)",
+		"JSON_EXTRACT(payload, 'user.name')",
+		"JSON_EXTRACT(payload, '$.')",
+		"JSON_ARRAY_LENGTH(payload)",
+		"payload -> 1.5",
+		"id -> 'a'",
+		"JSON_KEYS('not json')",
+	} {
+		if _, err := env.execute(t, "SELECT "+expr+" FROM events WHERE id = 1"); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
+
+// ============================================
+// LIST / STRUCT Tests
+// ============================================
+
+func TestListStructTypes(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE people (id INT64, tags LIST<STRING>, scores LIST<DECIMAL(5,1)>, addr STRUCT<city STRING, zip INT32>, nested LIST<STRUCT<k STRING, v LIST<INT64>>>)")
+	env.mustExecute(t, "INSERT INTO people VALUES (1, ['a', 'b'], [1.25, 2], {'city': 'Tokyo', 'zip': 100}, [{k: 'x', v: [1, 2]}])")
+	env.mustExecute(t, "INSERT INTO people VALUES (2, [], NULL, {city: 'Osaka'}, NULL)")
+	env.mustExecute(t, "INSERT INTO people VALUES (3, NULL, [NULL, 3], NULL, [{k: 'y', v: []}, NULL])")
+
+	schema, _ := env.catalog.GetTable("people")
+	if got := schema.Columns[4].TypeName(); got != "LIST<STRUCT<k STRING, v LIST<INT64>>>" {
+		t.Errorf("unexpected type name: %s", got)
+	}
+
+	for _, sql := range []string{
+		"INSERT INTO people (id, tags) VALUES (4, 'a')",
+		"INSERT INTO people (id, addr) VALUES (4, {country: 'JP'})",
+		"INSERT INTO people (id, scores) VALUES (4, [12345.6])",
+		"INSERT INTO people (id, tags) VALUES (4, [1, 'a'])",
+		"CREATE TABLE bad (s STRUCT<a INT64, a STRING>)",
+		"CREATE TABLE bad (l LIST)",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+
+	// Nested values are stored column by column and survive reopening.
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT tags, scores, addr, nested FROM people ORDER BY id")
+	want := [][]string{
+		{"['a', 'b']", "[1.3, 2.0]", "{city: 'Tokyo', zip: 100}", "[{k: 'x', v: [1, 2]}]"},
+		{"[]", "NULL", "{city: 'Osaka', zip: NULL}", "NULL"},
+		{"NULL", "[NULL, 3.0]", "NULL", "[{k: 'y', v: []}, NULL]"},
+	}
+	for i, row := range want {
+		for j, w := range row {
+			if got := result.Rows[i][j].String(); got != w {
+				t.Errorf("row %d column %d: expected %s, got %s", i, j, w, got)
+			}
+		}
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"tags[1]", "a"},
+		{"tags[2] || tags[1]", "ba"},
+		{"tags[3]", "NULL"},
+		{"tags[0]", "NULL"},
+		{"addr.city", "Tokyo"},
+		{"people.addr.zip + 1", "101"},
+		{"(addr).city", "Tokyo"},
+		{"nested[1].k", "x"},
+		{"nested[1].v[2]", "2"},
+		{"[1, 2.5, 3][2]", "2.500000"},
+		{"[[1], [2, 3]][2][2]", "3"},
+		{"{'a': 1, 'b': 'x'}", "{a: 1, b: 'x'}"},
+		{"{a: [1, 2]}.a[2]", "2"},
+		{"CAST([1, 2] AS LIST<STRING>)", "['1', '2']"},
+		{"CAST({a: '7'} AS STRUCT<a INT64, b BOOL>)", "{a: 7, b: NULL}"},
+		{"tags = ['a', 'b']", "true"},
+		{"[1, 2] < [1, 2, 0]", "true"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM people WHERE id = 1")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+
+	result = env.mustExecute(t, "SELECT id FROM people WHERE addr.city = 'Osaka'")
+	if result.RowCount() != 1 || result.Rows[0][0].String() != "2" {
+		t.Errorf("expected row 2, got %v", result.Rows)
+	}
+
+	env.mustExecute(t, "UPDATE people SET tags = ['z'], addr = {city: 'Kyoto', zip: 600} WHERE id = 3")
+	result = env.mustExecute(t, "SELECT tags[1], addr.zip FROM people WHERE id = 3")
+	if got := result.Rows[0][0].String() + " " + result.Rows[0][1].String(); got != "z 600" {
+		t.Errorf("unexpected updated values: %s", got)
+	}
+
+	env.mustExecute(t, "ALTER TABLE people ADD COLUMN ranks LIST<INT32> DEFAULT [1, 2]")
+	env.mustExecute(t, "ALTER TABLE people ALTER COLUMN ranks TYPE LIST<STRING>")
+	env.reopen(t)
+	result = env.mustExecute(t, "SELECT ranks FROM people WHERE id = 2")
+	if got := result.Rows[0][0].String(); got != "['1', '2']" {
+		t.Errorf("unexpected altered list: %s", got)
+	}
+
+	for _, expr := range []string{
+		"addr.country",
+		"id.x",
+		"id[1]",
+		"tags['a']",
+		"{a: 1, a: 2}",
+		"CAST({c: 1} AS STRUCT<a INT64>)",
+		"CAST('[1]' AS LIST<INT64>)",
+	} {
+		if _, err := env.execute(t, "SELECT "+expr+" FROM people WHERE id = 1"); err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+
+	// Column files with children carry version 2; newer versions are
+	// rejected.
+	path := env.dataDir + "/tables/people/col_tags.dat"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version := binary.LittleEndian.Uint16(data[4:]); version != 2 {
+		t.Errorf("expected version 2, got %d", version)
+	}
+	binary.LittleEndian.PutUint16(data[4:], storage.FormatVersion+1)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.reopen(t)
+	if _, err := env.execute(t, "SELECT tags FROM people"); err == nil || !strings.Contains(err.Error(), "unsupported column file version") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
+
+func TestUnnest(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE posts (id INT64, tags LIST<STRING>)")
+	env.mustExecute(t, "INSERT INTO posts VALUES (1, ['go', 'sql'])")
+	env.mustExecute(t, "INSERT INTO posts VALUES (2, [])")
+	env.mustExecute(t, "INSERT INTO posts VALUES (3, NULL)")
+	env.mustExecute(t, "INSERT INTO posts VALUES (4, ['sql'])")
+
+	result := env.mustExecute(t, "SELECT id, tag FROM posts, UNNEST(tags) AS tag ORDER BY id, tag")
+	var got []string
+	for _, row := range result.Rows {
+		got = append(got, row[0].String()+":"+row[1].String())
+	}
+	if strings.Join(got, " ") != "1:go 1:sql 4:sql" {
+		t.Errorf("unexpected unnested rows: %v", got)
+	}
+
+	result = env.mustExecute(t, "SELECT * FROM posts, UNNEST(tags) WHERE unnest = 'sql' ORDER BY id")
+	if len(result.Columns) != 3 || result.Columns[2] != "unnest" || result.RowCount() != 2 {
+		t.Errorf("unexpected result: %v %v", result.Columns, result.Rows)
+	}
+
+	result = env.mustExecute(t, "SELECT COUNT(*), COUNT(DISTINCT_TAG) FROM posts, UNNEST(tags) DISTINCT_TAG")
+	if got := result.Rows[0][0].String(); got != "3" {
+		t.Errorf("expected 3 unnested rows, got %s", got)
+	}
+
+	result = env.mustExecute(t, "SELECT x * 10 FROM UNNEST([1, 2, NULL]) x")
+	if result.RowCount() != 3 || result.Rows[1][0].String() != "20" || !result.Rows[2][0].IsNull {
+		t.Errorf("unexpected rows: %v", result.Rows)
+	}
+
+	for _, sql := range []string{
+		"SELECT * FROM posts, UNNEST(id)",
+		"SELECT * FROM posts, UNNEST(missing)",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+
+	p := parser.NewParser(parser.NewLexer("SELECT * FROM posts, other"))
+	p.Parse()
+	if len(p.Errors()) == 0 {
+		t.Error("expected parse error joining a table")
+	}
+}
+
+// ============================================
+// ENUM / UUID Tests
+// ============================================
+
+func TestEnumType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy')")
+	env.mustExecute(t, "CREATE TABLE diary (id INT64, feeling mood, history LIST<MOOD>)")
+	env.mustExecute(t, "INSERT INTO diary VALUES (1, 'happy', ['sad', 'ok'])")
+	env.mustExecute(t, "INSERT INTO diary VALUES (2, 'sad', [])")
+	env.mustExecute(t, "INSERT INTO diary VALUES (3, NULL, NULL)")
+	env.mustExecute(t, "INSERT INTO diary VALUES (4, 'ok', NULL)")
+
+	for _, sql := range []string{
+		"INSERT INTO diary (id, feeling) VALUES (5, 'angry')",
+		"INSERT INTO diary (id, feeling) VALUES (5, 1)",
+		"CREATE TYPE mood AS ENUM ('a')",
+		"CREATE TYPE uuid AS ENUM ('a')",
+		"CREATE TYPE dup AS ENUM ('a', 'a')",
+		"DROP TYPE mood",
+		"DROP TYPE missing",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+
+	schema, _ := env.catalog.GetTable("diary")
+	if got := schema.Columns[1].TypeName() + " " + schema.Columns[2].TypeName(); got != "mood LIST<mood>" {
+		t.Errorf("unexpected type names: %s", got)
+	}
+
+	// Values are ordered by label position, not alphabetically, and
+	// survive reopening.
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT id, feeling FROM diary ORDER BY feeling DESC")
+	var got []string
+	for _, row := range result.Rows {
+		got = append(got, row[1].String())
+	}
+	if strings.Join(got, ",") != "NULL,happy,ok,sad" {
+		t.Errorf("unexpected order: %v", got)
+	}
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT COUNT(*) FROM diary WHERE feeling > 'sad'", "2"},
+		{"SELECT COUNT(*) FROM diary WHERE feeling = 'ok'", "1"},
+		{"SELECT MAX(feeling) FROM diary", "happy"},
+		{"SELECT MIN(feeling) FROM diary", "sad"},
+		{"SELECT history[2] FROM diary WHERE id = 1", "ok"},
+		{"SELECT CAST('ok' AS mood) < CAST('happy' AS mood) FROM diary WHERE id = 1", "true"},
+		{"SELECT feeling || '!' FROM diary WHERE id = 1", "happy!"},
+		{"SELECT CAST(feeling AS STRING) FROM diary WHERE id = 2", "sad"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, tt.sql)
+		if err != nil {
+			t.Errorf("%s: %v", tt.sql, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.sql, tt.want, got)
+		}
+	}
+
+	if _, err := env.execute(t, "SELECT * FROM diary WHERE feeling = 'angry'"); err == nil {
+		t.Error("expected error comparing with an unknown label")
+	}
+
+	env.mustExecute(t, "UPDATE diary SET feeling = 'happy' WHERE id = 2")
+	env.mustExecute(t, "DROP TABLE diary")
+	env.mustExecute(t, "DROP TYPE mood")
+	env.mustExecute(t, "DROP TYPE IF EXISTS mood")
+}
+
+func TestDropTypeCascade(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TYPE mood AS ENUM ('sad', 'happy')")
+	env.mustExecute(t, "CREATE TYPE IF NOT EXISTS mood AS ENUM ('other')")
+	env.mustExecute(t, "CREATE TABLE diary (id INT64, feeling mood, history LIST<mood>)")
+	env.mustExecute(t, "CREATE TABLE notes (id INT64, body STRING)")
+	env.mustExecute(t, "INSERT INTO diary VALUES (1, 'happy', ['sad'])")
+
+	enum, _ := env.catalog.GetType("mood")
+	if len(enum.Labels) != 2 {
+		t.Errorf("IF NOT EXISTS replaced the type: %v", enum.Labels)
+	}
+
+	if _, err := env.execute(t, "DROP TYPE mood RESTRICT"); err == nil {
+		t.Error("expected error dropping a type in use with RESTRICT")
+	}
+	env.mustExecute(t, "DROP TYPE mood CASCADE")
+	if _, ok := env.catalog.GetType("mood"); ok {
+		t.Error("expected type to be dropped")
+	}
+
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT * FROM diary")
+	if len(result.Columns) != 1 || result.Columns[0] != "id" || result.RowCount() != 1 {
+		t.Errorf("expected only column id to remain, got %v with %d rows", result.Columns, result.RowCount())
+	}
+	env.mustExecute(t, "DROP TYPE IF EXISTS mood CASCADE")
+}
+
+func TestAlterColumnTypeEnum(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TYPE mood AS ENUM ('sad', 'happy')")
+	env.mustExecute(t, "CREATE TABLE diary (id INT64, m STRING)")
+	env.mustExecute(t, "INSERT INTO diary VALUES (1, 'happy')")
+	env.mustExecute(t, "INSERT INTO diary VALUES (2, 'sad')")
+
+	// STRING to ENUM keeps the values as labels.
+	env.mustExecute(t, "ALTER TABLE diary ALTER COLUMN m TYPE mood")
+	env.mustExecute(t, "INSERT INTO diary VALUES (3, 'sad')")
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT id, m FROM diary ORDER BY m, id")
+	var got []string
+	for _, row := range result.Rows {
+		got = append(got, row[0].String()+":"+row[1].String())
+	}
+	if strings.Join(got, ",") != "2:sad,3:sad,1:happy" {
+		t.Errorf("unexpected rows after altering to ENUM: %v", got)
+	}
+	if _, err := env.execute(t, "INSERT INTO diary VALUES (4, 'angry')"); err == nil {
+		t.Error("expected error inserting an unknown label")
+	}
+	if _, err := env.execute(t, "DROP TYPE mood"); err == nil {
+		t.Error("expected error dropping a type in use")
+	}
+
+	// ENUM back to STRING releases the type.
+	env.mustExecute(t, "ALTER TABLE diary ALTER COLUMN m TYPE STRING")
+	env.mustExecute(t, "INSERT INTO diary VALUES (4, 'angry')")
+	env.reopen(t)
+	result = env.mustExecute(t, "SELECT m FROM diary ORDER BY m")
+	got = nil
+	for _, row := range result.Rows {
+		got = append(got, row[0].String())
+	}
+	if strings.Join(got, ",") != "angry,happy,sad,sad" {
+		t.Errorf("unexpected rows after altering to STRING: %v", got)
+	}
+	env.mustExecute(t, "DROP TYPE mood")
+}
+
+func TestUUIDType(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE sessions (id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(), name STRING)")
+	env.mustExecute(t, "INSERT INTO sessions VALUES ('A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11', 'a')")
+	env.mustExecute(t, "INSERT INTO sessions VALUES ('{b0eebc999c0b4ef8bb6d6bb9bd380a11}', 'b')")
+	env.mustExecute(t, "INSERT INTO sessions (name) VALUES ('c')")
+	env.mustExecute(t, "INSERT INTO sessions (name) VALUES ('d')")
+
+	for _, sql := range []string{
+		"INSERT INTO sessions VALUES ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 'dup')",
+		"INSERT INTO sessions VALUES ('not-a-uuid', 'x')",
+		"INSERT INTO sessions VALUES ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a1', 'x')",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT name FROM sessions WHERE id = 'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'")
+	if result.RowCount() != 1 || result.Rows[0][0].String() != "a" {
+		t.Errorf("expected row a, got %v", result.Rows)
+	}
+	result = env.mustExecute(t, "SELECT id FROM sessions WHERE name = 'b'")
+	if got := result.Rows[0][0].String(); got != "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11" {
+		t.Errorf("unexpected formatted UUID: %s", got)
+	}
+
+	result = env.mustExecute(t, "SELECT id FROM sessions WHERE name = 'c' OR name = 'd'")
+	a, b := result.Rows[0][0].String(), result.Rows[1][0].String()
+	if a == b || len(a) != 36 || a[14] != '4' {
+		t.Errorf("unexpected generated UUIDs: %s %s", a, b)
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"UUID 'a0eebc999c0b4ef8bb6d6bb9bd380a11' = id", "true"},
+		{"ENCODE(CAST(id AS BYTES), 'hex')", "a0eebc999c0b4ef8bb6d6bb9bd380a11"},
+		{"CAST(DECODE('a0eebc999c0b4ef8bb6d6bb9bd380a11', 'hex') AS UUID)", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+		{"LENGTH(CAST(id AS STRING))", "36"},
+		{"id < 'b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'", "true"},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.expr+" FROM sessions WHERE name = 'a'")
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+}
+
+// ============================================
+// Collation Tests
+// ============================================
+
+func TestCollation(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE words (w STRING, ci STRING COLLATE nocase UNIQUE)")
+	for _, w := range []string{"banana", "Apple", "cherry", "apple", "Éclair", "eclair", "Zebra"} {
+		env.mustExecute(t, "INSERT INTO words (w) VALUES ('"+w+"')")
+	}
+	env.mustExecute(t, "UPDATE words SET ci = w WHERE w <> 'apple' AND w <> 'eclair'")
+
+	schema, _ := env.catalog.GetTable("words")
+	if schema.Columns[1].Collation != storage.CollationNoCase {
+		t.Errorf("expected nocase collation, got %s", schema.Columns[1].Collation)
+	}
+
+	column := func(sql string) string {
+		t.Helper()
+		result := env.mustExecute(t, sql)
+		var got []string
+		for _, row := range result.Rows {
+			got = append(got, row[0].String())
+		}
+		return strings.Join(got, ",")
+	}
+
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT w FROM words ORDER BY w", "Apple,Zebra,apple,banana,cherry,eclair,Éclair"},
+		{"SELECT w FROM words ORDER BY w COLLATE nocase, w", "Apple,apple,banana,cherry,eclair,Zebra,Éclair"},
+		{"SELECT w FROM words ORDER BY w COLLATE unicode", "apple,Apple,banana,cherry,eclair,Éclair,Zebra"},
+		{"SELECT w FROM words ORDER BY w COLLATE 'und' DESC", "Zebra,Éclair,eclair,cherry,banana,Apple,apple"},
+		{"SELECT w FROM words WHERE w = 'APPLE' COLLATE nocase", "Apple,apple"},
+		{"SELECT w FROM words WHERE w COLLATE unicode > 'd'", "Éclair,eclair,Zebra"},
+		{"SELECT ci FROM words WHERE ci = 'ZEBRA'", "Zebra"},
+		{"SELECT ci FROM words WHERE ci = 'ZEBRA' COLLATE binary", ""},
+		{"SELECT ci FROM words WHERE ci IS NOT NULL ORDER BY ci", "Apple,banana,cherry,Zebra,Éclair"},
+		{"SELECT DISTINCT w COLLATE nocase FROM words ORDER BY 1", "Apple,banana,cherry,eclair,Zebra,Éclair"},
+		{"SELECT DISTINCT ON (w COLLATE nocase) w FROM words ORDER BY w COLLATE nocase, w", "Apple,banana,cherry,eclair,Zebra,Éclair"},
+		{"SELECT MAX(w COLLATE nocase) FROM words", "Éclair"},
+		{"SELECT MIN(ci) FROM words", "Apple"},
+	}
+	for _, tt := range tests {
+		if got := column(tt.sql); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.sql, tt.want, got)
+		}
+	}
+
+	// The nocase column is unique ignoring case, also after reopening.
+	env.reopen(t)
+	if _, err := env.execute(t, "INSERT INTO words VALUES ('x', 'BANANA')"); err == nil {
+		t.Error("expected unique violation for a value differing only in case")
+	}
+	if got := column("SELECT w FROM words WHERE ci = 'cherry'"); got != "cherry" {
+		t.Errorf("expected cherry after reopen, got %s", got)
+	}
+
+	for _, sql := range []string{
+		"SELECT * FROM words WHERE w = ci COLLATE unicode AND ci COLLATE nocase = w COLLATE binary",
+		"SELECT * FROM words WHERE w COLLATE nope = 'a'",
+		"SELECT 1 COLLATE nocase FROM words",
+		"CREATE TABLE bad (n INT64 COLLATE nocase)",
+		"CREATE TABLE bad (s STRING COLLATE nope)",
+	} {
+		if _, err := env.execute(t, sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+
+	// Two different implicit collations conflict; an explicit one decides.
+	if _, err := env.execute(t, "SELECT * FROM words WHERE ci = COALESCE(w COLLATE unicode)"); err == nil {
+		t.Error("expected error comparing strings of different collations")
+	}
+	if got := column("SELECT ci FROM words WHERE ci = COALESCE('CHERRY' COLLATE unicode) COLLATE nocase"); got != "cherry" {
+		t.Errorf("expected cherry, got %s", got)
+	}
+}
+
+func TestCollationConcatAndAlter(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE words (id INT64, ci STRING COLLATE nocase)")
+	env.mustExecute(t, "INSERT INTO words VALUES (1, 'Apple')")
+	env.mustExecute(t, "INSERT INTO words VALUES (2, 'banana')")
+
+	// Concatenation keeps the collation of the column.
+	result := env.mustExecute(t, "SELECT id FROM words WHERE ci || 's' = 'APPLES'")
+	if result.RowCount() != 1 {
+		t.Errorf("expected 1 row comparing a concatenation by nocase, got %d", result.RowCount())
+	}
+	result = env.mustExecute(t, "SELECT ci || '!' FROM words ORDER BY ci || '!' DESC")
+	if got := result.Rows[0][0].String(); got != "banana!" {
+		t.Errorf("expected banana! first, got %s", got)
+	}
+
+	// ALTER COLUMN TYPE takes the collation of the new type.
+	env.mustExecute(t, "ALTER TABLE words ALTER COLUMN ci TYPE STRING")
+	if schema, _ := env.catalog.GetTable("words"); schema.Columns[1].Collation != storage.CollationBinary {
+		t.Errorf("expected binary collation, got %s", schema.Columns[1].Collation)
+	}
+	if result := env.mustExecute(t, "SELECT id FROM words WHERE ci = 'APPLE'"); result.RowCount() != 0 {
+		t.Error("expected binary comparison after dropping the collation")
+	}
+	env.mustExecute(t, "ALTER TABLE words ALTER COLUMN ci TYPE STRING COLLATE nocase")
+	env.reopen(t)
+	if result := env.mustExecute(t, "SELECT id FROM words WHERE ci = 'APPLE'"); result.RowCount() != 1 {
+		t.Error("expected nocase comparison after altering the collation")
+	}
+
+	if _, err := env.execute(t, "ALTER TABLE words ALTER COLUMN id TYPE INT32 COLLATE nocase"); err == nil {
+		t.Error("expected error for COLLATE on a non-STRING type")
+	}
+	env.mustExecute(t, "UPDATE words SET ci = '5'")
+	env.mustExecute(t, "ALTER TABLE words ALTER COLUMN ci TYPE INT64")
+	if schema, _ := env.catalog.GetTable("words"); schema.Columns[1].Collation != storage.CollationBinary {
+		t.Errorf("expected no collation on INT64, got %s", schema.Columns[1].Collation)
+	}
+}
+
+func TestUnicodeCollationOrder(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE names (s STRING COLLATE unicode)")
+	for _, s := range []string{"カ", "か", "が", "き", "１", "2", "a", "ＡＢ", "ab", "straße", "strasse", "Strasse", "café", "cafe", "cafes", "ø", "p", "_x", "漢字"} {
+		env.mustExecute(t, "INSERT INTO names VALUES ('"+s+"')")
+	}
+	result := env.mustExecute(t, "SELECT s FROM names ORDER BY s")
+	var got []string
+	for _, row := range result.Rows {
+		got = append(got, row[0].String())
+	}
+	want := "_x,１,2,a,ab,ＡＢ,cafe,café,cafes,ø,p,strasse,straße,Strasse,か,カ,が,き,漢字"
+	if strings.Join(got, ",") != want {
+		t.Errorf("unexpected order:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+
+	if got := env.mustExecute(t, "SELECT COUNT(*) FROM names WHERE s = 'CAFE'").Rows[0][0].String(); got != "0" {
+		t.Errorf("expected case to matter at the tertiary level, got %s matches", got)
+	}
+	if got := env.mustExecute(t, "SELECT COUNT(*) FROM names WHERE s = 'café'").Rows[0][0].String(); got != "1" {
+		t.Errorf("expected a combining accent to equal the precomposed letter, got %s matches", got)
+	}
+}
+
+// ============================================
+// Lexer Tests
+// ============================================
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE 注文 (商品名 STRING, 数量 INT64, \"Order Items\" INT64 GENERATED ALWAYS AS (数量 * 2) STORED, \"select\" STRING)")
+	env.mustExecute(t, "INSERT INTO 注文 (商品名, 数量, \"select\") VALUES ('りんご', 3, E'a\\tb')")
+	env.mustExecute(t, "/* leading /* nested */ comment */ INSERT INTO 注文 (商品名, 数量) VALUES ('みかん', 5) -- trailing")
+
+	env.reopen(t)
+	result := env.mustExecute(t, "SELECT 商品名, \"Order Items\", \"select\" FROM 注文 WHERE 数量 > 2 ORDER BY \"Order Items\" DESC")
+	if got := strings.Join(result.Columns, ","); got != "商品名,Order Items,select" {
+		t.Errorf("unexpected headers: %s", got)
+	}
+	if result.RowCount() != 2 || result.Rows[0][0].String() != "みかん" || result.Rows[0][1].String() != "10" {
+		t.Errorf("unexpected rows: %v", result.Rows)
+	}
+	if got := result.Rows[1][2].String(); got != "a\tb" {
+		t.Errorf("expected escaped tab, got %q", got)
+	}
+
+	// Stored expressions keep the quotes a column name needs.
+	env.mustExecute(t, "ALTER TABLE 注文 RENAME COLUMN 数量 TO \"Qty \"\"Total\"\"\"")
+	schema, _ := env.catalog.GetTable("注文")
+	if got := schema.Columns[2].Generated; got != `"Qty ""Total""" * 2` {
+		t.Errorf("unexpected generated expression: %s", got)
+	}
+	env.mustExecute(t, "INSERT INTO 注文 (商品名, \"Qty \"\"Total\"\"\") VALUES ('ぶどう', 1)")
+	result = env.mustExecute(t, "SELECT \"Order Items\" FROM 注文 WHERE 商品名 = 'ぶどう'")
+	if got := result.Rows[0][0].String(); got != "2" {
+		t.Errorf("expected 2, got %s", got)
+	}
+
+	for _, sql := range []string{
+		`CREATE TABLE "../escape" (a INT64)`,
+		`CREATE TABLE ok (a INT64)`,
+		`ALTER TABLE ok RENAME TO "a/b"`,
+		`ALTER TABLE ok ADD COLUMN "x/y" INT64`,
+	} {
+		_, err := env.execute(t, sql)
+		if (err == nil) != (sql == `CREATE TABLE ok (a INT64)`) {
+			t.Errorf("%s: unexpected result %v", sql, err)
+		}
+	}
+}
+
+func TestEscapeStrings(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE t (s STRING)")
+	env.mustExecute(t, "INSERT INTO t VALUES ('x')")
+
+	tests := []struct {
+		literal string
+		want    string
+	}{
+		{`E'line\nbreak'`, "line\nbreak"},
+		{`e'it\'s'`, "it's"},
+		{`E'it''s'`, "it's"},
+		{`E'back\\slash'`, `back\slash`},
+		{`E'\x41\102é\U0001F600'`, "ABé😀"},
+		{`E'\q'`, "q"},
+		{`'no\nescape'`, `no\nescape`},
+	}
+	for _, tt := range tests {
+		result, err := env.execute(t, "SELECT "+tt.literal+" FROM t")
+		if err != nil {
+			t.Errorf("%s: %v", tt.literal, err)
+			continue
+		}
+		if got := result.Rows[0][0].String(); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.literal, tt.want, got)
+		}
+	}
+
+	for _, sql := range []string{
+		`SELECT E'\u00' FROM t`,
+		`SELECT E'\xff' FROM t`,
+		`SELECT "" FROM t`,
+		`SELECT "open FROM t`,
+		`SELECT 1 /* open FROM t`,
+	} {
+		p := parser.NewParser(parser.NewLexer(sql))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%s: expected parse error", sql)
+		}
+	}
+}
+
+func TestLexerPositions(t *testing.T) {
+	l := parser.NewLexer("SELECT 名前,\n  \"é x\" /* コメント */ FROM t")
+	want := []struct {
+		literal      string
+		line, column int
+	}{
+		{"SELECT", 1, 1},
+		{"名前", 1, 8},
+		{",", 1, 10},
+		{"é x", 2, 3},
+		{"FROM", 2, 20},
+		{"t", 2, 25},
+	}
+	for _, w := range want {
+		tok := l.NextToken()
+		if tok.Literal != w.literal || tok.Line != w.line || tok.Column != w.column {
+			t.Errorf("expected %q at %d:%d, got %q at %d:%d", w.literal, w.line, w.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+	if tok := l.NextToken(); tok.Type != parser.TOKEN_EOF {
+		t.Errorf("expected EOF, got %q", tok.Literal)
+	}
+}
+
+func TestLexerUnterminated(t *testing.T) {
+	for _, input := range []string{`'abc`, `E'abc`, `E'ab\'`, `X'ab`} {
+		tok := parser.NewLexer(input).NextToken()
+		if tok.Type != parser.TOKEN_ILLEGAL || tok.Literal != "unterminated string" {
+			t.Errorf("%s: expected unterminated string, got %v %q", input, tok.Type, tok.Literal)
+		}
+	}
+
+	for _, sql := range []string{
+		`SELECT * FROM t WHERE s = E'abc`,
+		`SELECT * FROM t /* open`,
+		`SELECT * FROM t; /* open`,
+	} {
+		p := parser.NewParser(parser.NewLexer(sql))
+		p.Parse()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%s: expected parse error", sql)
+		}
+	}
+}
+
+// ============================================
+// Result Formatting Tests
+// ============================================
+
+func TestResultString(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE items (name STRING, qty INT64, price DECIMAL(6,2))")
+	env.mustExecute(t, "INSERT INTO items VALUES ('りんご', 3, 1.5)")
+	env.mustExecute(t, "INSERT INTO items VALUES ('pear 🍐', 12, NULL)")
+	env.mustExecute(t, "INSERT INTO items VALUES ('e\u0301clair', NULL, 100)") // combining accent
+
+	result := env.mustExecute(t, "SELECT * FROM items")
+	lines := strings.Split(result.String(), "\n")
+	width := displayWidth(lines[0])
+	for _, line := range lines {
+		if displayWidth(line) != width {
+			t.Errorf("misaligned line %q: width %d, want %d", line, displayWidth(line), width)
+		}
+	}
+	if !strings.HasPrefix(lines[3], "| りんご  |") || !strings.HasSuffix(lines[3], "|    3 |   1.50 |") {
+		t.Errorf("unexpected row: %q", lines[3])
+	}
+
+	result.MaxColumnWidth = 4
+	lines = strings.Split(result.String(), "\n")
+	if lines[1] != "| name |  qty |   pri… |" || lines[3] != "| り…  |    3 |   1.50 |" || lines[5] != "| e\u0301cl… | NULL | 100.00 |" {
+		t.Errorf("unexpected truncation:\n%s", result.String())
+	}
+
+	// Only text is truncated; numbers and dates are shown in full.
+	env.mustExecute(t, "CREATE TABLE wide (n INT64, d DECIMAL(10,3), t DATE, s STRING)")
+	env.mustExecute(t, "INSERT INTO wide VALUES (1234567890123, 12345.678, '2026-01-02', 'abcdef')")
+	result = env.mustExecute(t, "SELECT * FROM wide")
+	result.MaxColumnWidth = 4
+	lines = strings.Split(result.String(), "\n")
+	if lines[3] != "| 1234567890123 | 12345.678 | 2026-01-02 | abc… |" {
+		t.Errorf("unexpected truncation:\n%s", result.String())
+	}
+
+	tests := []struct {
+		s     string
+		width int
+	}{
+		{"abc", 3},
+		{"日本語", 6},
+		{"ｱｲ", 2},
+		{"ＡＢ", 4},
+		{"é", 1},
+		{"👍🏽", 2},
+		{"한국", 4},
+	}
+	for _, tt := range tests {
+		if got := displayWidth(tt.s); got != tt.width {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.s, got, tt.width)
+		}
+	}
+}
+
+func TestResultStringControlCharacters(t *testing.T) {
+	env := setupTest(t)
+	defer env.cleanup()
+
+	env.mustExecute(t, "CREATE TABLE notes (body STRING)")
+	env.mustExecute(t, `INSERT INTO notes VALUES (E'first\nsecond\tend\x1b[31m')`)
+
+	result := env.mustExecute(t, "SELECT body FROM notes")
+	lines := strings.Split(result.String(), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d:\n%s", len(lines), result.String())
+	}
+	if want := `| first\nsecond\tend\x1b[31m |`; lines[3] != want {
+		t.Errorf("expected %s, got %s", want, lines[3])
+	}
+	if displayWidth(lines[0]) != displayWidth(lines[3]) {
+		t.Errorf("misaligned row:\n%s", result.String())
+	}
+}
@@ -0,0 +1,621 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// rowScope binds column names to the values of the row being evaluated.
+// Each name may carry a table qualifier, so that t.col resolves only to
+// columns bound for t.
+type rowScope struct {
+	names  []string
+	tables []string
+	values []storage.Value
+	// columns holds the definitions of the names bound to table columns,
+	// if known, for typing the values even when they are NULL.
+	columns []storage.ColumnDef
+	// aggregates holds the results of the aggregate calls of a query.
+	aggregates map[*parser.FunctionCall]storage.Value
+}
+
+func newRowScope(schema *storage.TableSchema, row []storage.Value) *rowScope {
+	return tableScope(schema.Name, schema, row)
+}
+
+// tableScope binds the columns of a table under the given qualifier, which
+// is the table name or its alias.
+func tableScope(qualifier string, schema *storage.TableSchema, row []storage.Value) *rowScope {
+	names := schema.ColumnNames()
+	tables := make([]string, len(names))
+	for i := range tables {
+		tables[i] = qualifier
+	}
+	return &rowScope{names: names, tables: tables, values: row, columns: schema.Columns}
+}
+
+// join returns a scope holding the columns of s followed by those of other.
+func (s *rowScope) join(other *rowScope) *rowScope {
+	return &rowScope{
+		names:   append(slices.Clone(s.names), other.names...),
+		tables:  append(slices.Clone(s.tables), other.tables...),
+		values:  append(slices.Clone(s.values), other.values...),
+		columns: slices.Concat(s.columnDefs(), other.columnDefs()),
+	}
+}
+
+// columnDefs returns the column definitions of s, with zero definitions
+// for the names whose column is unknown.
+func (s *rowScope) columnDefs() []storage.ColumnDef {
+	if len(s.columns) == len(s.names) {
+		return s.columns
+	}
+	return make([]storage.ColumnDef, len(s.names))
+}
+
+// typeOf returns the type of an expression from the column it refers to
+// or else from its value, and reports false if neither tells.
+func (s *rowScope) typeOf(expr parser.Expression, v storage.Value) (storage.ColumnDef, bool) {
+	if ident, ok := expr.(*parser.Identifier); ok && s != nil {
+		if i, err := s.resolve(ident); err == nil && i < len(s.columns) && s.columns[i].Type != storage.TypeNull {
+			return s.columns[i], true
+		}
+	}
+	if v.IsNull {
+		return storage.ColumnDef{}, false
+	}
+	enum, _, _ := v.AsEnum()
+	return storage.ColumnDef{Type: v.Type, Enum: enum}, true
+}
+
+// resolve returns the position of the column an identifier refers to. An
+// unqualified name matching columns of two different tables is ambiguous;
+// otherwise the first match wins.
+func (s *rowScope) resolve(ident *parser.Identifier) (int, error) {
+	found := -1
+	for i, name := range s.names {
+		if name != ident.Name || (ident.Table != "" && ident.Table != s.tables[i]) {
+			continue
+		}
+		if found == -1 {
+			found = i
+			continue
+		}
+		if s.tables[found] != "" && s.tables[i] != "" && s.tables[found] != s.tables[i] {
+			return -1, fmt.Errorf("column reference %q is ambiguous", ident.Name)
+		}
+	}
+	if found == -1 {
+		return -1, fmt.Errorf("column %q not found", ident.String())
+	}
+	return found, nil
+}
+
+// checkColumns reports an error if expr refers to a column not in scope.
+func checkColumns(expr parser.Expression, scope *rowScope) error {
+	var err error
+	parser.Inspect(expr, func(node parser.Expression) bool {
+		if ident, ok := node.(*parser.Identifier); ok {
+			if _, err = scope.resolve(ident); err != nil {
+				if _, ok := scope.structColumn(ident); ok {
+					err = nil
+				}
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// evaluate evaluates an expression against a row.
+func (e *Executor) evaluate(expr parser.Expression, scope *rowScope) (storage.Value, error) {
+	switch ex := expr.(type) {
+	case *parser.Identifier:
+		if scope == nil {
+			return storage.NewNullValue(), fmt.Errorf("column %q not found", ex.String())
+		}
+		i, err := scope.resolve(ex)
+		if err != nil {
+			if col, ok := scope.structColumn(ex); ok {
+				return evaluateField(scope.values[col], ex.Name)
+			}
+			return storage.NewNullValue(), err
+		}
+		return scope.values[i], nil
+	case *parser.PrefixExpression:
+		right, err := e.evaluate(ex.Right, scope)
+		if err != nil {
+			return right, err
+		}
+		return evaluatePrefix(ex.Operator, right)
+	case *parser.InfixExpression:
+		left, err := e.evaluate(ex.Left, scope)
+		if err != nil {
+			return left, err
+		}
+		if ex.Operator == "AND" || ex.Operator == "OR" {
+			return e.evaluateLogical(ex, left, scope)
+		}
+		right, err := e.evaluate(ex.Right, scope)
+		if err != nil {
+			return right, err
+		}
+		if left, right, err = coerceLiteralOperand(ex, left, right); err != nil {
+			return storage.NewNullValue(), err
+		}
+		if left, right, err = collateOperands(ex, left, right); err != nil {
+			return storage.NewNullValue(), err
+		}
+		return evaluateInfix(ex.Operator, left, right)
+	case *parser.IsNullExpression:
+		val, err := e.evaluate(ex.Expression, scope)
+		if err != nil {
+			return val, err
+		}
+		return storage.NewBoolValue(val.IsNull != ex.Not), nil
+	case *parser.IsDistinctFromExpression:
+		left, err := e.evaluate(ex.Left, scope)
+		if err != nil {
+			return left, err
+		}
+		right, err := e.evaluate(ex.Right, scope)
+		if err != nil {
+			return right, err
+		}
+		return storage.NewBoolValue(left.Equal(right) == ex.Not), nil
+	case *parser.LikeExpression:
+		str, err := e.evaluate(ex.Expression, scope)
+		if err != nil {
+			return str, err
+		}
+		pattern, err := e.evaluate(ex.Pattern, scope)
+		if err != nil {
+			return pattern, err
+		}
+		var escape storage.Value
+		if ex.Escape != nil {
+			if escape, err = e.evaluate(ex.Escape, scope); err != nil {
+				return escape, err
+			}
+		}
+		return evaluateLike(str, pattern, escape, ex.Escape != nil, ex.CaseInsensitive, ex.Not)
+	case *parser.CastExpression:
+		val, err := e.evaluate(ex.Expression, scope)
+		if err != nil {
+			return val, err
+		}
+		return e.castToTypeName(val, ex.DataType)
+	case *parser.CollateExpression:
+		val, err := e.evaluate(ex.Expression, scope)
+		if err != nil {
+			return val, err
+		}
+		return evaluateCollate(val, ex.Collation)
+	case *parser.CaseExpression:
+		return e.evaluateCase(ex, scope)
+	case *parser.ListLiteral:
+		return e.evaluateListLiteral(ex, scope)
+	case *parser.StructLiteral:
+		return e.evaluateStructLiteral(ex, scope)
+	case *parser.IndexExpression:
+		list, err := e.evaluate(ex.Expression, scope)
+		if err != nil {
+			return list, err
+		}
+		index, err := e.evaluate(ex.Index, scope)
+		if err != nil {
+			return index, err
+		}
+		return evaluateIndex(list, index)
+	case *parser.FieldAccess:
+		val, err := e.evaluate(ex.Expression, scope)
+		if err != nil {
+			return val, err
+		}
+		return evaluateField(val, ex.Field)
+	case *parser.FunctionCall:
+		return e.evaluateFunction(ex, scope)
+	default:
+		return e.evaluateLiteral(expr)
+	}
+}
+
+// evaluateLogical evaluates AND and OR with SQL three-valued logic: a NULL
+// operand yields NULL unless the other operand alone decides the result.
+func (e *Executor) evaluateLogical(ex *parser.InfixExpression, left storage.Value, scope *rowScope) (storage.Value, error) {
+	l, err := truthValue(left, ex.Operator)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+
+	// FALSE AND x is FALSE, TRUE OR x is TRUE.
+	decisive := ex.Operator == "OR"
+	if l != nil && *l == decisive {
+		return storage.NewBoolValue(decisive), nil
+	}
+
+	right, err := e.evaluate(ex.Right, scope)
+	if err != nil {
+		return right, err
+	}
+	r, err := truthValue(right, ex.Operator)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+
+	switch {
+	case r != nil && *r == decisive:
+		return storage.NewBoolValue(decisive), nil
+	case l == nil || r == nil:
+		return storage.NewNullValue(), nil
+	default:
+		return storage.NewBoolValue(!decisive), nil
+	}
+}
+
+// truthValue converts a BOOL value to a tri-state, where nil means NULL.
+func truthValue(v storage.Value, context string) (*bool, error) {
+	if v.IsNull {
+		return nil, nil
+	}
+	b, ok := v.AsBool()
+	if !ok {
+		return nil, fmt.Errorf("argument of %s must be type BOOL, not %s", context, v.Type)
+	}
+	return &b, nil
+}
+
+// matches reports whether a WHERE condition holds for a row.
+func (e *Executor) matches(cond parser.Expression, scope *rowScope) (bool, error) {
+	val, err := e.evaluate(cond, scope)
+	if err != nil {
+		return false, err
+	}
+	return isTrue(val, "WHERE")
+}
+
+// isTrue reports whether a condition evaluates to TRUE. NULL is not TRUE.
+func isTrue(v storage.Value, context string) (bool, error) {
+	b, err := truthValue(v, context)
+	if err != nil || b == nil {
+		return false, err
+	}
+	return *b, nil
+}
+
+func (e *Executor) evaluateCase(ex *parser.CaseExpression, scope *rowScope) (storage.Value, error) {
+	var operand storage.Value
+	if ex.Operand != nil {
+		var err error
+		if operand, err = e.evaluate(ex.Operand, scope); err != nil {
+			return operand, err
+		}
+	}
+
+	chosen := -1
+	for i, when := range ex.Whens {
+		cond, err := e.evaluate(when.Condition, scope)
+		if err != nil {
+			return cond, err
+		}
+
+		// Simple CASE compares the operand with each WHEN value using =,
+		// so a NULL operand never matches.
+		if ex.Operand != nil {
+			if cond, err = evaluateComparison("=", operand, cond); err != nil {
+				return cond, err
+			}
+		}
+
+		matched, err := isTrue(cond, "CASE/WHEN")
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		if matched {
+			chosen = i
+			break
+		}
+	}
+
+	results := make([]parser.Expression, 0, len(ex.Whens)+1)
+	for _, when := range ex.Whens {
+		results = append(results, when.Result)
+	}
+	if ex.Else != nil {
+		results = append(results, ex.Else)
+		if chosen == -1 {
+			chosen = len(ex.Whens)
+		}
+	}
+
+	val := storage.NewNullValue()
+	if chosen != -1 {
+		var err error
+		if val, err = e.evaluate(results[chosen], scope); err != nil {
+			return val, err
+		}
+	}
+	return e.unifyBranches("CASE", results, chosen, val, scope)
+}
+
+// unifyBranches converts val, the value of the chosen branch of a CASE or
+// COALESCE (-1 for none), to the type common to all branches. Numbers
+// promote to the wider numeric type, a DECIMAL keeping its precision and
+// scale, and string literals take the type of the other branches; any
+// other mix is an error. Besides the chosen branch, only column references, literals and the types of casts are
+// looked at, so that the check does not depend on which branch is chosen.
+func (e *Executor) unifyBranches(context string, branches []parser.Expression, chosen int, val storage.Value, scope *rowScope) (storage.Value, error) {
+	var target *storage.ColumnDef
+	var numbers, strs []storage.Value
+	for i, branch := range branches {
+		v := val
+		if i != chosen {
+			switch branch.(type) {
+			case *parser.Identifier, *parser.IntegerLiteral, *parser.FloatLiteral, *parser.StringLiteral, *parser.BoolLiteral:
+				var err error
+				if v, err = e.evaluate(branch, scope); err != nil {
+					return v, err
+				}
+			case *parser.CastExpression:
+			default:
+				continue
+			}
+		}
+
+		var t storage.ColumnDef
+		ok := true
+		switch ex := branch.(type) {
+		case *parser.StringLiteral:
+			strs = append(strs, v)
+			continue
+		case *parser.IntegerLiteral, *parser.FloatLiteral:
+			numbers = append(numbers, v)
+			continue
+		case *parser.CastExpression:
+			var err error
+			if t, err = e.columnType(ex.DataType); err != nil {
+				return storage.NewNullValue(), err
+			}
+		default:
+			t, ok = scope.typeOf(branch, v)
+		}
+		switch {
+		case !ok:
+		case target == nil:
+			target = &t
+		case sameType(*target, t) && t.Type != storage.TypeDecimal:
+		case target.Type.IsNumeric() && t.Type.IsNumeric():
+			wider := widerColumnType(*target, t)
+			target = &wider
+		default:
+			return storage.NewNullValue(), fmt.Errorf("%s types %s and %s cannot be matched", context, typeName(*target), typeName(t))
+		}
+	}
+
+	// An integer literal takes any numeric type and a float literal any
+	// but an integer one. A DECIMAL widens to hold the literal's digits.
+	for _, n := range numbers {
+		switch {
+		case target == nil:
+			target = &storage.ColumnDef{Type: n.Type}
+		case !target.Type.IsNumeric():
+			return storage.NewNullValue(), fmt.Errorf("%s types %s and %s cannot be matched", context, typeName(*target), n.Type)
+		case n.Type.IsFloat() && target.Type.IsInteger():
+			target = &storage.ColumnDef{Type: widerNumeric(target.Type, n.Type)}
+		case target.Type == storage.TypeDecimal && target.Precision > 0:
+			if v, err := castToDecimal(n); err == nil {
+				d, _ := v.AsDecimal()
+				wider := widerColumnType(*target, storage.ColumnDef{Type: storage.TypeDecimal, Precision: d.Precision(), Scale: d.Scale()})
+				target = &wider
+			}
+		}
+	}
+	if target == nil || target.Type == storage.TypeString {
+		return val, nil
+	}
+	for _, str := range strs {
+		if _, err := castToColumnType(str, *target); err != nil {
+			return storage.NewNullValue(), fmt.Errorf("%s: %w", context, err)
+		}
+	}
+	return castToColumnType(val, *target)
+}
+
+// sameType reports whether two types are the same, taking ENUM types as
+// different unless they have the same name.
+func sameType(a, b storage.ColumnDef) bool {
+	if a.Enum != nil || b.Enum != nil {
+		return a.Enum != nil && b.Enum != nil && a.Enum.Name == b.Enum.Name
+	}
+	return a.Type == b.Type
+}
+
+// typeName returns the name of a type in an error message.
+func typeName(t storage.ColumnDef) string {
+	if t.Enum != nil {
+		return t.Enum.Name
+	}
+	return t.Type.String()
+}
+
+// castToColumnType converts v to a type, taking the labels of an ENUM
+// type and the precision and scale of a DECIMAL from the definition.
+func castToColumnType(v storage.Value, t storage.ColumnDef) (storage.Value, error) {
+	switch {
+	case t.Enum != nil:
+		return castToEnum(v, t.Enum)
+	case t.Type == storage.TypeDecimal && t.Precision > 0 && !v.IsNull:
+		return castToColumn(v, t)
+	}
+	return castValue(v, t.Type)
+}
+
+func (e *Executor) evaluateFunction(ex *parser.FunctionCall, scope *rowScope) (storage.Value, error) {
+	if isAggregate(ex) {
+		if scope != nil {
+			if val, ok := scope.aggregates[ex]; ok {
+				return val, nil
+			}
+		}
+		return storage.NewNullValue(), fmt.Errorf("aggregate function %s is not allowed here", ex.Name)
+	}
+	if ex.Star {
+		return storage.NewNullValue(), fmt.Errorf("function %s does not accept *", ex.Name)
+	}
+
+	switch ex.Name {
+	case "COALESCE":
+		if len(ex.Arguments) == 0 {
+			return storage.NewNullValue(), fmt.Errorf("COALESCE requires at least one argument")
+		}
+		for i, arg := range ex.Arguments {
+			val, err := e.evaluate(arg, scope)
+			if err != nil {
+				return val, err
+			}
+			if !val.IsNull {
+				return e.unifyBranches("COALESCE", ex.Arguments, i, val, scope)
+			}
+		}
+		return e.unifyBranches("COALESCE", ex.Arguments, -1, storage.NewNullValue(), scope)
+
+	case "NULLIF":
+		if len(ex.Arguments) != 2 {
+			return storage.NewNullValue(), fmt.Errorf("NULLIF requires exactly 2 arguments")
+		}
+		args, err := e.evaluateList(ex.Arguments, scope)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		eq, err := evaluateComparison("=", args[0], args[1])
+		if err != nil {
+			return eq, err
+		}
+		if matched, _ := eq.AsBool(); matched {
+			return storage.NewNullValue(), nil
+		}
+		return args[0], nil
+
+	case "NEXTVAL", "CURRVAL", "SETVAL":
+		args, err := e.evaluateList(ex.Arguments, scope)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return e.evaluateSequenceFunction(ex.Name, args)
+
+	default:
+		args, err := e.evaluateList(ex.Arguments, scope)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return callBuiltin(ex.Name, args)
+	}
+}
+
+func evaluatePrefix(op string, right storage.Value) (storage.Value, error) {
+	if right.IsNull {
+		return storage.NewNullValue(), nil
+	}
+
+	switch op {
+	case "NOT":
+		if b, ok := right.AsBool(); ok {
+			return storage.NewBoolValue(!b), nil
+		}
+		return storage.NewNullValue(), fmt.Errorf("argument of NOT must be type BOOL, not %s", right.Type)
+	case "-":
+		return evaluateNegate(right)
+	}
+
+	return storage.NewNullValue(), fmt.Errorf("operator %s is not defined for %s", op, right.Type)
+}
+
+func evaluateInfix(op string, left, right storage.Value) (storage.Value, error) {
+	switch op {
+	case "=", "<>", "<", "<=", ">", ">=":
+		return evaluateComparison(op, left, right)
+	case "||":
+		return evaluateConcat(left, right)
+	case "~", "!~":
+		return evaluateRegexMatch(op, left, right)
+	case "->", "->>":
+		return evaluateJSONArrow(op, left, right)
+	}
+
+	return evaluateArithmetic(op, left, right)
+}
+
+// coerceLiteralOperand converts a literal operand to the type of the other
+// operand when coercibleLiteral allows it, so that d > '2026-01-01'
+// compares dates and price * 1.1 stays exact.
+func coerceLiteralOperand(ex *parser.InfixExpression, left, right storage.Value) (storage.Value, storage.Value, error) {
+	var err error
+	if coercibleLiteral(ex.Operator, ex.Right, left) {
+		right, err = castToTypeOf(right, left)
+	} else if coercibleLiteral(ex.Operator, ex.Left, right) {
+		left, err = castToTypeOf(left, right)
+	}
+	return left, right, err
+}
+
+// coercibleLiteral reports whether literal converts to the type of other:
+// a string literal compared with a temporal, INTERVAL, JSON, ENUM or UUID
+// value, a float literal with a DECIMAL, or a non-negative integer literal
+// with a UINT64.
+func coercibleLiteral(op string, literal parser.Expression, other storage.Value) bool {
+	switch lit := literal.(type) {
+	case *parser.IntegerLiteral:
+		return other.Type == storage.TypeUint64 && lit.Value >= 0
+	case *parser.StringLiteral:
+		switch op {
+		case "=", "<>", "<", "<=", ">", ">=":
+			return other.IsTemporal() || other.Type == storage.TypeInterval || other.Type == storage.TypeJSON ||
+				other.Type == storage.TypeEnum || other.Type == storage.TypeUUID
+		}
+	case *parser.FloatLiteral:
+		return other.Type == storage.TypeDecimal
+	}
+	return false
+}
+
+// evaluateComparison compares two values. Comparing with NULL yields NULL.
+func evaluateComparison(op string, left, right storage.Value) (storage.Value, error) {
+	if left.IsNull || right.IsNull {
+		return storage.NewNullValue(), nil
+	}
+
+	if !canCompare(left, right) {
+		return storage.NewNullValue(), fmt.Errorf("cannot compare %s with %s", left.Type, right.Type)
+	}
+
+	c := left.Compare(right)
+	var result bool
+	switch op {
+	case "=":
+		result = c == 0
+	case "<>":
+		result = c != 0
+	case "<":
+		result = c < 0
+	case "<=":
+		result = c <= 0
+	case ">":
+		result = c > 0
+	case ">=":
+		result = c >= 0
+	}
+	return storage.NewBoolValue(result), nil
+}
+
+// canCompare reports whether two non-NULL values can be compared.
+func canCompare(a, b storage.Value) bool {
+	if a.Type == b.Type || (a.IsTemporal() && b.IsTemporal()) {
+		return true
+	}
+	_, aNum := numericValue(a)
+	_, bNum := numericValue(b)
+	return aNum && bNum
+}
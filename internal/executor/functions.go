@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// builtinFunction describes a scalar function callable from SQL.
+type builtinFunction struct {
+	// minArgs and maxArgs bound the number of arguments. A negative
+	// maxArgs means the function is variadic.
+	minArgs int
+	maxArgs int
+	// nullable marks functions that handle NULL arguments themselves.
+	// Other functions return NULL as soon as any argument is NULL.
+	nullable bool
+	call     func(args []storage.Value) (storage.Value, error)
+}
+
+// builtinFunctions is the registry of scalar functions, keyed by upper-case
+// name. Each function family registers itself from an init function.
+var builtinFunctions = map[string]builtinFunction{}
+
+func registerFunctions(fns map[string]builtinFunction) {
+	for name, fn := range fns {
+		if _, exists := builtinFunctions[name]; exists {
+			panic(fmt.Sprintf("function %s registered twice", name))
+		}
+		builtinFunctions[name] = fn
+	}
+}
+
+func callBuiltin(name string, args []storage.Value) (storage.Value, error) {
+	fn, ok := builtinFunctions[name]
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("function %s does not exist", name)
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return storage.NewNullValue(), fmt.Errorf("function %s: %s, got %d",
+			name, arityString(fn.minArgs, fn.maxArgs), len(args))
+	}
+
+	if !fn.nullable {
+		for _, arg := range args {
+			if arg.IsNull {
+				return storage.NewNullValue(), nil
+			}
+		}
+	}
+
+	val, err := fn.call(args)
+	if err != nil {
+		return storage.NewNullValue(), fmt.Errorf("function %s: %w", name, err)
+	}
+	return val, nil
+}
+
+func arityString(minArgs, maxArgs int) string {
+	switch {
+	case maxArgs < 0:
+		return fmt.Sprintf("expected at least %d argument(s)", minArgs)
+	case minArgs == maxArgs:
+		return fmt.Sprintf("expected %d argument(s)", minArgs)
+	default:
+		return fmt.Sprintf("expected %d to %d arguments", minArgs, maxArgs)
+	}
+}
+
+// stringArg returns args[i] as a string or an error naming its position.
+func stringArg(args []storage.Value, i int) (string, error) {
+	s, ok := args[i].AsString()
+	if !ok {
+		return "", fmt.Errorf("argument %d must be STRING, not %s", i+1, args[i].Type)
+	}
+	return s, nil
+}
+
+// intArg returns args[i] as an int64 or an error naming its position.
+func intArg(args []storage.Value, i int) (int64, error) {
+	n, ok := args[i].AsInt64()
+	if !ok {
+		return 0, fmt.Errorf("argument %d must be INT64, not %s", i+1, args[i].Type)
+	}
+	return n, nil
+}
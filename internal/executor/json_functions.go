@@ -0,0 +1,362 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+func init() {
+	registerFunctions(map[string]builtinFunction{
+		"JSON_EXTRACT":      {minArgs: 2, maxArgs: 2, call: fnJSONExtract},
+		"JSON_ARRAY_LENGTH": {minArgs: 1, maxArgs: 2, call: fnJSONArrayLength},
+		"JSON_KEYS":         {minArgs: 1, maxArgs: 2, call: fnJSONKeys},
+	})
+}
+
+// castToJSON parses a string as a JSON document. JSON values are kept in
+// compact form, so documents that differ only in whitespace are equal.
+func castToJSON(v storage.Value) (storage.Value, error) {
+	s, ok := v.AsString()
+	if !ok {
+		return storage.NewNullValue(), cannotCast(v.Type, storage.TypeJSON)
+	}
+	if !json.Valid([]byte(s)) {
+		return storage.NewNullValue(), invalidInput(storage.TypeJSON, s)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		return storage.NewNullValue(), invalidInput(storage.TypeJSON, s)
+	}
+	return storage.NewJSONValue(buf.String()), nil
+}
+
+// castFromJSON converts a JSON scalar to another type. A JSON string is
+// converted as a STRING value would be, a number as a numeric literal and
+// null to NULL. Objects and arrays can only be cast to STRING.
+func castFromJSON(v storage.Value, target storage.DataType) (storage.Value, error) {
+	doc, _ := v.AsJSON()
+	switch doc[0] {
+	case '{', '[':
+		return storage.NewNullValue(), fmt.Errorf("cannot cast JSON %s to %s", jsonKind(doc), target)
+	case '"', 'n':
+		return castValue(jsonText(json.RawMessage(doc)), target)
+	case 't', 'f':
+		return castValue(storage.NewBoolValue(doc == "true"), target)
+	}
+
+	if target.IsFloat() {
+		f, err := strconv.ParseFloat(doc, 64)
+		if err != nil {
+			return storage.NewNullValue(), errFloatOverflow
+		}
+		return castValue(storage.NewFloat64Value(f), target)
+	}
+	d, err := storage.ParseDecimal(doc)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return castValue(storage.NewDecimalValue(d), target)
+}
+
+// jsonKind names the kind of a JSON document for error messages.
+func jsonKind(doc string) string {
+	switch doc[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// jsonText returns a JSON value as ->> does: a string without its quotes,
+// null as NULL and anything else as JSON text.
+func jsonText(raw json.RawMessage) storage.Value {
+	switch raw[0] {
+	case 'n':
+		return storage.NewNullValue()
+	case '"':
+		var s string
+		_ = json.Unmarshal(raw, &s)
+		return storage.NewStringValue(s)
+	default:
+		return storage.NewStringValue(string(raw))
+	}
+}
+
+// jsonField is a member of a JSON object.
+type jsonField struct {
+	key   string
+	value json.RawMessage
+}
+
+// jsonObject returns the members of a JSON object in document order, or
+// false if doc is not an object.
+func jsonObject(doc json.RawMessage) ([]jsonField, bool) {
+	if doc[0] != '{' {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	if _, err := dec.Token(); err != nil {
+		return nil, false
+	}
+	var fields []jsonField
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		fields = append(fields, jsonField{key: key.(string), value: value})
+	}
+	return fields, true
+}
+
+// jsonArray returns the elements of a JSON array, or false if doc is not
+// an array.
+func jsonArray(doc json.RawMessage) ([]json.RawMessage, bool) {
+	if doc[0] != '[' {
+		return nil, false
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(doc, &elems); err != nil {
+		return nil, false
+	}
+	return elems, true
+}
+
+// jsonStep looks up an object member by a string key or an array element
+// by an integer index, counting negative indexes from the end. It reports
+// false if there is no such member or element.
+func jsonStep(doc json.RawMessage, step any) (json.RawMessage, bool) {
+	switch s := step.(type) {
+	case string:
+		fields, _ := jsonObject(doc)
+		// As in PostgreSQL, the last of duplicate keys wins.
+		for i := len(fields) - 1; i >= 0; i-- {
+			if fields[i].key == s {
+				return fields[i].value, true
+			}
+		}
+	case int64:
+		elems, _ := jsonArray(doc)
+		if s < 0 {
+			s += int64(len(elems))
+		}
+		if s >= 0 && s < int64(len(elems)) {
+			return elems[s], true
+		}
+	}
+	return nil, false
+}
+
+// evaluateJSONArrow implements -> and ->>, which take an object member by
+// key or an array element by index. -> yields JSON and ->> yields the
+// value as text. A missing member or element yields NULL.
+func evaluateJSONArrow(op string, left, right storage.Value) (storage.Value, error) {
+	if left.IsNull || right.IsNull {
+		return storage.NewNullValue(), nil
+	}
+	doc, ok := left.AsJSON()
+	var step any
+	if key, isKey := right.AsString(); isKey {
+		step = key
+	} else if index, isIndex := right.AsInt64(); isIndex && right.Type.IsInteger() {
+		step = index
+	} else {
+		ok = false
+	}
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("operator %s is not defined for %s and %s", op, left.Type, right.Type)
+	}
+
+	value, found := jsonStep(json.RawMessage(doc), step)
+	switch {
+	case !found:
+		return storage.NewNullValue(), nil
+	case op == "->>":
+		return jsonText(value), nil
+	default:
+		return storage.NewJSONValue(string(value)), nil
+	}
+}
+
+// parseJSONPath parses a path such as $.a.b[0] or $["a b"] into its steps:
+// strings for object keys and int64s for array indexes.
+func parseJSONPath(path string) ([]any, error) {
+	invalid := fmt.Errorf("invalid JSON path %q", path)
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, invalid
+	}
+
+	var steps []any
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, `"`) {
+				key, n, err := quotedJSONKey(rest)
+				if err != nil {
+					return nil, invalid
+				}
+				steps, rest = append(steps, key), rest[n:]
+				continue
+			}
+			n := strings.IndexAny(rest, ".[")
+			if n < 0 {
+				n = len(rest)
+			}
+			if n == 0 {
+				return nil, invalid
+			}
+			steps, rest = append(steps, rest[:n]), rest[n:]
+		case '[':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, `"`) {
+				key, n, err := quotedJSONKey(rest)
+				if err != nil || !strings.HasPrefix(rest[n:], "]") {
+					return nil, invalid
+				}
+				steps, rest = append(steps, key), rest[n+1:]
+				continue
+			}
+			digits, after, found := strings.Cut(rest, "]")
+			index, err := strconv.ParseInt(strings.TrimSpace(digits), 10, 64)
+			if !found || err != nil || index < 0 {
+				return nil, invalid
+			}
+			steps, rest = append(steps, index), after
+		default:
+			return nil, invalid
+		}
+	}
+	return steps, nil
+}
+
+// quotedJSONKey reads a double-quoted key at the start of s and returns it
+// with the number of bytes it took.
+func quotedJSONKey(s string) (string, int, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	var key string
+	if err := dec.Decode(&key); err != nil {
+		return "", 0, err
+	}
+	return key, int(dec.InputOffset()), nil
+}
+
+// jsonArg returns args[i] as a JSON document. A STRING argument is parsed
+// as JSON.
+func jsonArg(args []storage.Value, i int) (json.RawMessage, error) {
+	v := args[i]
+	if v.Type == storage.TypeString {
+		var err error
+		if v, err = castToJSON(v); err != nil {
+			return nil, err
+		}
+	}
+	doc, ok := v.AsJSON()
+	if !ok {
+		return nil, fmt.Errorf("argument %d must be JSON, not %s", i+1, args[i].Type)
+	}
+	return json.RawMessage(doc), nil
+}
+
+// jsonPathArg returns the value that the path in args[i] selects from doc,
+// or false if the path selects nothing. Without args[i] the whole document
+// is selected.
+func jsonPathArg(doc json.RawMessage, args []storage.Value, i int) (json.RawMessage, bool, error) {
+	if i >= len(args) {
+		return doc, true, nil
+	}
+	path, err := stringArg(args, i)
+	if err != nil {
+		return nil, false, err
+	}
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, step := range steps {
+		var found bool
+		if doc, found = jsonStep(doc, step); !found {
+			return nil, false, nil
+		}
+	}
+	return doc, true, nil
+}
+
+// fnJSONExtract returns the value at a path, or NULL if there is none.
+func fnJSONExtract(args []storage.Value) (storage.Value, error) {
+	doc, err := jsonArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	value, found, err := jsonPathArg(doc, args, 1)
+	if err != nil || !found {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewJSONValue(string(value)), nil
+}
+
+// fnJSONArrayLength returns the number of elements of an array, optionally
+// at a path.
+func fnJSONArrayLength(args []storage.Value) (storage.Value, error) {
+	doc, err := jsonArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	value, found, err := jsonPathArg(doc, args, 1)
+	if err != nil || !found {
+		return storage.NewNullValue(), err
+	}
+	elems, ok := jsonArray(value)
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("cannot get array length of a JSON %s", jsonKind(string(value)))
+	}
+	return storage.NewInt64Value(int64(len(elems))), nil
+}
+
+// fnJSONKeys returns the keys of an object, optionally at a path, as a JSON
+// array in document order. It returns NULL for anything but an object.
+func fnJSONKeys(args []storage.Value) (storage.Value, error) {
+	doc, err := jsonArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	value, found, err := jsonPathArg(doc, args, 1)
+	if err != nil || !found {
+		return storage.NewNullValue(), err
+	}
+	fields, ok := jsonObject(value)
+	if !ok {
+		return storage.NewNullValue(), nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.key
+	}
+	if err := enc.Encode(keys); err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewJSONValue(strings.TrimSuffix(buf.String(), "\n")), nil
+}
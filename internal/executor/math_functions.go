@@ -0,0 +1,437 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+var (
+	errDivisionByZero   = errors.New("division by zero")
+	errIntegerOverflow  = errors.New("INT64 out of range")
+	errUnsignedOverflow = errors.New("UINT64 out of range")
+	errFloatOverflow    = errors.New("FLOAT64 out of range")
+)
+
+func init() {
+	registerFunctions(map[string]builtinFunction{
+		"ABS":      {minArgs: 1, maxArgs: 1, call: fnAbs},
+		"ROUND":    {minArgs: 1, maxArgs: 3, call: fnRound},
+		"CEIL":     {minArgs: 1, maxArgs: 1, call: roundingFunc(math.Ceil, storage.RoundCeiling)},
+		"CEILING":  {minArgs: 1, maxArgs: 1, call: roundingFunc(math.Ceil, storage.RoundCeiling)},
+		"FLOOR":    {minArgs: 1, maxArgs: 1, call: roundingFunc(math.Floor, storage.RoundFloor)},
+		"POWER":    {minArgs: 2, maxArgs: 2, call: fnPower},
+		"POW":      {minArgs: 2, maxArgs: 2, call: fnPower},
+		"SQRT":     {minArgs: 1, maxArgs: 1, call: fnSqrt},
+		"LN":       {minArgs: 1, maxArgs: 1, call: fnLn},
+		"MOD":      {minArgs: 2, maxArgs: 2, call: fnMod},
+		"GREATEST": {minArgs: 1, maxArgs: -1, nullable: true, call: extremumFunc(1)},
+		"LEAST":    {minArgs: 1, maxArgs: -1, nullable: true, call: extremumFunc(-1)},
+	})
+}
+
+// evaluateArithmetic applies + - * / % to two values. The promotion rules
+// are:
+//
+//   - Integer op integer yields INT64, whatever the widths of the
+//     operands, except that UINT64 op UINT64 yields UINT64. Results that
+//     do not fit are an error rather than wrapping around. Division
+//     truncates toward zero.
+//   - Integer op float, in either order, promotes the integer and yields
+//     FLOAT64; FLOAT32 is widened to FLOAT64 as well. Results that
+//     overflow to infinity are an error.
+//   - DECIMAL op DECIMAL or integer is computed exactly and yields DECIMAL,
+//     with scales as described at decimalArithmetic. DECIMAL op float
+//     yields FLOAT64.
+//   - Division or modulo by zero is an error for both types.
+//   - A NULL operand yields NULL.
+//
+// Operations on dates, timestamps and intervals are described at
+// evaluateDatetimeArithmetic.
+func evaluateArithmetic(op string, left, right storage.Value) (storage.Value, error) {
+	if left.IsNull || right.IsNull {
+		return storage.NewNullValue(), nil
+	}
+
+	if left.IsTemporal() || right.IsTemporal() || left.Type == storage.TypeInterval || right.Type == storage.TypeInterval {
+		return evaluateDatetimeArithmetic(op, left, right)
+	}
+
+	if left.Type == storage.TypeDecimal || right.Type == storage.TypeDecimal {
+		if l, ok := exactValue(left); ok {
+			if r, ok := exactValue(right); ok {
+				return decimalArithmetic(op, l, r)
+			}
+		}
+	}
+
+	if l, ok := left.AsUint64(); ok {
+		if r, ok := right.AsUint64(); ok {
+			return uintArithmetic(op, l, r)
+		}
+	}
+
+	if left.Type.IsInteger() && right.Type.IsInteger() {
+		l, lok := left.AsInt64()
+		r, rok := right.AsInt64()
+		if !lok || !rok {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+		return intArithmetic(op, l, r)
+	}
+
+	l, lok := numericValue(left)
+	r, rok := numericValue(right)
+	if !lok || !rok {
+		return storage.NewNullValue(), fmt.Errorf("operator %s is not defined for %s and %s",
+			op, left.Type, right.Type)
+	}
+	return floatArithmetic(op, l, r)
+}
+
+func intArithmetic(op string, l, r int64) (storage.Value, error) {
+	var result int64
+	switch op {
+	case "+":
+		result = l + r
+		if (result > l) != (r > 0) {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+	case "-":
+		result = l - r
+		if (result < l) != (r > 0) {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+	case "*":
+		result = l * r
+		if l != 0 && (result/l != r || (l == -1 && r == math.MinInt64)) {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+	case "/":
+		if r == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		if l == math.MinInt64 && r == -1 {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+		result = l / r
+	case "%":
+		if r == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		result = l % r
+	default:
+		return storage.NewNullValue(), fmt.Errorf("unknown operator: %s", op)
+	}
+	return storage.NewInt64Value(result), nil
+}
+
+func uintArithmetic(op string, l, r uint64) (storage.Value, error) {
+	var result uint64
+	switch op {
+	case "+":
+		result = l + r
+		if result < l {
+			return storage.NewNullValue(), errUnsignedOverflow
+		}
+	case "-":
+		if r > l {
+			return storage.NewNullValue(), errUnsignedOverflow
+		}
+		result = l - r
+	case "*":
+		result = l * r
+		if l != 0 && result/l != r {
+			return storage.NewNullValue(), errUnsignedOverflow
+		}
+	case "/":
+		if r == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		result = l / r
+	case "%":
+		if r == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		result = l % r
+	default:
+		return storage.NewNullValue(), fmt.Errorf("unknown operator: %s", op)
+	}
+	return storage.NewUint64Value(result), nil
+}
+
+func floatArithmetic(op string, l, r float64) (storage.Value, error) {
+	var result float64
+	switch op {
+	case "+":
+		result = l + r
+	case "-":
+		result = l - r
+	case "*":
+		result = l * r
+	case "/":
+		if r == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		result = l / r
+	case "%":
+		if r == 0 {
+			return storage.NewNullValue(), errDivisionByZero
+		}
+		result = math.Mod(l, r)
+	default:
+		return storage.NewNullValue(), fmt.Errorf("unknown operator: %s", op)
+	}
+	return checkedFloat(result, l, r)
+}
+
+// checkedFloat rejects an infinite result computed from finite inputs.
+func checkedFloat(result float64, inputs ...float64) (storage.Value, error) {
+	if math.IsInf(result, 0) {
+		for _, in := range inputs {
+			if math.IsInf(in, 0) {
+				return storage.NewFloat64Value(result), nil
+			}
+		}
+		return storage.NewNullValue(), errFloatOverflow
+	}
+	return storage.NewFloat64Value(result), nil
+}
+
+func evaluateNegate(v storage.Value) (storage.Value, error) {
+	if v.Type.IsInteger() {
+		i, ok := v.AsInt64()
+		if !ok || i == math.MinInt64 {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+		return storage.NewInt64Value(-i), nil
+	}
+	if f, ok := v.AsFloat64(); ok {
+		if v.Type == storage.TypeFloat32 {
+			return storage.NewFloat32Value(float32(-f)), nil
+		}
+		return storage.NewFloat64Value(-f), nil
+	}
+	if d, ok := v.AsDecimal(); ok {
+		return storage.NewDecimalValue(d.Neg()), nil
+	}
+	if iv, ok := v.AsInterval(); ok {
+		neg, err := negateInterval(iv)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return storage.NewIntervalValue(neg), nil
+	}
+	return storage.NewNullValue(), fmt.Errorf("operator - is not defined for %s", v.Type)
+}
+
+// numericValue returns a numeric value as a float64.
+func numericValue(v storage.Value) (float64, bool) {
+	if i, ok := v.AsInt64(); ok {
+		return float64(i), true
+	}
+	if u, ok := v.AsUint64(); ok {
+		return float64(u), true
+	}
+	if d, ok := v.AsDecimal(); ok {
+		return d.Float64(), true
+	}
+	return v.AsFloat64()
+}
+
+// numericArg returns args[i] as a float64 or an error naming its position.
+func numericArg(args []storage.Value, i int) (float64, error) {
+	f, ok := numericValue(args[i])
+	if !ok {
+		return 0, fmt.Errorf("argument %d must be numeric, not %s", i+1, args[i].Type)
+	}
+	return f, nil
+}
+
+func fnAbs(args []storage.Value) (storage.Value, error) {
+	if args[0].Type == storage.TypeUint64 {
+		return args[0], nil
+	}
+	if i, ok := args[0].AsInt64(); ok {
+		if i == math.MinInt64 {
+			return storage.NewNullValue(), errIntegerOverflow
+		}
+		return storage.NewInt64Value(max(i, -i)), nil
+	}
+	if d, ok := args[0].AsDecimal(); ok {
+		if d.Sign() < 0 {
+			d = d.Neg()
+		}
+		return storage.NewDecimalValue(d), nil
+	}
+	f, err := numericArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewFloat64Value(math.Abs(f)), nil
+}
+
+// fnRound rounds to n decimal places (default 0), with halves rounded away
+// from zero. A negative n rounds to the left of the decimal point. INT64
+// input stays INT64 and DECIMAL input gets scale n. An optional third
+// argument names another rounding mode, such as 'half_even'.
+func fnRound(args []storage.Value) (storage.Value, error) {
+	var places int64
+	if len(args) > 1 {
+		var err error
+		if places, err = intArg(args, 1); err != nil {
+			return storage.NewNullValue(), err
+		}
+	}
+	if len(args) > 2 || args[0].Type == storage.TypeDecimal {
+		mode := storage.RoundHalfUp
+		if len(args) > 2 {
+			var err error
+			if mode, err = roundingModeArg(args, 2); err != nil {
+				return storage.NewNullValue(), err
+			}
+		}
+		if _, ok := numericValue(args[0]); !ok {
+			return storage.NewNullValue(), fmt.Errorf("argument 1 must be numeric, not %s", args[0].Type)
+		}
+		return roundExact(args[0], places, mode)
+	}
+
+	if i, ok := args[0].AsInt64(); ok {
+		if places >= 0 {
+			return storage.NewInt64Value(i), nil
+		}
+		if places < -18 {
+			return storage.NewInt64Value(0), nil
+		}
+		unit := int64(math.Pow10(int(-places)))
+		rounded := (i / unit) * unit
+		if rem := i % unit; rem*2 >= unit {
+			return intArithmetic("+", rounded, unit)
+		} else if rem*2 <= -unit {
+			return intArithmetic("-", rounded, unit)
+		}
+		return storage.NewInt64Value(rounded), nil
+	}
+
+	f, err := numericArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	if places == 0 {
+		return storage.NewFloat64Value(math.Round(f)), nil
+	}
+	if places > 308 || places < -308 {
+		if places > 0 {
+			return storage.NewFloat64Value(f), nil
+		}
+		return storage.NewFloat64Value(0), nil
+	}
+	scale := math.Pow10(int(places))
+	scaled := f * scale
+	if math.IsInf(scaled, 0) {
+		return storage.NewFloat64Value(f), nil
+	}
+	return storage.NewFloat64Value(math.Round(scaled) / scale), nil
+}
+
+// roundingFunc wraps CEIL and FLOOR, which keep integer input unchanged and
+// round DECIMAL input to scale 0 with mode.
+func roundingFunc(f func(float64) float64, mode storage.RoundingMode) func([]storage.Value) (storage.Value, error) {
+	return func(args []storage.Value) (storage.Value, error) {
+		if args[0].Type.IsInteger() {
+			return args[0], nil
+		}
+		if d, ok := args[0].AsDecimal(); ok {
+			return storage.NewDecimalValue(d.Rescale(0, mode)), nil
+		}
+		x, err := numericArg(args, 0)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return storage.NewFloat64Value(f(x)), nil
+	}
+}
+
+func fnPower(args []storage.Value) (storage.Value, error) {
+	base, err := numericArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	exp, err := numericArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	if base == 0 && exp < 0 {
+		return storage.NewNullValue(), fmt.Errorf("zero raised to a negative power is undefined")
+	}
+	if base < 0 && exp != math.Trunc(exp) {
+		return storage.NewNullValue(), fmt.Errorf("a negative number raised to a non-integer power yields a complex result")
+	}
+	return checkedFloat(math.Pow(base, exp), base, exp)
+}
+
+func fnSqrt(args []storage.Value) (storage.Value, error) {
+	x, err := numericArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	if x < 0 {
+		return storage.NewNullValue(), fmt.Errorf("cannot take square root of a negative number")
+	}
+	return storage.NewFloat64Value(math.Sqrt(x)), nil
+}
+
+func fnLn(args []storage.Value) (storage.Value, error) {
+	x, err := numericArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	switch {
+	case x == 0:
+		return storage.NewNullValue(), fmt.Errorf("cannot take logarithm of zero")
+	case x < 0:
+		return storage.NewNullValue(), fmt.Errorf("cannot take logarithm of a negative number")
+	}
+	return storage.NewFloat64Value(math.Log(x)), nil
+}
+
+func fnMod(args []storage.Value) (storage.Value, error) {
+	return evaluateArithmetic("%", args[0], args[1])
+}
+
+// extremumFunc builds GREATEST (sign 1) and LEAST (sign -1). NULL arguments
+// are ignored; the result is NULL only if every argument is NULL. Mixed
+// numeric types yield the widest of them, as at widerNumeric.
+func extremumFunc(sign int) func([]storage.Value) (storage.Value, error) {
+	return func(args []storage.Value) (storage.Value, error) {
+		best := storage.NewNullValue()
+		promote := false
+		target := storage.TypeInt64
+		for _, arg := range args {
+			if arg.IsNull {
+				continue
+			}
+			target = widerNumeric(target, arg.Type)
+			if best.IsNull {
+				best = arg
+				continue
+			}
+			if !canCompare(best, arg) {
+				return storage.NewNullValue(), fmt.Errorf("cannot compare %s with %s", best.Type, arg.Type)
+			}
+			if best.Type != arg.Type {
+				promote = true
+			}
+			if arg.Compare(best)*sign > 0 {
+				best = arg
+			}
+		}
+		if _, ok := numericValue(best); ok && promote {
+			return castValue(best, target)
+		}
+		return best, nil
+	}
+}
@@ -0,0 +1,316 @@
+package executor
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// executeOnConflict inserts a row unless it conflicts with an existing row
+// under one of the arbiter constraints, in which case the row is skipped
+// (DO NOTHING) or the existing row is updated (DO UPDATE). In DO UPDATE the
+// proposed row is available as EXCLUDED.
+func (e *Executor) executeOnConflict(table *storage.Table, stmt *parser.InsertStatement, values []storage.Value) (*Result, error) {
+	schema := table.Schema
+	clause := stmt.OnConflict
+
+	arbiters, err := conflictArbiters(schema, clause)
+	if err != nil {
+		return nil, err
+	}
+
+	var scope *rowScope
+	var targets []int
+	if clause.DoUpdate {
+		scope = newRowScope(schema, nil).join(tableScope("excluded", schema, nil))
+		if targets, err = resolveAssignments(schema, clause.Assignments, scope); err != nil {
+			return nil, err
+		}
+		if clause.Where != nil {
+			if err := checkColumns(clause.Where, scope); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rowID, conflict := uint64(0), false
+	for _, name := range arbiters {
+		if rowID, conflict = table.FindConflict(values, name); conflict {
+			break
+		}
+	}
+
+	if !conflict {
+		if err := table.Insert(values); err != nil {
+			return nil, err
+		}
+		if err := table.Save(); err != nil {
+			return nil, err
+		}
+		return e.returning(schema, stmt.Returning, [][]storage.Value{values}, "1 row inserted")
+	}
+
+	if !clause.DoUpdate {
+		return e.returning(schema, stmt.Returning, nil, "0 rows inserted")
+	}
+
+	existing := table.Row(rowID)
+	scope = newRowScope(schema, existing).join(tableScope("excluded", schema, values))
+	if clause.Where != nil {
+		matched, err := e.matches(clause.Where, scope)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			return e.returning(schema, stmt.Returning, nil, "0 rows inserted")
+		}
+	}
+
+	updated, err := e.applyAssignments(schema, clause.Assignments, targets, scope, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := scanRows(table)
+	rows[rowID] = updated
+
+	if err := table.ReplaceRows(rows); err != nil {
+		return nil, err
+	}
+	if err := table.Save(); err != nil {
+		return nil, err
+	}
+
+	return e.returning(schema, stmt.Returning, [][]storage.Value{updated}, "1 row updated")
+}
+
+// conflictArbiters returns the names of the unique constraints an ON
+// CONFLICT clause checks. A column list selects the constraint on exactly
+// those columns. Without a conflict target, DO NOTHING checks every
+// constraint.
+func conflictArbiters(schema *storage.TableSchema, clause *parser.OnConflictClause) ([]string, error) {
+	switch {
+	case clause.Constraint != "":
+		for _, uc := range schema.UniqueKeys {
+			if uc.Name == clause.Constraint {
+				return []string{uc.Name}, nil
+			}
+		}
+		return nil, fmt.Errorf("constraint %q for table %q does not exist", clause.Constraint, schema.Name)
+
+	case len(clause.Columns) > 0:
+		var names []string
+		for _, uc := range schema.UniqueKeys {
+			if sameColumns(uc.Columns, clause.Columns) {
+				names = append(names, uc.Name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("there is no unique constraint matching the ON CONFLICT specification")
+		}
+		return names, nil
+
+	case clause.DoUpdate:
+		return nil, fmt.Errorf("ON CONFLICT DO UPDATE requires a conflict target")
+
+	default:
+		var names []string
+		for _, uc := range schema.UniqueKeys {
+			names = append(names, uc.Name)
+		}
+		return names, nil
+	}
+}
+
+// sameColumns reports whether two column lists name the same set.
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, name := range b {
+		if !slices.Contains(a, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// executeMerge joins the target table with the source table on the ON
+// condition. Each target row matched by a source row takes the first
+// WHEN MATCHED clause whose condition holds; each unmatched source row
+// takes the first WHEN NOT MATCHED clause. All changes are applied
+// together, so constraints are checked against the final contents.
+func (e *Executor) executeMerge(stmt *parser.MergeStatement) (*Result, error) {
+	target, err := e.getTable(stmt.TargetTable)
+	if err != nil {
+		return nil, err
+	}
+	source, err := e.getTable(stmt.SourceTable)
+	if err != nil {
+		return nil, err
+	}
+
+	targetName := cmp.Or(stmt.TargetAlias, stmt.TargetTable)
+	sourceName := cmp.Or(stmt.SourceAlias, stmt.SourceTable)
+	if targetName == sourceName {
+		return nil, fmt.Errorf("table name %q specified more than once", targetName)
+	}
+
+	schema := target.Schema
+	joined := tableScope(targetName, schema, nil).join(tableScope(sourceName, source.Schema, nil))
+	sourceOnly := tableScope(sourceName, source.Schema, nil)
+
+	if err := checkColumns(stmt.On, joined); err != nil {
+		return nil, err
+	}
+	targets := make([][]int, len(stmt.Clauses))
+	for i, clause := range stmt.Clauses {
+		scope := sourceOnly
+		if clause.Matched {
+			scope = joined
+		}
+		if clause.Condition != nil {
+			if err := checkColumns(clause.Condition, scope); err != nil {
+				return nil, err
+			}
+		}
+		switch clause.Action {
+		case parser.MergeUpdate:
+			if targets[i], err = resolveAssignments(schema, clause.Assignments, scope); err != nil {
+				return nil, err
+			}
+		case parser.MergeInsert:
+			for _, value := range clause.Values {
+				if err := checkColumns(value, scope); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	targetRows := scanRows(target)
+	sourceRows := scanRows(source)
+
+	rows := slices.Clone(targetRows)
+	deleted := make([]bool, len(targetRows))
+	modified := make([]bool, len(targetRows))
+	var inserted [][]storage.Value
+	affected := 0
+
+	for _, sourceRow := range sourceRows {
+		sourceScope := tableScope(sourceName, source.Schema, sourceRow)
+		matched := false
+
+		for i, targetRow := range targetRows {
+			scope := tableScope(targetName, schema, targetRow).join(sourceScope)
+			on, err := e.evaluate(stmt.On, scope)
+			if err != nil {
+				return nil, err
+			}
+			ok, err := isTrue(on, "ON")
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			matched = true
+
+			n, err := e.mergeClause(stmt.Clauses, true, scope)
+			if err != nil {
+				return nil, err
+			}
+			if n == -1 || stmt.Clauses[n].Action == parser.MergeDoNothing {
+				continue
+			}
+			if modified[i] {
+				return nil, fmt.Errorf("MERGE command cannot affect row a second time")
+			}
+			modified[i] = true
+			affected++
+
+			if stmt.Clauses[n].Action == parser.MergeDelete {
+				deleted[i] = true
+				continue
+			}
+			if rows[i], err = e.applyAssignments(schema, stmt.Clauses[n].Assignments, targets[n], scope, targetRow); err != nil {
+				return nil, err
+			}
+		}
+
+		if matched {
+			continue
+		}
+
+		n, err := e.mergeClause(stmt.Clauses, false, sourceScope)
+		if err != nil {
+			return nil, err
+		}
+		if n == -1 || stmt.Clauses[n].Action == parser.MergeDoNothing {
+			continue
+		}
+		row, err := e.newRow(schema, stmt.Clauses[n].Columns, stmt.Clauses[n].Values, sourceScope)
+		if err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, row)
+		affected++
+	}
+
+	if affected > 0 {
+		var final [][]storage.Value
+		for i, row := range rows {
+			if !deleted[i] {
+				final = append(final, row)
+			}
+		}
+		final = append(final, inserted...)
+
+		if err := target.ReplaceRows(final); err != nil {
+			return nil, err
+		}
+		if err := target.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Result{Message: fmt.Sprintf("%d row(s) merged", affected)}, nil
+}
+
+// mergeClause returns the index of the first WHEN [NOT] MATCHED clause
+// whose condition holds, or -1 if there is none.
+func (e *Executor) mergeClause(clauses []parser.MergeClause, matched bool, scope *rowScope) (int, error) {
+	for i, clause := range clauses {
+		if clause.Matched != matched {
+			continue
+		}
+		if clause.Condition == nil {
+			return i, nil
+		}
+		val, err := e.evaluate(clause.Condition, scope)
+		if err != nil {
+			return -1, err
+		}
+		ok, err := isTrue(val, "WHEN")
+		if err != nil {
+			return -1, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// scanRows reads all rows of a table.
+func scanRows(table *storage.Table) [][]storage.Value {
+	var rows [][]storage.Value
+	_ = table.Scan(func(rowIndex uint64, row []storage.Value) bool {
+		rows = append(rows, row)
+		return true
+	})
+	return rows
+}
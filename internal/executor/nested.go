@@ -0,0 +1,288 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// nestedColumnType parses the element type of LIST<type> or the fields of
+// STRUCT<name type, ...>.
+func (e *Executor) nestedColumnType(col storage.ColumnDef, name string) (storage.ColumnDef, error) {
+	open := strings.IndexByte(name, '<')
+	if open < 0 || !strings.HasSuffix(name, ">") {
+		if col.Type == storage.TypeList {
+			return storage.ColumnDef{}, fmt.Errorf("type LIST requires an element type, as in LIST<INT64>")
+		}
+		return storage.ColumnDef{}, fmt.Errorf("type STRUCT requires field types, as in STRUCT<a INT64>")
+	}
+	inner := name[open+1 : len(name)-1]
+
+	if col.Type == storage.TypeList {
+		elem, err := e.columnType(inner)
+		if err != nil {
+			return storage.ColumnDef{}, err
+		}
+		elem.Name, elem.Nullable = "element", true
+		col.Children = []storage.ColumnDef{elem}
+		return col, nil
+	}
+
+	for _, field := range splitFields(inner) {
+		fieldName, typeName, _ := strings.Cut(strings.TrimSpace(field), " ")
+		child, err := e.columnType(strings.TrimSpace(typeName))
+		if err != nil {
+			return storage.ColumnDef{}, err
+		}
+		if slices.ContainsFunc(col.Children, func(c storage.ColumnDef) bool { return c.Name == fieldName }) {
+			return storage.ColumnDef{}, fmt.Errorf("duplicate field %q in STRUCT type", fieldName)
+		}
+		child.Name, child.Nullable, child.Position = fieldName, true, len(col.Children)
+		col.Children = append(col.Children, child)
+	}
+	return col, nil
+}
+
+// splitFields splits the field list of a STRUCT type at the commas that
+// are not inside a nested type or type modifiers.
+func splitFields(s string) []string {
+	var fields []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				fields = append(fields, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, s[start:])
+}
+
+// castToColumn casts a value to the type of a column: a DECIMAL is fitted
+// to the precision and scale of the column, and the elements of a LIST and
+// fields of a STRUCT are cast to the types of their children. STRUCT fields
+// are matched by name, with missing fields set to NULL.
+func castToColumn(v storage.Value, col storage.ColumnDef) (storage.Value, error) {
+	if v.IsNull {
+		return v, nil
+	}
+	switch col.Type {
+	case storage.TypeDecimal:
+		v, err := castValue(v, storage.TypeDecimal)
+		if err != nil {
+			return v, err
+		}
+		return fitDecimal(v, col.Precision, col.Scale)
+	case storage.TypeList:
+		elems, ok := v.AsList()
+		if !ok {
+			return storage.NewNullValue(), fmt.Errorf("cannot cast %s to %s", v.Type, col.TypeName())
+		}
+		cast := make([]storage.Value, len(elems))
+		for i, elem := range elems {
+			var err error
+			if cast[i], err = castToColumn(elem, col.Children[0]); err != nil {
+				return storage.NewNullValue(), err
+			}
+		}
+		return storage.NewListValue(cast), nil
+	case storage.TypeStruct:
+		s, ok := v.AsStruct()
+		if !ok {
+			return storage.NewNullValue(), fmt.Errorf("cannot cast %s to %s", v.Type, col.TypeName())
+		}
+		for _, name := range s.Names {
+			if !slices.ContainsFunc(col.Children, func(c storage.ColumnDef) bool { return c.Name == name }) {
+				return storage.NewNullValue(), fmt.Errorf("STRUCT type has no field %q", name)
+			}
+		}
+		names := make([]string, len(col.Children))
+		values := make([]storage.Value, len(col.Children))
+		for i, child := range col.Children {
+			names[i] = child.Name
+			field, found := s.Field(child.Name)
+			if !found {
+				values[i] = storage.NewNullValue()
+				continue
+			}
+			var err error
+			if values[i], err = castToColumn(field, child); err != nil {
+				return storage.NewNullValue(), err
+			}
+		}
+		return storage.NewStructValue(names, values), nil
+	case storage.TypeEnum:
+		return castToEnum(v, col.Enum)
+	default:
+		return castValue(v, col.Type)
+	}
+}
+
+// evaluateListLiteral builds a LIST from its elements, which must share a
+// type. Numbers of different types are promoted to a common type.
+func (e *Executor) evaluateListLiteral(lit *parser.ListLiteral, scope *rowScope) (storage.Value, error) {
+	elems, err := e.evaluateList(lit.Elements, scope)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	target := storage.TypeNull
+	for _, elem := range elems {
+		switch {
+		case elem.IsNull || elem.Type == target:
+		case target == storage.TypeNull:
+			target = elem.Type
+		case target.IsNumeric() && elem.Type.IsNumeric():
+			target = widerNumeric(target, elem.Type)
+		default:
+			return storage.NewNullValue(), fmt.Errorf("list elements must have the same type, not %s and %s", target, elem.Type)
+		}
+	}
+	for i, elem := range elems {
+		if elems[i], err = castValue(elem, target); err != nil {
+			return storage.NewNullValue(), err
+		}
+	}
+	return storage.NewListValue(elems), nil
+}
+
+// evaluateStructLiteral builds a STRUCT from its fields.
+func (e *Executor) evaluateStructLiteral(lit *parser.StructLiteral, scope *rowScope) (storage.Value, error) {
+	for i, name := range lit.Names {
+		if slices.Contains(lit.Names[:i], name) {
+			return storage.NewNullValue(), fmt.Errorf("duplicate field %q in STRUCT", name)
+		}
+	}
+	values, err := e.evaluateList(lit.Values, scope)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewStructValue(lit.Names, values), nil
+}
+
+// evaluateIndex returns the element of a list at a 1-based index, or NULL
+// if the index is out of range.
+func evaluateIndex(list, index storage.Value) (storage.Value, error) {
+	if list.IsNull || index.IsNull {
+		return storage.NewNullValue(), nil
+	}
+	elems, ok := list.AsList()
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("cannot index a value of type %s", list.Type)
+	}
+	i, ok := index.AsInt64()
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("list index must be an integer, not %s", index.Type)
+	}
+	if i < 1 || i > int64(len(elems)) {
+		return storage.NewNullValue(), nil
+	}
+	return elems[i-1], nil
+}
+
+// evaluateField returns a field of a STRUCT. The field of a NULL struct is
+// NULL.
+func evaluateField(v storage.Value, field string) (storage.Value, error) {
+	if v.IsNull {
+		return storage.NewNullValue(), nil
+	}
+	s, ok := v.AsStruct()
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("cannot access field %q of a value of type %s", field, v.Type)
+	}
+	value, found := s.Field(field)
+	if !found {
+		return storage.NewNullValue(), fmt.Errorf("STRUCT has no field %q", field)
+	}
+	return value, nil
+}
+
+// structColumn resolves a qualified name a.b that names no table column as
+// field b of a column a, and returns the position of a.
+func (s *rowScope) structColumn(ident *parser.Identifier) (int, bool) {
+	if ident.Table == "" {
+		return -1, false
+	}
+	i, err := s.resolve(&parser.Identifier{Name: ident.Table})
+	return i, err == nil
+}
+
+// selectSource is the input of a SELECT: the columns in scope and a scan
+// over the rows.
+type selectSource struct {
+	schema *storage.TableSchema
+	scan   func(callback func(row []storage.Value) bool) error
+}
+
+// selectSource returns the rows a SELECT reads. With UNNEST each row of the
+// table is repeated once per element of the list, which is appended as a
+// column named by the alias. A NULL or empty list yields no rows. Without
+// a table UNNEST reads a single row with no columns.
+func (e *Executor) selectSource(stmt *parser.SelectStatement) (*selectSource, error) {
+	var table *storage.Table
+	base := storage.NewTableSchema("")
+	if stmt.TableName != "" {
+		var err error
+		if table, err = e.getTable(stmt.TableName); err != nil {
+			return nil, err
+		}
+		base = table.Schema
+	}
+	scanBase := func(callback func(row []storage.Value) bool) error {
+		if table == nil {
+			callback(nil)
+			return nil
+		}
+		return table.Scan(func(_ uint64, row []storage.Value) bool { return callback(row) })
+	}
+	if stmt.Unnest == nil {
+		return &selectSource{schema: base, scan: scanBase}, nil
+	}
+
+	unnest := stmt.Unnest
+	if err := checkColumns(unnest.Expression, newRowScope(base, nil)); err != nil {
+		return nil, err
+	}
+	if containsAggregate(unnest.Expression) {
+		return nil, fmt.Errorf("aggregate functions are not allowed in UNNEST")
+	}
+	schema := &storage.TableSchema{Name: base.Name, Columns: slices.Clone(base.Columns)}
+	schema.AddColumnDef(storage.ColumnDef{Name: unnest.Alias, Nullable: true})
+
+	scan := func(callback func(row []storage.Value) bool) error {
+		var evalErr error
+		err := scanBase(func(row []storage.Value) bool {
+			var list storage.Value
+			if list, evalErr = e.evaluate(unnest.Expression, newRowScope(base, row)); evalErr != nil {
+				return false
+			}
+			if list.IsNull {
+				return true
+			}
+			elems, ok := list.AsList()
+			if !ok {
+				evalErr = fmt.Errorf("UNNEST argument must be a LIST, not %s", list.Type)
+				return false
+			}
+			for _, elem := range elems {
+				if !callback(append(slices.Clone(row), elem)) {
+					return false
+				}
+			}
+			return true
+		})
+		if evalErr != nil {
+			return evalErr
+		}
+		return err
+	}
+	return &selectSource{schema: schema, scan: scan}, nil
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+// tupleSet is a hash set of value tuples. Tuples are compared with
+// storage.Value.Equal, so NULLs are treated as equal to each other.
+type tupleSet struct {
+	buckets map[uint64][][]storage.Value
+}
+
+func newTupleSet() *tupleSet {
+	return &tupleSet{buckets: make(map[uint64][][]storage.Value)}
+}
+
+// add inserts a tuple and reports whether it was not already present.
+func (s *tupleSet) add(tuple []storage.Value) bool {
+	h := storage.HashValues(tuple)
+	for _, existing := range s.buckets[h] {
+		if tuplesEqual(existing, tuple) {
+			return false
+		}
+	}
+	s.buckets[h] = append(s.buckets[h], tuple)
+	return true
+}
+
+func tuplesEqual(a, b []storage.Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// outputRow is a projected row together with the values it is sorted and
+// deduplicated by.
+type outputRow struct {
+	values      []storage.Value
+	sortKeys    []storage.Value
+	distinctKey []storage.Value
+}
+
+// sortRows orders rows by their sort keys. NULLs sort last in ascending
+// order and first in descending order. The sort is stable so rows with
+// equal keys keep their scan order.
+func sortRows(rows []outputRow, orderBy []parser.OrderByItem) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k, item := range orderBy {
+			c := rows[i].sortKeys[k].Compare(rows[j].sortKeys[k])
+			if c == 0 {
+				continue
+			}
+			if item.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// distinctRows keeps the first row for each distinct key, preserving order.
+func distinctRows(rows []outputRow) []outputRow {
+	seen := newTupleSet()
+	kept := rows[:0]
+	for _, row := range rows {
+		if seen.add(row.distinctKey) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// checkDistinctOn rejects an ORDER BY that does not start with the DISTINCT
+// ON expressions, in any order, as PostgreSQL does: the row kept for each
+// key is the first in the sort order, which is only meaningful when rows
+// with the same key sort together. Output column aliases and ORDER BY
+// positions are compared as the expressions they stand for.
+func checkDistinctOn(stmt *parser.SelectStatement, columns []string, projections []parser.Expression) error {
+	if len(stmt.DistinctOn) == 0 {
+		return nil
+	}
+	resolve := func(expr parser.Expression) string {
+		if ident, ok := expr.(*parser.Identifier); ok && ident.Table == "" {
+			if i := slices.Index(columns, ident.Name); i >= 0 {
+				return projections[i].String()
+			}
+		}
+		return expr.String()
+	}
+	keys := make(map[string]bool, len(stmt.DistinctOn))
+	for _, expr := range stmt.DistinctOn {
+		keys[resolve(expr)] = true
+	}
+	for _, item := range stmt.OrderBy[:min(len(stmt.OrderBy), len(stmt.DistinctOn))] {
+		key := resolve(item.Expression)
+		if pos, ok := item.Expression.(*parser.IntegerLiteral); ok && pos.Value >= 1 && pos.Value <= int64(len(projections)) {
+			key = projections[pos.Value-1].String()
+		}
+		if !keys[key] {
+			return fmt.Errorf("SELECT DISTINCT ON expressions must match initial ORDER BY expressions")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+
+	"github.com/taikicoco/tate/internal/parser"
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+func (e *Executor) executeCreateSequence(stmt *parser.CreateSequenceStatement) (*Result, error) {
+	if _, exists := e.catalog.GetSequence(stmt.Name); exists && stmt.IfNotExists {
+		return &Result{
+			Message: fmt.Sprintf("Sequence %q already exists, skipping", stmt.Name),
+		}, nil
+	}
+	seq, err := newSequence(stmt.Name, stmt.Options)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.catalog.CreateSequence(seq); err != nil {
+		return nil, err
+	}
+	return &Result{
+		Message: fmt.Sprintf("Sequence %q created successfully", stmt.Name),
+	}, nil
+}
+
+func (e *Executor) executeDropSequence(stmt *parser.DropSequenceStatement) (*Result, error) {
+	seq, ok := e.catalog.GetSequence(stmt.Name)
+	if !ok {
+		if stmt.IfExists {
+			return &Result{
+				Message: fmt.Sprintf("Sequence %q does not exist, skipping", stmt.Name),
+			}, nil
+		}
+		return nil, fmt.Errorf("sequence %q does not exist", stmt.Name)
+	}
+	if seq.OwnedBy != "" {
+		return nil, fmt.Errorf("cannot drop sequence %q because an identity column of table %q requires it",
+			stmt.Name, seq.OwnedBy)
+	}
+	if err := e.dropSequenceDependents([]string{stmt.Name}, "", stmt.Cascade); err != nil {
+		return nil, err
+	}
+	if err := e.catalog.DropSequence(stmt.Name); err != nil {
+		return nil, err
+	}
+	delete(e.currvals, stmt.Name)
+	return &Result{
+		Message: fmt.Sprintf("Sequence %q dropped successfully", stmt.Name),
+	}, nil
+}
+
+// sequenceDependent is a column whose DEFAULT uses a sequence.
+type sequenceDependent struct {
+	table    string
+	column   string
+	sequence string
+}
+
+// dropSequenceDependents handles the column defaults that use any of the
+// named sequences, which are about to be dropped. Columns of the table
+// skip are ignored. Without cascade such a default is an error; with
+// cascade the default is removed.
+func (e *Executor) dropSequenceDependents(sequences []string, skip string, cascade bool) error {
+	deps, err := e.sequenceDependents(sequences, skip)
+	if err != nil {
+		return err
+	}
+	if len(deps) > 0 && !cascade {
+		d := deps[0]
+		return fmt.Errorf("cannot drop sequence %q because default value of column %q of table %q depends on it",
+			d.sequence, d.column, d.table)
+	}
+
+	for _, d := range deps {
+		table, err := e.getTable(d.table)
+		if err != nil {
+			return err
+		}
+		col, _ := table.Schema.GetColumn(d.column)
+		col.Default = ""
+		if err := table.SaveSchema(); err != nil {
+			return err
+		}
+		if err := e.catalog.ReplaceTable(d.table, table.Schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sequenceDependents returns the columns outside the table skip whose
+// DEFAULT calls NEXTVAL, CURRVAL or SETVAL on one of the named sequences.
+func (e *Executor) sequenceDependents(sequences []string, skip string) ([]sequenceDependent, error) {
+	var deps []sequenceDependent
+	for _, name := range e.catalog.ListTables() {
+		if name == skip {
+			continue
+		}
+		schema, _ := e.catalog.GetTable(name)
+		for _, col := range schema.Columns {
+			if col.Default == "" {
+				continue
+			}
+			expr, err := e.storedExpression(col.Default)
+			if err != nil {
+				return nil, err
+			}
+			parser.Inspect(expr, func(node parser.Expression) bool {
+				call, ok := node.(*parser.FunctionCall)
+				if !ok || !isSequenceFunction(call.Name) || len(call.Arguments) == 0 {
+					return true
+				}
+				if lit, ok := call.Arguments[0].(*parser.StringLiteral); ok && slices.Contains(sequences, lit.Value) {
+					deps = append(deps, sequenceDependent{table: name, column: col.Name, sequence: lit.Value})
+				}
+				return true
+			})
+		}
+	}
+	return deps, nil
+}
+
+func isSequenceFunction(name string) bool {
+	return name == "NEXTVAL" || name == "CURRVAL" || name == "SETVAL"
+}
+
+func newSequence(name string, opts parser.SequenceOptions) (*storage.Sequence, error) {
+	seq, err := storage.NewSequence(name, opts.Start, opts.Increment, opts.MinValue, opts.MaxValue)
+	if err != nil {
+		return nil, fmt.Errorf("sequence %q: %w", name, err)
+	}
+	return seq, nil
+}
+
+// identitySequences creates the sequence behind each identity column of a
+// new table, named <table>_<column>_seq. Nothing is registered if any
+// column is invalid.
+func (e *Executor) identitySequences(stmt *parser.CreateTableStatement) (map[string]*storage.Sequence, error) {
+	seqs := make(map[string]*storage.Sequence)
+	for _, col := range stmt.Columns {
+		if col.Identity == "" {
+			continue
+		}
+		if col.Default != nil || col.Generated != nil {
+			return nil, fmt.Errorf("both default and identity specified for column %q", col.Name)
+		}
+		dt, _ := e.resolveDataType(col.DataType)
+		if !dt.IsInteger() {
+			return nil, fmt.Errorf("identity column %q must be of an integer type", col.Name)
+		}
+		opts, err := identityOptions(col, dt)
+		if err != nil {
+			return nil, err
+		}
+
+		name := stmt.TableName + "_" + col.Name + "_seq"
+		if _, exists := e.catalog.GetSequence(name); exists {
+			return nil, fmt.Errorf("sequence %q already exists", name)
+		}
+		seq, err := newSequence(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		seq.OwnedBy = stmt.TableName
+		seqs[col.Name] = seq
+	}
+	return seqs, nil
+}
+
+// identityOptions returns the sequence options of an identity column with
+// the bounds the options leave out taken from the range of its type, so
+// that a narrow column runs out of sequence values rather than overflowing.
+func identityOptions(col parser.ColumnDefinition, dt storage.DataType) (parser.SequenceOptions, error) {
+	opts := col.IdentityOptions
+	lo, hi := storage.IntegerRange(dt)
+	if !hi.IsInt64() {
+		hi = big.NewInt(math.MaxInt64)
+	}
+	for _, bound := range []struct {
+		name  string
+		value *int64
+	}{{"MINVALUE", opts.MinValue}, {"MAXVALUE", opts.MaxValue}} {
+		if bound.value != nil && (*bound.value < lo.Int64() || *bound.value > hi.Int64()) {
+			return opts, fmt.Errorf("%s (%d) is out of range for identity column %q of type %s",
+				bound.name, *bound.value, col.Name, dt)
+		}
+	}
+
+	descending := opts.Increment != nil && *opts.Increment < 0
+	if !descending && opts.MaxValue == nil {
+		maxValue := hi.Int64()
+		opts.MaxValue = &maxValue
+	}
+	if descending && opts.MinValue == nil {
+		minValue := lo.Int64()
+		opts.MinValue = &minValue
+	}
+	return opts, nil
+}
+
+// evaluateSequenceFunction implements NEXTVAL, CURRVAL and SETVAL, which
+// take the sequence name as a string. CURRVAL returns the value most
+// recently obtained by NEXTVAL in this session.
+func (e *Executor) evaluateSequenceFunction(name string, args []storage.Value) (storage.Value, error) {
+	wantArgs := 1
+	if name == "SETVAL" {
+		wantArgs = 2
+	}
+	if len(args) != wantArgs {
+		return storage.NewNullValue(), fmt.Errorf("function %s: %s, got %d",
+			name, arityString(wantArgs, wantArgs), len(args))
+	}
+	for _, arg := range args {
+		if arg.IsNull {
+			return storage.NewNullValue(), nil
+		}
+	}
+
+	seqName, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), fmt.Errorf("function %s: %w", name, err)
+	}
+
+	switch name {
+	case "NEXTVAL":
+		val, err := e.catalog.NextVal(seqName)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		e.currvals[seqName] = val
+		return storage.NewInt64Value(val), nil
+
+	case "CURRVAL":
+		if _, exists := e.catalog.GetSequence(seqName); !exists {
+			return storage.NewNullValue(), fmt.Errorf("sequence %q does not exist", seqName)
+		}
+		val, ok := e.currvals[seqName]
+		if !ok {
+			return storage.NewNullValue(), fmt.Errorf("currval of sequence %q is not yet defined in this session", seqName)
+		}
+		return storage.NewInt64Value(val), nil
+
+	default: // SETVAL
+		val, err := intArg(args, 1)
+		if err != nil {
+			return storage.NewNullValue(), fmt.Errorf("function %s: %w", name, err)
+		}
+		if err := e.catalog.SetVal(seqName, val); err != nil {
+			return storage.NewNullValue(), err
+		}
+		e.currvals[seqName] = val
+		return storage.NewInt64Value(val), nil
+	}
+}
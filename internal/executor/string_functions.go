@@ -0,0 +1,370 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+func init() {
+	registerFunctions(map[string]builtinFunction{
+		"LOWER":       {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToLower)},
+		"UPPER":       {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToUpper)},
+		"LENGTH":      {minArgs: 1, maxArgs: 1, call: fnLength},
+		"CHAR_LENGTH": {minArgs: 1, maxArgs: 1, call: fnLength},
+		"SUBSTRING":   {minArgs: 2, maxArgs: 3, call: fnSubstring},
+		"TRIM":        {minArgs: 1, maxArgs: 2, call: trimFunc(strings.Trim)},
+		"LTRIM":       {minArgs: 1, maxArgs: 2, call: trimFunc(strings.TrimLeft)},
+		"RTRIM":       {minArgs: 1, maxArgs: 2, call: trimFunc(strings.TrimRight)},
+		"REPLACE":     {minArgs: 3, maxArgs: 3, call: fnReplace},
+		"CONCAT":      {minArgs: 1, maxArgs: -1, nullable: true, call: fnConcat},
+		"SPLIT_PART":  {minArgs: 3, maxArgs: 3, call: fnSplitPart},
+		"STARTS_WITH": {minArgs: 2, maxArgs: 2, call: fnStartsWith},
+	})
+}
+
+func stringFunc(f func(string) string) func([]storage.Value) (storage.Value, error) {
+	return func(args []storage.Value) (storage.Value, error) {
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		return storage.NewStringValue(f(s)), nil
+	}
+}
+
+func trimFunc(f func(string, string) string) func([]storage.Value) (storage.Value, error) {
+	return func(args []storage.Value) (storage.Value, error) {
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		chars := " "
+		if len(args) > 1 {
+			if chars, err = stringArg(args, 1); err != nil {
+				return storage.NewNullValue(), err
+			}
+		}
+		return storage.NewStringValue(f(s, chars)), nil
+	}
+}
+
+// fnLength returns the number of characters, not bytes, in a string, or
+// the number of bytes in a BYTES value.
+func fnLength(args []storage.Value) (storage.Value, error) {
+	if b, ok := args[0].AsBytes(); ok {
+		return storage.NewInt64Value(int64(len(b))), nil
+	}
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewInt64Value(int64(utf8.RuneCountInString(s))), nil
+}
+
+// fnSubstring extracts characters starting at a 1-based position. As in
+// PostgreSQL, a start before the first character shortens the result
+// rather than shifting it.
+func fnSubstring(args []storage.Value) (storage.Value, error) {
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	start, err := intArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+
+	runes := []rune(s)
+	end := int64(math.MaxInt64)
+	if len(args) > 2 {
+		length, err := intArg(args, 2)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		if length < 0 {
+			return storage.NewNullValue(), fmt.Errorf("negative substring length not allowed")
+		}
+		if start <= math.MaxInt64-length {
+			end = start + length
+		}
+	}
+
+	from := max(start, 1) - 1
+	to := min(end-1, int64(len(runes)))
+	if from >= to {
+		return storage.NewStringValue(""), nil
+	}
+	return storage.NewStringValue(string(runes[from:to])), nil
+}
+
+func fnReplace(args []storage.Value) (storage.Value, error) {
+	var strs [3]string
+	for i := range strs {
+		s, err := stringArg(args, i)
+		if err != nil {
+			return storage.NewNullValue(), err
+		}
+		strs[i] = s
+	}
+	if strs[1] == "" {
+		return storage.NewStringValue(strs[0]), nil
+	}
+	return storage.NewStringValue(strings.ReplaceAll(strs[0], strs[1], strs[2])), nil
+}
+
+// fnConcat concatenates the text of its arguments, skipping NULLs.
+func fnConcat(args []storage.Value) (storage.Value, error) {
+	var sb strings.Builder
+	for _, arg := range args {
+		if !arg.IsNull {
+			sb.WriteString(formatText(arg))
+		}
+	}
+	return storage.NewStringValue(sb.String()), nil
+}
+
+// fnSplitPart returns the n-th field of a string split on a delimiter.
+// Negative n counts from the end.
+func fnSplitPart(args []storage.Value) (storage.Value, error) {
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	delim, err := stringArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	n, err := intArg(args, 2)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	if n == 0 {
+		return storage.NewNullValue(), fmt.Errorf("field position must not be zero")
+	}
+
+	parts := []string{s}
+	if delim != "" {
+		parts = strings.Split(s, delim)
+	}
+	if n < 0 {
+		n += int64(len(parts)) + 1
+	}
+	if n < 1 || n > int64(len(parts)) {
+		return storage.NewStringValue(""), nil
+	}
+	return storage.NewStringValue(parts[n-1]), nil
+}
+
+func fnStartsWith(args []storage.Value) (storage.Value, error) {
+	s, err := stringArg(args, 0)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	prefix, err := stringArg(args, 1)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewBoolValue(strings.HasPrefix(s, prefix)), nil
+}
+
+// formatText renders a non-NULL value as text, as used by || and CONCAT.
+func formatText(v storage.Value) string {
+	if f, ok := v.AsFloat64(); ok {
+		return formatFloatBits(f, floatBits(v.Type))
+	}
+	return v.String()
+}
+
+// formatFloat renders a float with the fewest digits that round-trip,
+// switching to exponent notation only for very large or small magnitudes.
+func formatFloat(f float64) string {
+	return formatFloatBits(f, 64)
+}
+
+// formatFloatBits is formatFloat for a float of the given bit size, so
+// that a FLOAT32 is shown with the digits it actually holds.
+func formatFloatBits(f float64, bitSize int) string {
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-4 || abs >= 1e15) {
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	return strconv.FormatFloat(f, 'f', -1, bitSize)
+}
+
+// floatBits returns the bit size of a float type.
+func floatBits(t storage.DataType) int {
+	if t == storage.TypeFloat32 {
+		return 32
+	}
+	return 64
+}
+
+// evaluateConcat implements the || operator. Two BYTES operands are
+// joined into BYTES; otherwise at least one operand must be a string and
+// the other is converted to text. A NULL operand yields NULL.
+func evaluateConcat(left, right storage.Value) (storage.Value, error) {
+	if left.IsNull || right.IsNull {
+		return storage.NewNullValue(), nil
+	}
+	if l, ok := left.AsBytes(); ok {
+		if r, ok := right.AsBytes(); ok {
+			return storage.NewBytesValue(append(slices.Clip(l), r...)), nil
+		}
+	}
+	if left.Type != storage.TypeString && right.Type != storage.TypeString {
+		return storage.NewNullValue(), fmt.Errorf("operator || is not defined for %s and %s",
+			left.Type, right.Type)
+	}
+	// The result keeps the collation of a STRING operand, as when a column
+	// is concatenated with a literal.
+	c := left.Collation()
+	if c == storage.CollationBinary {
+		c = right.Collation()
+	}
+	return storage.NewStringValue(formatText(left) + formatText(right)).WithCollation(c), nil
+}
+
+// likeMatch reports whether s matches a LIKE pattern, where % matches any
+// sequence of characters and _ matches exactly one. When escape is not
+// zero, it makes the following pattern character literal.
+func likeMatch(s, pattern string, escape rune) (bool, error) {
+	type token struct {
+		r       rune
+		literal bool
+	}
+
+	var tokens []token
+	pr := []rune(pattern)
+	for i := 0; i < len(pr); i++ {
+		if escape != 0 && pr[i] == escape {
+			if i+1 == len(pr) {
+				return false, fmt.Errorf("LIKE pattern must not end with escape character")
+			}
+			i++
+			tokens = append(tokens, token{r: pr[i], literal: true})
+			continue
+		}
+		tokens = append(tokens, token{r: pr[i]})
+	}
+
+	// Greedy match with backtracking to the most recent %.
+	sr := []rune(s)
+	si, ti := 0, 0
+	starTi, starSi := -1, 0
+	for si < len(sr) {
+		switch {
+		case ti < len(tokens) && !tokens[ti].literal && tokens[ti].r == '%':
+			starTi, starSi = ti, si
+			ti++
+		case ti < len(tokens) && (tokens[ti].r == sr[si] || (!tokens[ti].literal && tokens[ti].r == '_')):
+			si++
+			ti++
+		case starTi >= 0:
+			starSi++
+			si, ti = starSi, starTi+1
+		default:
+			return false, nil
+		}
+	}
+	for ti < len(tokens) && !tokens[ti].literal && tokens[ti].r == '%' {
+		ti++
+	}
+	return ti == len(tokens), nil
+}
+
+// evaluateLike evaluates a LIKE or ILIKE predicate. The escape character
+// defaults to backslash; an empty ESCAPE string disables escaping.
+func evaluateLike(str, pattern, escape storage.Value, hasEscape, caseInsensitive, not bool) (storage.Value, error) {
+	if str.IsNull || pattern.IsNull || (hasEscape && escape.IsNull) {
+		return storage.NewNullValue(), nil
+	}
+
+	s, ok := str.AsString()
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("LIKE is not defined for %s", str.Type)
+	}
+	p, ok := pattern.AsString()
+	if !ok {
+		return storage.NewNullValue(), fmt.Errorf("LIKE pattern must be STRING, not %s", pattern.Type)
+	}
+
+	esc := '\\'
+	if hasEscape {
+		e, ok := escape.AsString()
+		if !ok || utf8.RuneCountInString(e) > 1 {
+			return storage.NewNullValue(), fmt.Errorf("invalid escape string: must be empty or one character")
+		}
+		esc, _ = utf8.DecodeRuneInString(e)
+		if e == "" {
+			esc = 0
+		}
+	}
+
+	if caseInsensitive {
+		s, p = strings.ToLower(s), strings.ToLower(p)
+		esc = unicode.ToLower(esc)
+	}
+
+	matched, err := likeMatch(s, p, esc)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewBoolValue(matched != not), nil
+}
+
+// regexpCache holds compiled patterns for the ~ and !~ operators so a
+// pattern is compiled once per query rather than once per row.
+var regexpCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+const regexpCacheSize = 256
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+
+	if re, ok := regexpCache.m[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	if len(regexpCache.m) >= regexpCacheSize {
+		clear(regexpCache.m)
+	}
+	regexpCache.m[pattern] = re
+	return re, nil
+}
+
+// evaluateRegexMatch implements ~ and !~, which test whether a string
+// contains a match for a regular expression.
+func evaluateRegexMatch(op string, left, right storage.Value) (storage.Value, error) {
+	if left.IsNull || right.IsNull {
+		return storage.NewNullValue(), nil
+	}
+
+	s, lok := left.AsString()
+	pattern, rok := right.AsString()
+	if !lok || !rok {
+		return storage.NewNullValue(), fmt.Errorf("operator %s is not defined for %s and %s",
+			op, left.Type, right.Type)
+	}
+
+	re, err := compileRegexp(pattern)
+	if err != nil {
+		return storage.NewNullValue(), err
+	}
+	return storage.NewBoolValue(re.MatchString(s) == (op == "~")), nil
+}
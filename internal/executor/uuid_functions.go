@@ -0,0 +1,39 @@
+package executor
+
+import (
+	"crypto/rand"
+
+	"github.com/taikicoco/tate/internal/storage"
+)
+
+func init() {
+	registerFunctions(map[string]builtinFunction{
+		"GEN_RANDOM_UUID": {minArgs: 0, maxArgs: 0, call: fnGenRandomUUID},
+	})
+}
+
+// castToUUID parses a string as a UUID. A 16-byte BYTES value is taken as
+// the UUID's bytes.
+func castToUUID(v storage.Value) (storage.Value, error) {
+	if b, ok := v.AsBytes(); ok && len(b) == 16 {
+		return storage.NewUUIDValue(storage.UUID(b)), nil
+	}
+	s, ok := v.AsString()
+	if !ok {
+		return storage.NewNullValue(), cannotCast(v.Type, storage.TypeUUID)
+	}
+	u, err := storage.ParseUUID(s)
+	if err != nil {
+		return storage.NewNullValue(), invalidInput(storage.TypeUUID, s)
+	}
+	return storage.NewUUIDValue(u), nil
+}
+
+// fnGenRandomUUID returns a random version 4 UUID.
+func fnGenRandomUUID([]storage.Value) (storage.Value, error) {
+	var u storage.UUID
+	_, _ = rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40 // version 4
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	return storage.NewUUIDValue(u), nil
+}
@@ -1,5 +1,12 @@
 package parser
 
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
 // Node is the base interface for all AST nodes.
 type Node interface {
 	node()
@@ -15,12 +22,16 @@ type Statement interface {
 type Expression interface {
 	Node
 	expressionNode()
+	// String returns the SQL text of the expression.
+	String() string
 }
 
 // CreateTableStatement represents a CREATE TABLE statement.
 type CreateTableStatement struct {
-	TableName string
-	Columns   []ColumnDefinition
+	TableName   string
+	IfNotExists bool
+	Columns     []ColumnDefinition
+	Constraints []TableConstraint
 }
 
 func (s *CreateTableStatement) node()          {}
@@ -28,15 +39,115 @@ func (s *CreateTableStatement) statementNode() {}
 
 // ColumnDefinition represents a column definition in CREATE TABLE.
 type ColumnDefinition struct {
+	Name       string
+	DataType   string
+	Nullable   bool
+	Default    Expression // DEFAULT expr, or nil
+	Generated  Expression // GENERATED ALWAYS AS (expr) STORED, or nil
+	PrimaryKey bool
+	Unique     bool
+	Collation  string // COLLATE name, or empty
+	// Identity is "ALWAYS" or "BY DEFAULT" for GENERATED ... AS IDENTITY
+	// columns, whose values come from a sequence with IdentityOptions.
+	Identity        string
+	IdentityOptions SequenceOptions
+}
+
+// TableConstraint represents a table-level PRIMARY KEY or UNIQUE constraint.
+type TableConstraint struct {
+	Name       string
+	Columns    []string
+	PrimaryKey bool
+}
+
+// TruncateStatement represents a TRUNCATE statement.
+type TruncateStatement struct {
+	TableNames []string
+	// RestartIdentity resets the sequences of the tables' identity columns.
+	RestartIdentity bool
+}
+
+func (s *TruncateStatement) node()          {}
+func (s *TruncateStatement) statementNode() {}
+
+// AlterTableStatement represents an ALTER TABLE statement with one action.
+type AlterTableStatement struct {
+	TableName  string
+	Action     AlterAction
+	Column     ColumnDefinition // ADD COLUMN
+	ColumnName string           // DROP, RENAME and ALTER COLUMN
+	NewName    string           // RENAME COLUMN and RENAME TO
+	DataType   string           // ALTER COLUMN TYPE
+	Collation  string           // ALTER COLUMN TYPE ... COLLATE, or empty
+}
+
+func (s *AlterTableStatement) node()          {}
+func (s *AlterTableStatement) statementNode() {}
+
+// AlterAction is the action of an ALTER TABLE statement.
+type AlterAction int
+
+const (
+	AlterAddColumn AlterAction = iota
+	AlterDropColumn
+	AlterRenameColumn
+	AlterColumnType
+	AlterRenameTable
+)
+
+// SequenceOptions holds the options of a sequence. Options not given are nil.
+type SequenceOptions struct {
+	Start     *int64
+	Increment *int64
+	MinValue  *int64
+	MaxValue  *int64
+}
+
+// CreateSequenceStatement represents a CREATE SEQUENCE statement.
+type CreateSequenceStatement struct {
+	Name        string
+	IfNotExists bool
+	Options     SequenceOptions
+}
+
+func (s *CreateSequenceStatement) node()          {}
+func (s *CreateSequenceStatement) statementNode() {}
+
+// DropSequenceStatement represents a DROP SEQUENCE statement.
+type DropSequenceStatement struct {
+	Name     string
+	IfExists bool
+	Cascade  bool
+}
+
+func (s *DropSequenceStatement) node()          {}
+func (s *DropSequenceStatement) statementNode() {}
+
+// CreateTypeStatement represents CREATE TYPE name AS ENUM (label, ...).
+type CreateTypeStatement struct {
+	Name        string
+	IfNotExists bool
+	Labels      []string
+}
+
+func (s *CreateTypeStatement) node()          {}
+func (s *CreateTypeStatement) statementNode() {}
+
+// DropTypeStatement represents a DROP TYPE statement.
+type DropTypeStatement struct {
 	Name     string
-	DataType string
-	Nullable bool
+	IfExists bool
+	Cascade  bool
 }
 
+func (s *DropTypeStatement) node()          {}
+func (s *DropTypeStatement) statementNode() {}
+
 // DropTableStatement represents a DROP TABLE statement.
 type DropTableStatement struct {
 	TableName string
 	IfExists  bool
+	Cascade   bool
 }
 
 func (s *DropTableStatement) node()          {}
@@ -44,36 +155,136 @@ func (s *DropTableStatement) statementNode() {}
 
 // InsertStatement represents an INSERT statement.
 type InsertStatement struct {
-	TableName string
-	Columns   []string
-	Values    []Expression
+	TableName  string
+	Columns    []string
+	Values     []Expression
+	OnConflict *OnConflictClause
+	Returning  []SelectColumn
 }
 
 func (s *InsertStatement) node()          {}
 func (s *InsertStatement) statementNode() {}
 
+// UpdateStatement represents an UPDATE statement.
+type UpdateStatement struct {
+	TableName   string
+	Assignments []Assignment
+	Where       Expression
+	Returning   []SelectColumn
+}
+
+func (s *UpdateStatement) node()          {}
+func (s *UpdateStatement) statementNode() {}
+
+// DeleteStatement represents a DELETE statement.
+type DeleteStatement struct {
+	TableName string
+	Where     Expression
+	Returning []SelectColumn
+}
+
+func (s *DeleteStatement) node()          {}
+func (s *DeleteStatement) statementNode() {}
+
+// OnConflictClause represents ON CONFLICT ... DO NOTHING | DO UPDATE.
+type OnConflictClause struct {
+	// Columns or Constraint name the unique constraint to check. Both are
+	// empty when no conflict target is given.
+	Columns     []string
+	Constraint  string
+	DoUpdate    bool
+	Assignments []Assignment
+	Where       Expression
+}
+
+// MergeStatement represents a MERGE statement.
+type MergeStatement struct {
+	TargetTable string
+	TargetAlias string
+	SourceTable string
+	SourceAlias string
+	On          Expression
+	Clauses     []MergeClause
+}
+
+func (s *MergeStatement) node()          {}
+func (s *MergeStatement) statementNode() {}
+
+// MergeAction is the action of a WHEN clause in MERGE.
+type MergeAction int
+
+const (
+	MergeDoNothing MergeAction = iota
+	MergeUpdate
+	MergeDelete
+	MergeInsert
+)
+
+// MergeClause represents WHEN [NOT] MATCHED [AND condition] THEN action.
+type MergeClause struct {
+	Matched     bool
+	Condition   Expression
+	Action      MergeAction
+	Assignments []Assignment // UPDATE SET
+	Columns     []string     // INSERT (columns)
+	Values      []Expression // INSERT VALUES
+}
+
+// Assignment represents column = value in a SET clause.
+type Assignment struct {
+	Column string
+	Value  Expression
+}
+
 // SelectStatement represents a SELECT statement.
 type SelectStatement struct {
-	Columns   []SelectColumn
+	Distinct   bool
+	DistinctOn []Expression
+	Columns    []SelectColumn
+	// TableName is empty when the FROM clause holds only an UNNEST.
 	TableName string
+	Unnest    *UnnestClause
+	Where     Expression
+	OrderBy   []OrderByItem
 }
 
 func (s *SelectStatement) node()          {}
 func (s *SelectStatement) statementNode() {}
 
+// UnnestClause represents UNNEST(list) [AS alias] in a FROM clause, which
+// yields one row per element of the list.
+type UnnestClause struct {
+	Expression Expression
+	Alias      string
+}
+
 // SelectColumn represents a column in SELECT clause.
 type SelectColumn struct {
 	Expression Expression
+	Alias      string
 	IsWildcard bool
 }
 
+// OrderByItem represents a sort key in ORDER BY clause.
+type OrderByItem struct {
+	Expression Expression
+	Desc       bool
+}
+
 // Identifier represents an identifier (column or table name).
 type Identifier struct {
-	Name string
+	Table string // qualifier in table.column, or empty
+	Name  string
 }
 
 func (e *Identifier) node()           {}
 func (e *Identifier) expressionNode() {}
+func (e *Identifier) String() string {
+	if e.Table != "" {
+		return quoteIdentifier(e.Table) + "." + quoteIdentifier(e.Name)
+	}
+	return quoteIdentifier(e.Name)
+}
 
 // IntegerLiteral represents an integer literal.
 type IntegerLiteral struct {
@@ -82,6 +293,7 @@ type IntegerLiteral struct {
 
 func (e *IntegerLiteral) node()           {}
 func (e *IntegerLiteral) expressionNode() {}
+func (e *IntegerLiteral) String() string  { return strconv.FormatInt(e.Value, 10) }
 
 // FloatLiteral represents a floating-point literal.
 type FloatLiteral struct {
@@ -90,6 +302,13 @@ type FloatLiteral struct {
 
 func (e *FloatLiteral) node()           {}
 func (e *FloatLiteral) expressionNode() {}
+func (e *FloatLiteral) String() string {
+	s := strconv.FormatFloat(e.Value, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
 
 // StringLiteral represents a string literal.
 type StringLiteral struct {
@@ -98,6 +317,49 @@ type StringLiteral struct {
 
 func (e *StringLiteral) node()           {}
 func (e *StringLiteral) expressionNode() {}
+func (e *StringLiteral) String() string  { return quoteString(e.Value) }
+
+// BytesLiteral represents a hexadecimal byte string literal, X'DEADBEEF'.
+type BytesLiteral struct {
+	Value []byte
+}
+
+func (e *BytesLiteral) node()           {}
+func (e *BytesLiteral) expressionNode() {}
+func (e *BytesLiteral) String() string {
+	return "X'" + strings.ToUpper(hex.EncodeToString(e.Value)) + "'"
+}
+
+// ListLiteral represents a list literal, [1, 2, 3].
+type ListLiteral struct {
+	Elements []Expression
+}
+
+func (e *ListLiteral) node()           {}
+func (e *ListLiteral) expressionNode() {}
+func (e *ListLiteral) String() string {
+	elems := make([]string, len(e.Elements))
+	for i, elem := range e.Elements {
+		elems[i] = elem.String()
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// StructLiteral represents a struct literal, {'a': 1, 'b': 'x'}.
+type StructLiteral struct {
+	Names  []string
+	Values []Expression
+}
+
+func (e *StructLiteral) node()           {}
+func (e *StructLiteral) expressionNode() {}
+func (e *StructLiteral) String() string {
+	fields := make([]string, len(e.Names))
+	for i, name := range e.Names {
+		fields[i] = quoteString(name) + ": " + e.Values[i].String()
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
 
 // BoolLiteral represents a boolean literal.
 type BoolLiteral struct {
@@ -106,9 +368,265 @@ type BoolLiteral struct {
 
 func (e *BoolLiteral) node()           {}
 func (e *BoolLiteral) expressionNode() {}
+func (e *BoolLiteral) String() string {
+	if e.Value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
 
 // NullLiteral represents a NULL literal.
 type NullLiteral struct{}
 
 func (e *NullLiteral) node()           {}
 func (e *NullLiteral) expressionNode() {}
+func (e *NullLiteral) String() string  { return "NULL" }
+
+// DefaultKeyword represents the DEFAULT keyword used in place of a value
+// in INSERT, which stands for the column's default.
+type DefaultKeyword struct{}
+
+func (e *DefaultKeyword) node()           {}
+func (e *DefaultKeyword) expressionNode() {}
+func (e *DefaultKeyword) String() string  { return "DEFAULT" }
+
+// PrefixExpression represents a unary operator applied to an expression.
+type PrefixExpression struct {
+	Operator string
+	Right    Expression
+}
+
+func (e *PrefixExpression) node()           {}
+func (e *PrefixExpression) expressionNode() {}
+func (e *PrefixExpression) String() string {
+	if e.Operator == "NOT" {
+		return "NOT " + operandString(e.Right)
+	}
+	return e.Operator + operandString(e.Right)
+}
+
+// InfixExpression represents a binary operator applied to two expressions.
+type InfixExpression struct {
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (e *InfixExpression) node()           {}
+func (e *InfixExpression) expressionNode() {}
+func (e *InfixExpression) String() string {
+	return operandString(e.Left) + " " + e.Operator + " " + operandString(e.Right)
+}
+
+// IndexExpression represents list[index], with 1 the first element.
+type IndexExpression struct {
+	Expression Expression
+	Index      Expression
+}
+
+func (e *IndexExpression) node()           {}
+func (e *IndexExpression) expressionNode() {}
+func (e *IndexExpression) String() string {
+	return operandString(e.Expression) + "[" + e.Index.String() + "]"
+}
+
+// FieldAccess represents expr.field on a STRUCT value. A plain a.b is
+// parsed as an Identifier and only treated as a field access when a is not
+// a table.
+type FieldAccess struct {
+	Expression Expression
+	Field      string
+}
+
+func (e *FieldAccess) node()           {}
+func (e *FieldAccess) expressionNode() {}
+func (e *FieldAccess) String() string {
+	return operandString(e.Expression) + "." + quoteIdentifier(e.Field)
+}
+
+// IsNullExpression represents expr IS [NOT] NULL.
+type IsNullExpression struct {
+	Expression Expression
+	Not        bool
+}
+
+func (e *IsNullExpression) node()           {}
+func (e *IsNullExpression) expressionNode() {}
+func (e *IsNullExpression) String() string {
+	if e.Not {
+		return operandString(e.Expression) + " IS NOT NULL"
+	}
+	return operandString(e.Expression) + " IS NULL"
+}
+
+// IsDistinctFromExpression represents left IS [NOT] DISTINCT FROM right.
+type IsDistinctFromExpression struct {
+	Left  Expression
+	Right Expression
+	Not   bool
+}
+
+func (e *IsDistinctFromExpression) node()           {}
+func (e *IsDistinctFromExpression) expressionNode() {}
+func (e *IsDistinctFromExpression) String() string {
+	op := " IS DISTINCT FROM "
+	if e.Not {
+		op = " IS NOT DISTINCT FROM "
+	}
+	return operandString(e.Left) + op + operandString(e.Right)
+}
+
+// LikeExpression represents expr [NOT] LIKE|ILIKE pattern [ESCAPE escape].
+type LikeExpression struct {
+	Expression      Expression
+	Pattern         Expression
+	Escape          Expression
+	Not             bool
+	CaseInsensitive bool
+}
+
+func (e *LikeExpression) node()           {}
+func (e *LikeExpression) expressionNode() {}
+func (e *LikeExpression) String() string {
+	op := " LIKE "
+	if e.CaseInsensitive {
+		op = " ILIKE "
+	}
+	if e.Not {
+		op = " NOT" + op
+	}
+	s := operandString(e.Expression) + op + operandString(e.Pattern)
+	if e.Escape != nil {
+		s += " ESCAPE " + operandString(e.Escape)
+	}
+	return s
+}
+
+// CastExpression represents CAST(expr AS type) or expr::type.
+type CastExpression struct {
+	Expression Expression
+	DataType   string
+}
+
+func (e *CastExpression) node()           {}
+func (e *CastExpression) expressionNode() {}
+func (e *CastExpression) String() string {
+	return "CAST(" + e.Expression.String() + " AS " + e.DataType + ")"
+}
+
+// CollateExpression represents expr COLLATE name, which compares and sorts
+// a string by the named collation.
+type CollateExpression struct {
+	Expression Expression
+	Collation  string
+}
+
+func (e *CollateExpression) node()           {}
+func (e *CollateExpression) expressionNode() {}
+func (e *CollateExpression) String() string {
+	return operandString(e.Expression) + " COLLATE " + quoteIdentifier(e.Collation)
+}
+
+// CaseExpression represents a CASE expression. Operand is nil for the
+// searched form (CASE WHEN cond THEN ...) and set for the simple form
+// (CASE expr WHEN value THEN ...).
+type CaseExpression struct {
+	Operand Expression
+	Whens   []WhenClause
+	Else    Expression
+}
+
+// WhenClause represents a WHEN ... THEN ... branch of a CASE expression.
+type WhenClause struct {
+	Condition Expression
+	Result    Expression
+}
+
+func (e *CaseExpression) node()           {}
+func (e *CaseExpression) expressionNode() {}
+func (e *CaseExpression) String() string {
+	var sb strings.Builder
+	sb.WriteString("CASE")
+	if e.Operand != nil {
+		sb.WriteString(" " + e.Operand.String())
+	}
+	for _, w := range e.Whens {
+		sb.WriteString(" WHEN " + w.Condition.String() + " THEN " + w.Result.String())
+	}
+	if e.Else != nil {
+		sb.WriteString(" ELSE " + e.Else.String())
+	}
+	sb.WriteString(" END")
+	return sb.String()
+}
+
+// FunctionCall represents a function call. Name is upper-cased.
+type FunctionCall struct {
+	Name      string
+	Arguments []Expression
+	// Star is set for a call written with * as its argument, as in COUNT(*).
+	Star bool
+}
+
+func (e *FunctionCall) node()           {}
+func (e *FunctionCall) expressionNode() {}
+func (e *FunctionCall) String() string {
+	if e.Star {
+		return e.Name + "(*)"
+	}
+	// EXTRACT is shown as written, EXTRACT(year FROM d), when its field
+	// reads back as the same name.
+	if field, ok := e.extractField(); ok {
+		return "EXTRACT(" + field + " FROM " + e.Arguments[1].String() + ")"
+	}
+	args := make([]string, len(e.Arguments))
+	for i, arg := range e.Arguments {
+		args[i] = arg.String()
+	}
+	return e.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// extractField returns the field of an EXTRACT call if it is a lower-case
+// name, which the EXTRACT(field FROM source) syntax accepts unquoted.
+func (e *FunctionCall) extractField() (string, bool) {
+	if e.Name != "EXTRACT" || len(e.Arguments) != 2 {
+		return "", false
+	}
+	lit, ok := e.Arguments[0].(*StringLiteral)
+	if !ok || lit.Value != strings.ToLower(lit.Value) || quoteIdentifier(lit.Value) != lit.Value {
+		return "", false
+	}
+	return lit.Value, true
+}
+
+// operandString renders an operand, parenthesizing nested operators so the
+// result reads unambiguously.
+func operandString(e Expression) string {
+	switch e.(type) {
+	case *InfixExpression, *PrefixExpression, *IsNullExpression, *IsDistinctFromExpression,
+		*LikeExpression:
+		return "(" + e.String() + ")"
+	default:
+		return e.String()
+	}
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifier returns name as written in SQL: unchanged if it lexes as
+// an identifier, and in double quotes otherwise.
+func quoteIdentifier(name string) string {
+	plain := name != "" && LookupIdent(name) == TOKEN_IDENT
+	for i, r := range name {
+		if !isLetter(r) && (i == 0 || !unicode.IsDigit(r) && !unicode.IsMark(r)) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,673 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operator precedences, from loosest to tightest binding.
+const (
+	_ int = iota
+	LOWEST
+	OR      // OR
+	AND     // AND
+	NOT     // NOT x
+	IS      // IS [NOT] NULL, IS [NOT] DISTINCT FROM
+	COMPARE // = <> < <= > >=
+	LIKE    // [NOT] LIKE, [NOT] ILIKE
+	CONCAT  // || ~ !~
+	SUM     // + -
+	PRODUCT // * / %
+	PREFIX  // -x
+	JSON    // -> ->>
+	CAST    // x::type, x COLLATE name
+	INDEX   // x[i] x.field
+)
+
+var precedences = map[TokenType]int{
+	TOKEN_OR:           OR,
+	TOKEN_AND:          AND,
+	TOKEN_IS:           IS,
+	TOKEN_EQ:           COMPARE,
+	TOKEN_NOT_EQ:       COMPARE,
+	TOKEN_LT:           COMPARE,
+	TOKEN_LTE:          COMPARE,
+	TOKEN_GT:           COMPARE,
+	TOKEN_GTE:          COMPARE,
+	TOKEN_LIKE:         LIKE,
+	TOKEN_ILIKE:        LIKE,
+	TOKEN_NOT:          LIKE,
+	TOKEN_CONCAT:       CONCAT,
+	TOKEN_MATCH:        CONCAT,
+	TOKEN_NOMATCH:      CONCAT,
+	TOKEN_DOUBLE_COLON: CAST,
+	TOKEN_COLLATE:      CAST,
+	TOKEN_ARROW:        JSON,
+	TOKEN_LONG_ARROW:   JSON,
+	TOKEN_LBRACKET:     INDEX,
+	TOKEN_DOT:          INDEX,
+	TOKEN_PLUS:         SUM,
+	TOKEN_MINUS:        SUM,
+	TOKEN_ASTERISK:     PRODUCT,
+	TOKEN_SLASH:        PRODUCT,
+	TOKEN_PERCENT:      PRODUCT,
+}
+
+type (
+	prefixParseFn func() Expression
+	infixParseFn  func(Expression) Expression
+)
+
+func (p *Parser) registerExpressionParsers() {
+	p.prefixParseFns = map[TokenType]prefixParseFn{
+		TOKEN_IDENT:    p.parseIdentifier,
+		TOKEN_INT:      p.parseLiteral,
+		TOKEN_FLOAT:    p.parseLiteral,
+		TOKEN_STRING:   p.parseLiteral,
+		TOKEN_BYTES:    p.parseLiteral,
+		TOKEN_TRUE:     p.parseLiteral,
+		TOKEN_FALSE:    p.parseLiteral,
+		TOKEN_NULL:     p.parseLiteral,
+		TOKEN_LPAREN:   p.parseGroupedExpression,
+		TOKEN_MINUS:    p.parsePrefixExpression,
+		TOKEN_PLUS:     p.parsePrefixExpression,
+		TOKEN_NOT:      p.parseNotExpression,
+		TOKEN_CASE:     p.parseCaseExpression,
+		TOKEN_CAST:     p.parseCastExpression,
+		TOKEN_LBRACKET: p.parseListLiteral,
+		TOKEN_LBRACE:   p.parseStructLiteral,
+	}
+	p.infixParseFns = map[TokenType]infixParseFn{
+		TOKEN_PLUS:         p.parseInfixExpression,
+		TOKEN_MINUS:        p.parseInfixExpression,
+		TOKEN_ASTERISK:     p.parseInfixExpression,
+		TOKEN_SLASH:        p.parseInfixExpression,
+		TOKEN_PERCENT:      p.parseInfixExpression,
+		TOKEN_EQ:           p.parseInfixExpression,
+		TOKEN_NOT_EQ:       p.parseInfixExpression,
+		TOKEN_LT:           p.parseInfixExpression,
+		TOKEN_LTE:          p.parseInfixExpression,
+		TOKEN_GT:           p.parseInfixExpression,
+		TOKEN_GTE:          p.parseInfixExpression,
+		TOKEN_AND:          p.parseInfixExpression,
+		TOKEN_OR:           p.parseInfixExpression,
+		TOKEN_IS:           p.parseIsExpression,
+		TOKEN_LIKE:         p.parseLikeExpression,
+		TOKEN_ILIKE:        p.parseLikeExpression,
+		TOKEN_NOT:          p.parseNegatedInfix,
+		TOKEN_CONCAT:       p.parseInfixExpression,
+		TOKEN_MATCH:        p.parseInfixExpression,
+		TOKEN_NOMATCH:      p.parseInfixExpression,
+		TOKEN_DOUBLE_COLON: p.parsePostfixCast,
+		TOKEN_COLLATE:      p.parseCollateExpression,
+		TOKEN_ARROW:        p.parseInfixExpression,
+		TOKEN_LONG_ARROW:   p.parseInfixExpression,
+		TOKEN_LBRACKET:     p.parseIndexExpression,
+		TOKEN_DOT:          p.parseFieldAccess,
+	}
+}
+
+func (p *Parser) peekPrecedence() int {
+	if prec, ok := precedences[p.peekToken.Type]; ok {
+		return prec
+	}
+	return LOWEST
+}
+
+func (p *Parser) curPrecedence() int {
+	if prec, ok := precedences[p.curToken.Type]; ok {
+		return prec
+	}
+	return LOWEST
+}
+
+// parseExpression parses an expression starting at the current token.
+// On return the current token is the last token of the expression.
+func (p *Parser) parseExpression(precedence int) Expression {
+	prefix := p.prefixParseFns[p.curToken.Type]
+	if prefix == nil {
+		p.addError(fmt.Sprintf("unexpected token in expression: %s", p.curToken.Literal))
+		return nil
+	}
+	left := prefix()
+
+	for left != nil && precedence < p.peekPrecedence() {
+		infix := p.infixParseFns[p.peekToken.Type]
+		if infix == nil {
+			return left
+		}
+		p.nextToken()
+		left = infix(left)
+	}
+
+	return left
+}
+
+func (p *Parser) parseIdentifier() Expression {
+	if p.peekTokenIs(TOKEN_LPAREN) {
+		return p.parseFunctionCall()
+	}
+
+	// A quoted name is always a column, never a typed literal or function.
+	if !p.curToken.Quoted {
+		switch name := strings.ToUpper(p.curToken.Literal); name {
+		case "DATE", "TIMESTAMP", "TIMESTAMPTZ", "INTERVAL", "DECIMAL", "NUMERIC", "JSON", "UUID":
+			if p.peekTokenIs(TOKEN_STRING) || (name == "TIMESTAMP" && (p.peekKeywordIs("WITH") || p.peekKeywordIs("WITHOUT"))) {
+				return p.parseTypedLiteral()
+			}
+		case "CURRENT_DATE", "CURRENT_TIMESTAMP", "LOCALTIMESTAMP":
+			return &FunctionCall{Name: name}
+		}
+	}
+
+	if p.peekTokenIs(TOKEN_DOT) {
+		table := p.curToken.Literal
+		p.nextToken()
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		return &Identifier{Table: table, Name: p.curToken.Literal}
+	}
+	return &Identifier{Name: p.curToken.Literal}
+}
+
+// parseTypedLiteral parses a type name followed by a string, such as
+// DATE '2026-01-01', as a cast of the string to that type.
+func (p *Parser) parseTypedLiteral() Expression {
+	dataType := p.parseDataType()
+	if dataType == "" || !p.expectPeek(TOKEN_STRING) {
+		return nil
+	}
+	return &CastExpression{Expression: &StringLiteral{Value: p.curToken.Literal}, DataType: dataType}
+}
+
+func (p *Parser) parseFunctionCall() Expression {
+	call := &FunctionCall{Name: strings.ToUpper(p.curToken.Literal)}
+
+	p.nextToken() // move to (
+
+	switch call.Name {
+	case "SUBSTRING":
+		return p.parseSubstring(call)
+	case "TRIM":
+		return p.parseTrim(call)
+	case "EXTRACT":
+		return p.parseExtract(call)
+	}
+	if p.peekTokenIs(TOKEN_ASTERISK) {
+		p.nextToken()
+		call.Star = true
+		if !p.expectPeek(TOKEN_RPAREN) {
+			return nil
+		}
+		return call
+	}
+	call.Arguments = p.parseExpressionList(TOKEN_RPAREN)
+	if call.Arguments == nil && !p.curTokenIs(TOKEN_RPAREN) {
+		return nil
+	}
+
+	return call
+}
+
+func (p *Parser) parsePrefixExpression() Expression {
+	operator := p.curToken.Literal
+
+	// Fold a sign directly in front of a numeric literal into the literal
+	// so that the most negative INT64 can be written.
+	if operator == "-" && p.peekTokenIs(TOKEN_INT) {
+		p.nextToken()
+		return p.integerLiteral("-" + p.curToken.Literal)
+	}
+	if operator == "-" && p.peekTokenIs(TOKEN_FLOAT) {
+		p.nextToken()
+		val, err := strconv.ParseFloat("-"+p.curToken.Literal, 64)
+		if err != nil {
+			p.addError(fmt.Sprintf("could not parse %q as float", "-"+p.curToken.Literal))
+			return nil
+		}
+		return &FloatLiteral{Value: val}
+	}
+
+	p.nextToken()
+	right := p.parseExpression(PREFIX)
+	if right == nil {
+		return nil
+	}
+	if operator == "+" {
+		return right
+	}
+
+	return &PrefixExpression{Operator: operator, Right: right}
+}
+
+func (p *Parser) parseInfixExpression(left Expression) Expression {
+	expr := &InfixExpression{Left: left, Operator: strings.ToUpper(p.curToken.Literal)}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expr.Right = p.parseExpression(precedence)
+	if expr.Right == nil {
+		return nil
+	}
+
+	return expr
+}
+
+// parseSubstring parses the arguments of SUBSTRING, accepting both the
+// function form SUBSTRING(s, start[, length]) and the SQL standard form
+// SUBSTRING(s FROM start [FOR length]).
+func (p *Parser) parseSubstring(call *FunctionCall) Expression {
+	p.nextToken()
+	str := p.parseExpression(LOWEST)
+	if str == nil {
+		return nil
+	}
+
+	if !p.peekTokenIs(TOKEN_FROM) {
+		call.Arguments = append([]Expression{str}, p.parseRemainingArguments()...)
+		if !p.curTokenIs(TOKEN_RPAREN) {
+			return nil
+		}
+		return call
+	}
+
+	p.nextToken()
+	p.nextToken()
+	start := p.parseExpression(LOWEST)
+	if start == nil {
+		return nil
+	}
+	call.Arguments = []Expression{str, start}
+
+	if p.peekKeywordIs("FOR") {
+		p.nextToken()
+		p.nextToken()
+		length := p.parseExpression(LOWEST)
+		if length == nil {
+			return nil
+		}
+		call.Arguments = append(call.Arguments, length)
+	}
+
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+	return call
+}
+
+// parseTrim parses TRIM(s[, chars]) and the SQL standard form
+// TRIM([LEADING | TRAILING | BOTH] [chars] FROM s). LEADING and TRAILING
+// are rewritten to LTRIM and RTRIM.
+func (p *Parser) parseTrim(call *FunctionCall) Expression {
+	p.nextToken()
+
+	if p.curTokenIs(TOKEN_IDENT) && !p.curToken.Quoted && !p.peekTokenIs(TOKEN_COMMA) && !p.peekTokenIs(TOKEN_RPAREN) {
+		switch strings.ToUpper(p.curToken.Literal) {
+		case "LEADING":
+			call.Name = "LTRIM"
+			p.nextToken()
+		case "TRAILING":
+			call.Name = "RTRIM"
+			p.nextToken()
+		case "BOTH":
+			p.nextToken()
+		}
+	}
+
+	var chars Expression
+	if !p.curTokenIs(TOKEN_FROM) {
+		first := p.parseExpression(LOWEST)
+		if first == nil {
+			return nil
+		}
+		if !p.peekTokenIs(TOKEN_FROM) {
+			call.Arguments = append([]Expression{first}, p.parseRemainingArguments()...)
+			if !p.curTokenIs(TOKEN_RPAREN) {
+				return nil
+			}
+			return call
+		}
+		chars = first
+		p.nextToken()
+	}
+
+	p.nextToken()
+	str := p.parseExpression(LOWEST)
+	if str == nil {
+		return nil
+	}
+	call.Arguments = []Expression{str}
+	if chars != nil {
+		call.Arguments = append(call.Arguments, chars)
+	}
+
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+	return call
+}
+
+// parseExtract parses EXTRACT(field FROM source), where the field is a
+// name such as YEAR, into EXTRACT('year', source). The function form
+// EXTRACT('year', source) is accepted as well.
+func (p *Parser) parseExtract(call *FunctionCall) Expression {
+	p.nextToken()
+
+	var field Expression
+	if p.curTokenIs(TOKEN_IDENT) && p.peekTokenIs(TOKEN_FROM) {
+		field = &StringLiteral{Value: strings.ToLower(p.curToken.Literal)}
+	} else if field = p.parseExpression(LOWEST); field == nil {
+		return nil
+	}
+
+	if !p.peekTokenIs(TOKEN_FROM) {
+		call.Arguments = append([]Expression{field}, p.parseRemainingArguments()...)
+		if !p.curTokenIs(TOKEN_RPAREN) {
+			return nil
+		}
+		return call
+	}
+
+	p.nextToken()
+	p.nextToken()
+	source := p.parseExpression(LOWEST)
+	if source == nil || !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+	call.Arguments = []Expression{field, source}
+	return call
+}
+
+// parseRemainingArguments parses ", arg)*" after the first argument of a
+// function call. On return the current token is the closing parenthesis.
+func (p *Parser) parseRemainingArguments() []Expression {
+	var args []Expression
+	for p.peekTokenIs(TOKEN_COMMA) {
+		p.nextToken()
+		p.nextToken()
+		args = append(args, p.parseExpression(LOWEST))
+	}
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+	return args
+}
+
+func (p *Parser) parseLikeExpression(left Expression) Expression {
+	expr := &LikeExpression{
+		Expression:      left,
+		CaseInsensitive: p.curTokenIs(TOKEN_ILIKE),
+	}
+
+	p.nextToken()
+	expr.Pattern = p.parseExpression(LIKE)
+	if expr.Pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(TOKEN_ESCAPE) {
+		p.nextToken()
+		p.nextToken()
+		expr.Escape = p.parseExpression(LIKE)
+		if expr.Escape == nil {
+			return nil
+		}
+	}
+
+	return expr
+}
+
+// parseNegatedInfix parses NOT used as an infix operator, as in
+// a NOT LIKE b.
+func (p *Parser) parseNegatedInfix(left Expression) Expression {
+	switch p.peekToken.Type {
+	case TOKEN_LIKE, TOKEN_ILIKE:
+		p.nextToken()
+		expr := p.parseLikeExpression(left)
+		if like, ok := expr.(*LikeExpression); ok {
+			like.Not = true
+		}
+		return expr
+	default:
+		p.addError(fmt.Sprintf("unexpected token after NOT: %s", p.peekToken.Literal))
+		return nil
+	}
+}
+
+func (p *Parser) parseNotExpression() Expression {
+	p.nextToken()
+	right := p.parseExpression(NOT)
+	if right == nil {
+		return nil
+	}
+	return &PrefixExpression{Operator: "NOT", Right: right}
+}
+
+func (p *Parser) parseIsExpression(left Expression) Expression {
+	not := false
+	if p.peekTokenIs(TOKEN_NOT) {
+		p.nextToken()
+		not = true
+	}
+
+	switch {
+	case p.peekTokenIs(TOKEN_NULL):
+		p.nextToken()
+		return &IsNullExpression{Expression: left, Not: not}
+
+	case p.peekTokenIs(TOKEN_DISTINCT):
+		p.nextToken()
+		if !p.expectPeek(TOKEN_FROM) {
+			return nil
+		}
+		p.nextToken()
+		right := p.parseExpression(IS)
+		if right == nil {
+			return nil
+		}
+		return &IsDistinctFromExpression{Left: left, Right: right, Not: not}
+
+	default:
+		p.addError(fmt.Sprintf("expected NULL or DISTINCT FROM after IS, got %s", p.peekToken.Literal))
+		return nil
+	}
+}
+
+func (p *Parser) parseCaseExpression() Expression {
+	expr := &CaseExpression{}
+
+	if !p.peekTokenIs(TOKEN_WHEN) {
+		p.nextToken()
+		expr.Operand = p.parseExpression(LOWEST)
+		if expr.Operand == nil {
+			return nil
+		}
+	}
+
+	for p.peekTokenIs(TOKEN_WHEN) {
+		p.nextToken()
+		p.nextToken()
+		cond := p.parseExpression(LOWEST)
+		if cond == nil || !p.expectPeek(TOKEN_THEN) {
+			return nil
+		}
+		p.nextToken()
+		result := p.parseExpression(LOWEST)
+		if result == nil {
+			return nil
+		}
+		expr.Whens = append(expr.Whens, WhenClause{Condition: cond, Result: result})
+	}
+
+	if len(expr.Whens) == 0 {
+		p.addError("expected WHEN in CASE expression")
+		return nil
+	}
+
+	if p.peekTokenIs(TOKEN_ELSE) {
+		p.nextToken()
+		p.nextToken()
+		expr.Else = p.parseExpression(LOWEST)
+		if expr.Else == nil {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(TOKEN_END) {
+		return nil
+	}
+
+	return expr
+}
+
+func (p *Parser) parseCastExpression() Expression {
+	if !p.expectPeek(TOKEN_LPAREN) {
+		return nil
+	}
+	p.nextToken()
+
+	expr := p.parseExpression(LOWEST)
+	if expr == nil || !p.expectPeek(TOKEN_AS) {
+		return nil
+	}
+	p.nextToken()
+
+	cast := &CastExpression{Expression: expr, DataType: p.parseDataType()}
+
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+	return cast
+}
+
+func (p *Parser) parsePostfixCast(left Expression) Expression {
+	p.nextToken()
+	return &CastExpression{Expression: left, DataType: p.parseDataType()}
+}
+
+// parseCollateExpression parses expr COLLATE name. The name may be written
+// as an identifier or a string.
+func (p *Parser) parseCollateExpression(left Expression) Expression {
+	collation, ok := p.parseCollationName()
+	if !ok {
+		return nil
+	}
+	return &CollateExpression{Expression: left, Collation: collation}
+}
+
+// parseCollationName parses the name after COLLATE, which is the peek
+// token.
+func (p *Parser) parseCollationName() (string, bool) {
+	if !p.peekTokenIs(TOKEN_IDENT) && !p.peekTokenIs(TOKEN_STRING) {
+		p.addError(fmt.Sprintf("expected collation name, got %s", p.peekToken.Literal))
+		return "", false
+	}
+	p.nextToken()
+	return p.curToken.Literal, true
+}
+
+func (p *Parser) parseGroupedExpression() Expression {
+	p.nextToken()
+
+	expr := p.parseExpression(LOWEST)
+
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+
+	return expr
+}
+
+// parseExpressionList parses a comma-separated list of expressions that
+// ends with the given token. On return the current token is the terminator.
+// parseListLiteral parses [elem, ...].
+func (p *Parser) parseListLiteral() Expression {
+	elems := p.parseExpressionList(TOKEN_RBRACKET)
+	if elems == nil && !p.curTokenIs(TOKEN_RBRACKET) {
+		return nil
+	}
+	for _, elem := range elems {
+		if elem == nil {
+			return nil
+		}
+	}
+	return &ListLiteral{Elements: elems}
+}
+
+// parseStructLiteral parses {name: value, ...}, where each name is an
+// identifier or a string.
+func (p *Parser) parseStructLiteral() Expression {
+	lit := &StructLiteral{}
+	if p.peekTokenIs(TOKEN_RBRACE) {
+		p.nextToken()
+		return lit
+	}
+	for {
+		p.nextToken()
+		if !p.curTokenIs(TOKEN_IDENT) && !p.curTokenIs(TOKEN_STRING) {
+			p.addError(fmt.Sprintf("expected field name, got %s", p.curToken.Literal))
+			return nil
+		}
+		name := p.curToken.Literal
+		if !p.expectPeek(TOKEN_COLON) {
+			return nil
+		}
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		lit.Names = append(lit.Names, name)
+		lit.Values = append(lit.Values, value)
+		if !p.peekTokenIs(TOKEN_COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(TOKEN_RBRACE) {
+		return nil
+	}
+	return lit
+}
+
+func (p *Parser) parseIndexExpression(left Expression) Expression {
+	p.nextToken()
+	index := p.parseExpression(LOWEST)
+	if index == nil || !p.expectPeek(TOKEN_RBRACKET) {
+		return nil
+	}
+	return &IndexExpression{Expression: left, Index: index}
+}
+
+func (p *Parser) parseFieldAccess(left Expression) Expression {
+	if !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	return &FieldAccess{Expression: left, Field: p.curToken.Literal}
+}
+
+func (p *Parser) parseExpressionList(end TokenType) []Expression {
+	var exprs []Expression
+
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return exprs
+	}
+
+	p.nextToken()
+	exprs = append(exprs, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(TOKEN_COMMA) {
+		p.nextToken()
+		p.nextToken()
+		exprs = append(exprs, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return exprs
+}
@@ -4,6 +4,7 @@ package parser
 import (
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // TokenType represents the type of a token.
@@ -18,15 +19,38 @@ const (
 	TOKEN_INT
 	TOKEN_FLOAT
 	TOKEN_STRING
+	TOKEN_BYTES // X'...'
 
 	// Operators
-	TOKEN_ASTERISK // *
+	TOKEN_ASTERISK     // *
+	TOKEN_PLUS         // +
+	TOKEN_MINUS        // -
+	TOKEN_SLASH        // /
+	TOKEN_PERCENT      // %
+	TOKEN_EQ           // =
+	TOKEN_NOT_EQ       // <> or !=
+	TOKEN_LT           // <
+	TOKEN_LTE          // <=
+	TOKEN_GT           // >
+	TOKEN_GTE          // >=
+	TOKEN_CONCAT       // ||
+	TOKEN_MATCH        // ~
+	TOKEN_NOMATCH      // !~
+	TOKEN_DOUBLE_COLON // ::
+	TOKEN_ARROW        // ->
+	TOKEN_LONG_ARROW   // ->>
 
 	// Delimiters
 	TOKEN_COMMA     // ,
+	TOKEN_DOT       // .
 	TOKEN_SEMICOLON // ;
 	TOKEN_LPAREN    // (
 	TOKEN_RPAREN    // )
+	TOKEN_LBRACKET  // [
+	TOKEN_RBRACKET  // ]
+	TOKEN_LBRACE    // {
+	TOKEN_RBRACE    // }
+	TOKEN_COLON     // :
 
 	// Keywords
 	TOKEN_SELECT
@@ -40,6 +64,40 @@ const (
 	TOKEN_NULL
 	TOKEN_TRUE
 	TOKEN_FALSE
+	TOKEN_DISTINCT
+	TOKEN_ON
+	TOKEN_ORDER
+	TOKEN_BY
+	TOKEN_ASC
+	TOKEN_DESC
+	TOKEN_AS
+	TOKEN_WHERE
+	TOKEN_AND
+	TOKEN_OR
+	TOKEN_NOT
+	TOKEN_IS
+	TOKEN_CASE
+	TOKEN_WHEN
+	TOKEN_THEN
+	TOKEN_ELSE
+	TOKEN_END
+	TOKEN_LIKE
+	TOKEN_ILIKE
+	TOKEN_ESCAPE
+	TOKEN_CAST
+	TOKEN_COLLATE
+	TOKEN_DEFAULT
+	TOKEN_PRIMARY
+	TOKEN_UNIQUE
+	TOKEN_CONSTRAINT
+	TOKEN_UPDATE
+	TOKEN_SET
+	TOKEN_DELETE
+	TOKEN_MERGE
+	TOKEN_USING
+	TOKEN_RETURNING
+	TOKEN_ALTER
+	TOKEN_TRUNCATE
 
 	// Data types
 	TOKEN_TYPE_INT64
@@ -52,26 +110,71 @@ const (
 type Token struct {
 	Type    TokenType
 	Literal string
-	Line    int
-	Column  int
+	// Line and Column give the position of the token's first character.
+	// Columns count characters, not bytes, from 1.
+	Line   int
+	Column int
+	// Quoted is set for an identifier written in double quotes, which is
+	// never a keyword.
+	Quoted bool
 }
 
 var keywords = map[string]TokenType{
-	"SELECT":  TOKEN_SELECT,
-	"FROM":    TOKEN_FROM,
-	"INSERT":  TOKEN_INSERT,
-	"INTO":    TOKEN_INTO,
-	"VALUES":  TOKEN_VALUES,
-	"CREATE":  TOKEN_CREATE,
-	"TABLE":   TOKEN_TABLE,
-	"DROP":    TOKEN_DROP,
-	"NULL":    TOKEN_NULL,
-	"TRUE":    TOKEN_TRUE,
-	"FALSE":   TOKEN_FALSE,
-	"INT64":   TOKEN_TYPE_INT64,
-	"FLOAT64": TOKEN_TYPE_FLOAT64,
-	"STRING":  TOKEN_TYPE_STRING,
-	"BOOL":    TOKEN_TYPE_BOOL,
+	"SELECT":     TOKEN_SELECT,
+	"FROM":       TOKEN_FROM,
+	"INSERT":     TOKEN_INSERT,
+	"INTO":       TOKEN_INTO,
+	"VALUES":     TOKEN_VALUES,
+	"CREATE":     TOKEN_CREATE,
+	"TABLE":      TOKEN_TABLE,
+	"DROP":       TOKEN_DROP,
+	"NULL":       TOKEN_NULL,
+	"TRUE":       TOKEN_TRUE,
+	"FALSE":      TOKEN_FALSE,
+	"DISTINCT":   TOKEN_DISTINCT,
+	"ON":         TOKEN_ON,
+	"ORDER":      TOKEN_ORDER,
+	"BY":         TOKEN_BY,
+	"ASC":        TOKEN_ASC,
+	"DESC":       TOKEN_DESC,
+	"AS":         TOKEN_AS,
+	"WHERE":      TOKEN_WHERE,
+	"AND":        TOKEN_AND,
+	"OR":         TOKEN_OR,
+	"NOT":        TOKEN_NOT,
+	"IS":         TOKEN_IS,
+	"CASE":       TOKEN_CASE,
+	"WHEN":       TOKEN_WHEN,
+	"THEN":       TOKEN_THEN,
+	"ELSE":       TOKEN_ELSE,
+	"END":        TOKEN_END,
+	"LIKE":       TOKEN_LIKE,
+	"ILIKE":      TOKEN_ILIKE,
+	"ESCAPE":     TOKEN_ESCAPE,
+	"CAST":       TOKEN_CAST,
+	"COLLATE":    TOKEN_COLLATE,
+	"DEFAULT":    TOKEN_DEFAULT,
+	"PRIMARY":    TOKEN_PRIMARY,
+	"UNIQUE":     TOKEN_UNIQUE,
+	"CONSTRAINT": TOKEN_CONSTRAINT,
+	"UPDATE":     TOKEN_UPDATE,
+	"SET":        TOKEN_SET,
+	"DELETE":     TOKEN_DELETE,
+	"MERGE":      TOKEN_MERGE,
+	"USING":      TOKEN_USING,
+	"RETURNING":  TOKEN_RETURNING,
+	"ALTER":      TOKEN_ALTER,
+	"TRUNCATE":   TOKEN_TRUNCATE,
+	"INT64":      TOKEN_TYPE_INT64,
+	"FLOAT64":    TOKEN_TYPE_FLOAT64,
+	"STRING":     TOKEN_TYPE_STRING,
+	"BOOL":       TOKEN_TYPE_BOOL,
+}
+
+// isKeyword reports whether the token is the given non-reserved keyword,
+// which is lexed as an unquoted identifier.
+func (t Token) isKeyword(keyword string) bool {
+	return t.Type == TOKEN_IDENT && !t.Quoted && strings.EqualFold(t.Literal, keyword)
 }
 
 // LookupIdent checks if an identifier is a keyword.
@@ -82,12 +185,12 @@ func LookupIdent(ident string) TokenType {
 	return TOKEN_IDENT
 }
 
-// Lexer performs lexical analysis on SQL input.
+// Lexer performs lexical analysis on SQL input, which is read as UTF-8.
 type Lexer struct {
 	input        string
-	position     int
-	readPosition int
-	ch           byte
+	position     int // byte offset of ch
+	readPosition int // byte offset of the character after ch
+	ch           rune
 	line         int
 	column       int
 }
@@ -100,13 +203,14 @@ func NewLexer(input string) *Lexer {
 }
 
 func (l *Lexer) readChar() {
+	l.position = l.readPosition
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		var width int
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
+		l.readPosition += width
 	}
-	l.position = l.readPosition
-	l.readPosition++
 
 	if l.ch == '\n' {
 		l.line++
@@ -116,16 +220,19 @@ func (l *Lexer) readChar() {
 	}
 }
 
-func (l *Lexer) peekChar() byte {
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	r, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return r
 }
 
 // NextToken returns the next token from the input.
 func (l *Lexer) NextToken() Token {
-	l.skipWhitespace()
+	if !l.skipWhitespace() {
+		return Token{Type: TOKEN_ILLEGAL, Literal: "unterminated /* comment", Line: l.line, Column: l.column}
+	}
 
 	tok := Token{Line: l.line, Column: l.column}
 
@@ -136,6 +243,9 @@ func (l *Lexer) NextToken() Token {
 	case ',':
 		tok.Type = TOKEN_COMMA
 		tok.Literal = string(l.ch)
+	case '.':
+		tok.Type = TOKEN_DOT
+		tok.Literal = string(l.ch)
 	case ';':
 		tok.Type = TOKEN_SEMICOLON
 		tok.Literal = string(l.ch)
@@ -145,28 +255,137 @@ func (l *Lexer) NextToken() Token {
 	case ')':
 		tok.Type = TOKEN_RPAREN
 		tok.Literal = string(l.ch)
+	case '[':
+		tok.Type = TOKEN_LBRACKET
+		tok.Literal = string(l.ch)
+	case ']':
+		tok.Type = TOKEN_RBRACKET
+		tok.Literal = string(l.ch)
+	case '{':
+		tok.Type = TOKEN_LBRACE
+		tok.Literal = string(l.ch)
+	case '}':
+		tok.Type = TOKEN_RBRACE
+		tok.Literal = string(l.ch)
 	case '\'':
+		var ok bool
+		tok.Literal, ok = l.readString()
 		tok.Type = TOKEN_STRING
-		tok.Literal = l.readString()
+		if !ok {
+			tok.Type = TOKEN_ILLEGAL
+		}
+	case '"':
+		tok.Literal, tok.Quoted = l.readQuotedIdentifier()
+		tok.Type = TOKEN_IDENT
+		if !tok.Quoted {
+			tok.Type = TOKEN_ILLEGAL
+		}
+	case '+':
+		tok.Type = TOKEN_PLUS
+		tok.Literal = string(l.ch)
 	case '-':
-		if isDigit(l.peekChar()) {
+		if l.peekChar() == '>' {
 			l.readChar()
-			literal, isFloat := l.readNumber()
-			tok.Literal = "-" + literal
-			if isFloat {
-				tok.Type = TOKEN_FLOAT
+			if l.peekChar() == '>' {
+				l.readChar()
+				tok.Type = TOKEN_LONG_ARROW
+				tok.Literal = "->>"
 			} else {
-				tok.Type = TOKEN_INT
+				tok.Type = TOKEN_ARROW
+				tok.Literal = "->"
 			}
-			return tok
+		} else {
+			tok.Type = TOKEN_MINUS
+			tok.Literal = string(l.ch)
 		}
-		tok.Type = TOKEN_ILLEGAL
+	case '/':
+		tok.Type = TOKEN_SLASH
 		tok.Literal = string(l.ch)
+	case '%':
+		tok.Type = TOKEN_PERCENT
+		tok.Literal = string(l.ch)
+	case '=':
+		tok.Type = TOKEN_EQ
+		tok.Literal = string(l.ch)
+	case '<':
+		switch l.peekChar() {
+		case '=':
+			l.readChar()
+			tok.Type = TOKEN_LTE
+			tok.Literal = "<="
+		case '>':
+			l.readChar()
+			tok.Type = TOKEN_NOT_EQ
+			tok.Literal = "<>"
+		default:
+			tok.Type = TOKEN_LT
+			tok.Literal = string(l.ch)
+		}
+	case '>':
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok.Type = TOKEN_GTE
+			tok.Literal = ">="
+		} else {
+			tok.Type = TOKEN_GT
+			tok.Literal = string(l.ch)
+		}
+	case '!':
+		switch l.peekChar() {
+		case '=':
+			l.readChar()
+			tok.Type = TOKEN_NOT_EQ
+			tok.Literal = "<>"
+		case '~':
+			l.readChar()
+			tok.Type = TOKEN_NOMATCH
+			tok.Literal = "!~"
+		default:
+			tok.Type = TOKEN_ILLEGAL
+			tok.Literal = string(l.ch)
+		}
+	case ':':
+		if l.peekChar() == ':' {
+			l.readChar()
+			tok.Type = TOKEN_DOUBLE_COLON
+			tok.Literal = "::"
+		} else {
+			tok.Type = TOKEN_COLON
+			tok.Literal = string(l.ch)
+		}
+	case '~':
+		tok.Type = TOKEN_MATCH
+		tok.Literal = string(l.ch)
+	case '|':
+		if l.peekChar() == '|' {
+			l.readChar()
+			tok.Type = TOKEN_CONCAT
+			tok.Literal = "||"
+		} else {
+			tok.Type = TOKEN_ILLEGAL
+			tok.Literal = string(l.ch)
+		}
 	case 0:
 		tok.Type = TOKEN_EOF
 		tok.Literal = ""
 	default:
-		if isLetter(l.ch) {
+		if (l.ch == 'x' || l.ch == 'X') && l.peekChar() == '\'' {
+			l.readChar()
+			var ok bool
+			tok.Literal, ok = l.readString()
+			tok.Type = TOKEN_BYTES
+			if !ok {
+				tok.Type = TOKEN_ILLEGAL
+			}
+		} else if (l.ch == 'e' || l.ch == 'E') && l.peekChar() == '\'' {
+			l.readChar()
+			var ok bool
+			tok.Literal, ok = l.readEscapeString()
+			tok.Type = TOKEN_STRING
+			if !ok {
+				tok.Type = TOKEN_ILLEGAL
+			}
+		} else if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = LookupIdent(tok.Literal)
 			return tok
@@ -189,28 +408,81 @@ func (l *Lexer) NextToken() Token {
 	return tok
 }
 
-func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
-		l.readChar()
+// skipWhitespace skips whitespace, -- line comments and /* */ block
+// comments, which may nest. It reports false if a block comment is not
+// closed.
+func (l *Lexer) skipWhitespace() bool {
+	for {
+		switch {
+		case unicode.IsSpace(l.ch):
+			l.readChar()
+		case l.ch == '-' && l.peekChar() == '-':
+			for l.ch != '\n' && l.ch != 0 {
+				l.readChar()
+			}
+		case l.ch == '/' && l.peekChar() == '*':
+			if !l.skipBlockComment() {
+				return false
+			}
+		default:
+			return true
+		}
 	}
+}
 
-	// Skip SQL comments
-	if l.ch == '-' && l.peekChar() == '-' {
-		for l.ch != '\n' && l.ch != 0 {
+func (l *Lexer) skipBlockComment() bool {
+	depth := 0
+	for l.ch != 0 {
+		switch {
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
 			l.readChar()
+			if depth == 0 {
+				l.readChar()
+				return true
+			}
 		}
-		l.skipWhitespace()
+		l.readChar()
 	}
+	return false
 }
 
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' {
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) || unicode.IsMark(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
+// readQuotedIdentifier reads an identifier in double quotes, in which two
+// double quotes stand for one. On return the current character is the
+// closing quote. It reports false for an empty or unterminated identifier.
+func (l *Lexer) readQuotedIdentifier() (string, bool) {
+	l.readChar() // skip opening quote
+	var b strings.Builder
+	for {
+		switch {
+		case l.ch == 0:
+			return "unterminated quoted identifier", false
+		case l.ch == '"' && l.peekChar() == '"':
+			b.WriteRune('"')
+			l.readChar()
+		case l.ch == '"':
+			if b.Len() == 0 {
+				return "zero-length quoted identifier", false
+			}
+			return b.String(), true
+		default:
+			b.WriteRune(l.ch)
+		}
+		l.readChar()
+	}
+}
+
 func (l *Lexer) readNumber() (string, bool) {
 	position := l.position
 	isFloat := false
@@ -227,10 +499,30 @@ func (l *Lexer) readNumber() (string, bool) {
 		}
 	}
 
+	// Exponent, as in 1e10 or 2.5E-3
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if (next == '+' || next == '-') && l.readPosition+1 < len(l.input) {
+			next = rune(l.input[l.readPosition+1])
+		}
+		if isDigit(next) {
+			isFloat = true
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+		}
+	}
+
 	return l.input[position:l.position], isFloat
 }
 
-func (l *Lexer) readString() string {
+// readString reads the string of a '...' literal, in which two quotes
+// stand for one. It reports false if the string is unterminated.
+func (l *Lexer) readString() (string, bool) {
 	l.readChar() // skip opening quote
 	position := l.position
 
@@ -244,20 +536,136 @@ func (l *Lexer) readString() string {
 			break
 		}
 		if l.ch == 0 {
-			break
+			return "unterminated string", false
 		}
 		l.readChar()
 	}
 
 	str := l.input[position:l.position]
 	str = strings.ReplaceAll(str, "''", "'")
-	return str
+	return str, true
+}
+
+// readEscapeString reads the string of an E'...' literal, in which a
+// backslash starts an escape sequence: \b, \f, \n, \r and \t, a byte
+// given by one to three octal digits or by \x and one or two hex digits, a
+// character given by \u and four or \U and eight hex digits, or any other
+// character standing for itself. It reports false if the string is
+// unterminated, a sequence is malformed or the result is not valid UTF-8.
+func (l *Lexer) readEscapeString() (string, bool) {
+	l.readChar() // skip opening quote
+	var b strings.Builder
+	for {
+		switch {
+		case l.ch == 0:
+			return "unterminated string", false
+		case l.ch == '\'' && l.peekChar() == '\'':
+			b.WriteByte('\'')
+			l.readChar()
+		case l.ch == '\'':
+			s := b.String()
+			if !utf8.ValidString(s) {
+				return "invalid byte sequence in escape string", false
+			}
+			return s, true
+		case l.ch == '\\':
+			l.readChar()
+			if !l.readEscape(&b) {
+				return "invalid escape sequence in escape string", false
+			}
+		default:
+			b.WriteRune(l.ch)
+		}
+		l.readChar()
+	}
 }
 
-func isLetter(ch byte) bool {
-	return unicode.IsLetter(rune(ch)) || ch == '_'
+// readEscape writes the character of the escape sequence whose first
+// character, after the backslash, is the current one. On return the
+// current character is the last of the sequence.
+func (l *Lexer) readEscape(b *strings.Builder) bool {
+	switch l.ch {
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'x':
+		n, ok := l.readDigits(16, 1, 2)
+		if !ok {
+			return false
+		}
+		b.WriteByte(byte(n))
+	case 'u', 'U':
+		size := 4
+		if l.ch == 'U' {
+			size = 8
+		}
+		n, ok := l.readDigits(16, size, size)
+		if !ok || !utf8.ValidRune(rune(n)) {
+			return false
+		}
+		b.WriteRune(rune(n))
+	case 0:
+		return false
+	default:
+		if isOctal(l.ch) {
+			n := int(l.ch - '0')
+			for i := 0; i < 2 && isOctal(l.peekChar()); i++ {
+				l.readChar()
+				n = n*8 + int(l.ch-'0')
+			}
+			if n > 0xFF {
+				return false
+			}
+			b.WriteByte(byte(n))
+			return true
+		}
+		b.WriteRune(l.ch)
+	}
+	return true
+}
+
+// readDigits reads between minDigits and maxDigits digits in the given
+// base following the current character.
+func (l *Lexer) readDigits(base, minDigits, maxDigits int) (int, bool) {
+	n := 0
+	for i := 0; i < maxDigits; i++ {
+		d := digitValue(l.peekChar())
+		if d < 0 || d >= base {
+			return n, i >= minDigits
+		}
+		l.readChar()
+		n = n*base + d
+	}
+	return n, true
 }
 
-func isDigit(ch byte) bool {
+func digitValue(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	case 'A' <= ch && ch <= 'F':
+		return int(ch-'A') + 10
+	}
+	return -1
+}
+
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
+
+func isOctal(ch rune) bool {
+	return '0' <= ch && ch <= '7'
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -12,11 +14,15 @@ type Parser struct {
 	curToken  Token
 	peekToken Token
 	errors    []string
+
+	prefixParseFns map[TokenType]prefixParseFn
+	infixParseFns  map[TokenType]infixParseFn
 }
 
 // NewParser creates a new Parser.
 func NewParser(l *Lexer) *Parser {
 	p := &Parser{l: l, errors: []string{}}
+	p.registerExpressionParsers()
 	p.nextToken()
 	p.nextToken()
 	return p
@@ -50,21 +56,78 @@ func (p *Parser) expectPeek(t TokenType) bool {
 	return false
 }
 
+// peekKeywordIs reports whether the next token is the given non-reserved
+// keyword. Non-reserved keywords are lexed as identifiers so that they
+// remain usable as table and column names.
+func (p *Parser) peekKeywordIs(keyword string) bool {
+	return p.peekToken.isKeyword(keyword)
+}
+
+// expectPeekKeyword is expectPeek for non-reserved keywords.
+func (p *Parser) expectPeekKeyword(keyword string) bool {
+	if p.peekKeywordIs(keyword) {
+		p.nextToken()
+		return true
+	}
+	p.errors = append(p.errors, fmt.Sprintf("line %d: expected %s, got %s",
+		p.peekToken.Line, keyword, p.peekToken.Literal))
+	return false
+}
+
 func (p *Parser) addError(msg string) {
 	p.errors = append(p.errors, fmt.Sprintf("line %d: %s", p.curToken.Line, msg))
 }
 
-// Parse parses a SQL statement and returns the AST.
+// ParseExpression parses a standalone expression, such as a column default
+// stored in the catalog.
+func ParseExpression(input string) (Expression, error) {
+	p := NewParser(NewLexer(input))
+	expr := p.parseExpression(LOWEST)
+	if len(p.errors) == 0 && !p.peekTokenIs(TOKEN_EOF) {
+		p.addError(fmt.Sprintf("unexpected token after expression: %s", p.peekToken.Literal))
+	}
+	if len(p.errors) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(p.errors, "; "))
+	}
+	return expr, nil
+}
+
+// Parse parses a SQL statement and returns the AST. The statement may be
+// followed by a semicolon, but by nothing else.
 func (p *Parser) Parse() Statement {
+	stmt := p.parseStatement()
+	if len(p.errors) > 0 {
+		return stmt
+	}
+	if p.peekTokenIs(TOKEN_SEMICOLON) {
+		p.nextToken()
+	}
+	if !p.peekTokenIs(TOKEN_EOF) {
+		p.addError(fmt.Sprintf("syntax error at or near %q", p.peekToken.Literal))
+	}
+	return stmt
+}
+
+func (p *Parser) parseStatement() Statement {
 	switch p.curToken.Type {
 	case TOKEN_SELECT:
 		return p.parseSelectStatement()
 	case TOKEN_INSERT:
 		return p.parseInsertStatement()
+	case TOKEN_UPDATE:
+		return p.parseUpdateStatement()
+	case TOKEN_DELETE:
+		return p.parseDeleteStatement()
+	case TOKEN_MERGE:
+		return p.parseMergeStatement()
 	case TOKEN_CREATE:
 		return p.parseCreateStatement()
 	case TOKEN_DROP:
 		return p.parseDropStatement()
+	case TOKEN_ALTER:
+		return p.parseAlterStatement()
+	case TOKEN_TRUNCATE:
+		return p.parseTruncateStatement()
 	default:
 		p.addError(fmt.Sprintf("unexpected token: %s", p.curToken.Literal))
 		return nil
@@ -76,6 +139,23 @@ func (p *Parser) parseSelectStatement() *SelectStatement {
 
 	p.nextToken() // move past SELECT
 
+	if p.curTokenIs(TOKEN_DISTINCT) {
+		stmt.Distinct = true
+		p.nextToken()
+
+		if p.curTokenIs(TOKEN_ON) {
+			if !p.expectPeek(TOKEN_LPAREN) {
+				return nil
+			}
+			stmt.DistinctOn = p.parseExpressionList(TOKEN_RPAREN)
+			if len(stmt.DistinctOn) == 0 {
+				p.addError("expected expression in DISTINCT ON")
+				return nil
+			}
+			p.nextToken()
+		}
+	}
+
 	stmt.Columns = p.parseSelectColumns()
 
 	if !p.expectPeek(TOKEN_FROM) {
@@ -83,27 +163,125 @@ func (p *Parser) parseSelectStatement() *SelectStatement {
 	}
 	p.nextToken()
 
-	if !p.curTokenIs(TOKEN_IDENT) {
-		p.addError("expected table name")
+	if !p.isUnnest() {
+		if !p.curTokenIs(TOKEN_IDENT) {
+			p.addError("expected table name")
+			return nil
+		}
+		stmt.TableName = p.curToken.Literal
+		if p.peekTokenIs(TOKEN_COMMA) {
+			p.nextToken()
+			p.nextToken()
+			if !p.isUnnest() {
+				p.addError(fmt.Sprintf("expected UNNEST, got %s", p.curToken.Literal))
+				return nil
+			}
+		}
+	}
+	if p.isUnnest() {
+		if stmt.Unnest = p.parseUnnest(); stmt.Unnest == nil {
+			return nil
+		}
+	}
+
+	var ok bool
+	if stmt.Where, ok = p.parseWhere(); !ok {
 		return nil
 	}
-	stmt.TableName = p.curToken.Literal
+
+	if p.peekTokenIs(TOKEN_ORDER) {
+		p.nextToken()
+		if !p.expectPeek(TOKEN_BY) {
+			return nil
+		}
+		stmt.OrderBy = p.parseOrderBy()
+		if stmt.OrderBy == nil {
+			return nil
+		}
+	}
 
 	return stmt
 }
 
+// isUnnest reports whether the current token starts UNNEST(...).
+func (p *Parser) isUnnest() bool {
+	return p.curToken.isKeyword("UNNEST") && p.peekTokenIs(TOKEN_LPAREN)
+}
+
+// parseUnnest parses UNNEST(list) [[AS] alias]. The column of elements is
+// named unnest unless an alias is given.
+func (p *Parser) parseUnnest() *UnnestClause {
+	p.nextToken()
+	p.nextToken()
+	expr := p.parseExpression(LOWEST)
+	if expr == nil || !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+	alias, ok := p.parseTableAlias()
+	if !ok {
+		return nil
+	}
+	if alias == "" {
+		alias = "unnest"
+	}
+	return &UnnestClause{Expression: expr, Alias: alias}
+}
+
+func (p *Parser) parseOrderBy() []OrderByItem {
+	var items []OrderByItem
+
+	for {
+		p.nextToken()
+
+		expr := p.parseExpression(LOWEST)
+		if expr == nil {
+			return nil
+		}
+		item := OrderByItem{Expression: expr}
+
+		if p.peekTokenIs(TOKEN_ASC) {
+			p.nextToken()
+		} else if p.peekTokenIs(TOKEN_DESC) {
+			p.nextToken()
+			item.Desc = true
+		}
+
+		items = append(items, item)
+
+		if !p.peekTokenIs(TOKEN_COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	return items
+}
+
 func (p *Parser) parseSelectColumns() []SelectColumn {
 	var columns []SelectColumn
 
 	for {
 		if p.curTokenIs(TOKEN_ASTERISK) {
 			columns = append(columns, SelectColumn{IsWildcard: true})
-		} else if p.curTokenIs(TOKEN_IDENT) {
-			columns = append(columns, SelectColumn{
-				Expression: &Identifier{Name: p.curToken.Literal},
-			})
 		} else {
-			break
+			expr := p.parseExpression(LOWEST)
+			if expr == nil {
+				break
+			}
+			col := SelectColumn{Expression: expr}
+
+			if p.peekTokenIs(TOKEN_AS) {
+				p.nextToken()
+				if !p.expectPeek(TOKEN_IDENT) {
+					break
+				}
+				col.Alias = p.curToken.Literal
+			} else if p.peekTokenIs(TOKEN_IDENT) {
+				p.nextToken()
+				col.Alias = p.curToken.Literal
+			}
+
+			columns = append(columns, col)
 		}
 
 		if !p.peekTokenIs(TOKEN_COMMA) {
@@ -153,17 +331,375 @@ func (p *Parser) parseInsertStatement() *InsertStatement {
 		return nil
 	}
 
+	if p.peekTokenIs(TOKEN_ON) {
+		p.nextToken()
+		if stmt.OnConflict = p.parseOnConflict(); stmt.OnConflict == nil {
+			return nil
+		}
+	}
+
+	var ok bool
+	if stmt.Returning, ok = p.parseReturning(); !ok {
+		return nil
+	}
+
 	return stmt
 }
 
-func (p *Parser) parseCreateStatement() *CreateTableStatement {
-	if !p.expectPeek(TOKEN_TABLE) {
+// parseOnConflict parses
+// ON CONFLICT [(cols) | ON CONSTRAINT name] DO NOTHING | DO UPDATE SET ... [WHERE cond].
+func (p *Parser) parseOnConflict() *OnConflictClause {
+	clause := &OnConflictClause{}
+
+	if !p.expectPeekKeyword("CONFLICT") {
+		return nil
+	}
+
+	switch {
+	case p.peekTokenIs(TOKEN_LPAREN):
+		p.nextToken()
+		clause.Columns = p.parseIdentifierList()
+		if !p.expectPeek(TOKEN_RPAREN) {
+			return nil
+		}
+	case p.peekTokenIs(TOKEN_ON):
+		p.nextToken()
+		if !p.expectPeek(TOKEN_CONSTRAINT) || !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		clause.Constraint = p.curToken.Literal
+	}
+
+	if !p.expectPeekKeyword("DO") {
+		return nil
+	}
+	if p.peekKeywordIs("NOTHING") {
+		p.nextToken()
+		return clause
+	}
+
+	if !p.expectPeek(TOKEN_UPDATE) || !p.expectPeek(TOKEN_SET) {
+		return nil
+	}
+	clause.DoUpdate = true
+	if clause.Assignments = p.parseAssignments(); clause.Assignments == nil {
+		return nil
+	}
+
+	var ok bool
+	if clause.Where, ok = p.parseWhere(); !ok {
+		return nil
+	}
+
+	return clause
+}
+
+func (p *Parser) parseMergeStatement() *MergeStatement {
+	stmt := &MergeStatement{}
+
+	if !p.expectPeek(TOKEN_INTO) || !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	stmt.TargetTable = p.curToken.Literal
+	var ok bool
+	if stmt.TargetAlias, ok = p.parseTableAlias(); !ok {
+		return nil
+	}
+
+	if !p.expectPeek(TOKEN_USING) || !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	stmt.SourceTable = p.curToken.Literal
+	if stmt.SourceAlias, ok = p.parseTableAlias(); !ok {
 		return nil
 	}
+
+	if !p.expectPeek(TOKEN_ON) {
+		return nil
+	}
+	p.nextToken()
+	if stmt.On = p.parseExpression(LOWEST); stmt.On == nil {
+		return nil
+	}
+
+	for p.peekTokenIs(TOKEN_WHEN) {
+		p.nextToken()
+		clause, ok := p.parseMergeClause()
+		if !ok {
+			return nil
+		}
+		stmt.Clauses = append(stmt.Clauses, clause)
+	}
+
+	if len(stmt.Clauses) == 0 {
+		p.addError("expected WHEN clause in MERGE")
+		return nil
+	}
+
+	return stmt
+}
+
+// parseTableAlias parses an optional [AS] alias after a table name. It
+// returns false only on a syntax error.
+func (p *Parser) parseTableAlias() (string, bool) {
+	if p.peekTokenIs(TOKEN_AS) {
+		p.nextToken()
+		if !p.expectPeek(TOKEN_IDENT) {
+			return "", false
+		}
+		return p.curToken.Literal, true
+	}
+	if p.peekTokenIs(TOKEN_IDENT) {
+		p.nextToken()
+		return p.curToken.Literal, true
+	}
+	return "", true
+}
+
+// parseMergeClause parses [NOT] MATCHED [AND condition] THEN action, with
+// the current token on WHEN.
+func (p *Parser) parseMergeClause() (MergeClause, bool) {
+	clause := MergeClause{Matched: true}
+
+	if p.peekTokenIs(TOKEN_NOT) {
+		p.nextToken()
+		clause.Matched = false
+	}
+	if !p.expectPeekKeyword("MATCHED") {
+		return clause, false
+	}
+
+	if p.peekTokenIs(TOKEN_AND) {
+		p.nextToken()
+		p.nextToken()
+		if clause.Condition = p.parseExpression(LOWEST); clause.Condition == nil {
+			return clause, false
+		}
+	}
+
+	if !p.expectPeek(TOKEN_THEN) {
+		return clause, false
+	}
+
+	switch {
+	case p.peekKeywordIs("DO"):
+		p.nextToken()
+		if !p.expectPeekKeyword("NOTHING") {
+			return clause, false
+		}
+		clause.Action = MergeDoNothing
+
+	case clause.Matched && p.peekTokenIs(TOKEN_UPDATE):
+		p.nextToken()
+		if !p.expectPeek(TOKEN_SET) {
+			return clause, false
+		}
+		clause.Action = MergeUpdate
+		if clause.Assignments = p.parseAssignments(); clause.Assignments == nil {
+			return clause, false
+		}
+
+	case clause.Matched && p.peekTokenIs(TOKEN_DELETE):
+		p.nextToken()
+		clause.Action = MergeDelete
+
+	case !clause.Matched && p.peekTokenIs(TOKEN_INSERT):
+		p.nextToken()
+		clause.Action = MergeInsert
+		if p.peekTokenIs(TOKEN_LPAREN) {
+			p.nextToken()
+			clause.Columns = p.parseIdentifierList()
+			if !p.expectPeek(TOKEN_RPAREN) {
+				return clause, false
+			}
+		}
+		if !p.expectPeek(TOKEN_VALUES) || !p.expectPeek(TOKEN_LPAREN) {
+			return clause, false
+		}
+		p.nextToken()
+		clause.Values = p.parseValueList()
+		if !p.expectPeek(TOKEN_RPAREN) {
+			return clause, false
+		}
+
+	default:
+		if clause.Matched {
+			p.addError(fmt.Sprintf("expected UPDATE, DELETE or DO NOTHING, got %s", p.peekToken.Literal))
+		} else {
+			p.addError(fmt.Sprintf("expected INSERT or DO NOTHING, got %s", p.peekToken.Literal))
+		}
+		return clause, false
+	}
+
+	return clause, true
+}
+
+func (p *Parser) parseUpdateStatement() *UpdateStatement {
+	stmt := &UpdateStatement{}
+
+	if !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	if !p.expectPeek(TOKEN_SET) {
+		return nil
+	}
+
+	stmt.Assignments = p.parseAssignments()
+	if stmt.Assignments == nil {
+		return nil
+	}
+
+	var ok bool
+	if stmt.Where, ok = p.parseWhere(); !ok {
+		return nil
+	}
+	if stmt.Returning, ok = p.parseReturning(); !ok {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseDeleteStatement() *DeleteStatement {
+	stmt := &DeleteStatement{}
+
+	if !p.expectPeek(TOKEN_FROM) || !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	var ok bool
+	if stmt.Where, ok = p.parseWhere(); !ok {
+		return nil
+	}
+	if stmt.Returning, ok = p.parseReturning(); !ok {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseWhere parses an optional WHERE clause. It returns false only on a
+// syntax error.
+func (p *Parser) parseWhere() (Expression, bool) {
+	if !p.peekTokenIs(TOKEN_WHERE) {
+		return nil, true
+	}
+	p.nextToken()
+	p.nextToken()
+	where := p.parseExpression(LOWEST)
+	return where, where != nil
+}
+
+// parseReturning parses an optional RETURNING list. It returns false only
+// on a syntax error.
+func (p *Parser) parseReturning() ([]SelectColumn, bool) {
+	if !p.peekTokenIs(TOKEN_RETURNING) {
+		return nil, true
+	}
 	p.nextToken()
+	p.nextToken()
+	columns := p.parseSelectColumns()
+	if len(columns) == 0 {
+		p.addError("expected expression after RETURNING")
+		return nil, false
+	}
+	return columns, true
+}
+
+// parseAssignments parses "col = expr, ..." after SET. The value may be
+// the DEFAULT keyword.
+func (p *Parser) parseAssignments() []Assignment {
+	var assignments []Assignment
+
+	for {
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		assignment := Assignment{Column: p.curToken.Literal}
+
+		if !p.expectPeek(TOKEN_EQ) {
+			return nil
+		}
+		p.nextToken()
+
+		if p.curTokenIs(TOKEN_DEFAULT) {
+			assignment.Value = &DefaultKeyword{}
+		} else {
+			assignment.Value = p.parseExpression(LOWEST)
+			if assignment.Value == nil {
+				return nil
+			}
+		}
+		assignments = append(assignments, assignment)
+
+		if !p.peekTokenIs(TOKEN_COMMA) {
+			return assignments
+		}
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parseCreateStatement() Statement {
+	if p.peekKeywordIs("SEQUENCE") {
+		return p.parseCreateSequence()
+	}
+	if p.peekKeywordIs("TYPE") {
+		return p.parseCreateType()
+	}
+	return p.parseCreateTable()
+}
+
+// parseCreateType parses CREATE TYPE [IF NOT EXISTS] name AS ENUM ('label', ...).
+func (p *Parser) parseCreateType() *CreateTypeStatement {
+	p.nextToken() // TYPE
+	stmt := &CreateTypeStatement{}
+	var ok bool
+	if stmt.IfNotExists, ok = p.parseIfExists(true); !ok {
+		return nil
+	}
+	if !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+	if !p.expectPeek(TOKEN_AS) || !p.expectPeekKeyword("ENUM") || !p.expectPeek(TOKEN_LPAREN) {
+		return nil
+	}
+	if p.peekTokenIs(TOKEN_RPAREN) {
+		p.nextToken()
+		return stmt
+	}
+	for {
+		if !p.expectPeek(TOKEN_STRING) {
+			return nil
+		}
+		stmt.Labels = append(stmt.Labels, p.curToken.Literal)
+		if !p.peekTokenIs(TOKEN_COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseCreateTable() *CreateTableStatement {
+	if !p.expectPeek(TOKEN_TABLE) {
+		return nil
+	}
 
 	stmt := &CreateTableStatement{}
 
+	var ok bool
+	if stmt.IfNotExists, ok = p.parseIfExists(true); !ok {
+		return nil
+	}
+	p.nextToken()
+
 	if !p.curTokenIs(TOKEN_IDENT) {
 		p.addError("expected table name")
 		return nil
@@ -174,7 +710,7 @@ func (p *Parser) parseCreateStatement() *CreateTableStatement {
 		return nil
 	}
 
-	stmt.Columns = p.parseColumnDefinitions()
+	stmt.Columns, stmt.Constraints = p.parseColumnDefinitions()
 
 	if !p.expectPeek(TOKEN_RPAREN) {
 		return nil
@@ -183,40 +719,349 @@ func (p *Parser) parseCreateStatement() *CreateTableStatement {
 	return stmt
 }
 
-func (p *Parser) parseDropStatement() *DropTableStatement {
+func (p *Parser) parseCreateSequence() *CreateSequenceStatement {
+	p.nextToken() // SEQUENCE
+	stmt := &CreateSequenceStatement{}
+	var ok bool
+	if stmt.IfNotExists, ok = p.parseIfExists(true); !ok {
+		return nil
+	}
+	if !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+	if !p.parseSequenceOptions(&stmt.Options) {
+		return nil
+	}
+	return stmt
+}
+
+// parseSequenceOptions parses any of INCREMENT [BY] n, START [WITH] n,
+// MINVALUE n and MAXVALUE n, in any order.
+func (p *Parser) parseSequenceOptions(opts *SequenceOptions) bool {
+	for {
+		var target **int64
+		switch {
+		case p.peekKeywordIs("INCREMENT"):
+			p.nextToken()
+			if p.peekTokenIs(TOKEN_BY) {
+				p.nextToken()
+			}
+			target = &opts.Increment
+		case p.peekKeywordIs("START"):
+			p.nextToken()
+			if p.peekKeywordIs("WITH") {
+				p.nextToken()
+			}
+			target = &opts.Start
+		case p.peekKeywordIs("MINVALUE"):
+			p.nextToken()
+			target = &opts.MinValue
+		case p.peekKeywordIs("MAXVALUE"):
+			p.nextToken()
+			target = &opts.MaxValue
+		default:
+			return true
+		}
+
+		if *target != nil {
+			p.addError(fmt.Sprintf("conflicting or redundant option %s", strings.ToUpper(p.curToken.Literal)))
+			return false
+		}
+		n, ok := p.parseSignedInteger()
+		if !ok {
+			return false
+		}
+		*target = &n
+	}
+}
+
+// parseSignedInteger parses an integer literal with an optional minus sign
+// following the current token.
+func (p *Parser) parseSignedInteger() (int64, bool) {
+	negative := false
+	if p.peekTokenIs(TOKEN_MINUS) {
+		p.nextToken()
+		negative = true
+	}
+	if !p.expectPeek(TOKEN_INT) {
+		return 0, false
+	}
+	literal := p.curToken.Literal
+	if negative {
+		literal = "-" + literal
+	}
+	n, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil {
+		p.addError(fmt.Sprintf("could not parse %q as integer", literal))
+		return 0, false
+	}
+	return n, true
+}
+
+// integerLiteral returns an integer literal, or a DECIMAL for one that
+// does not fit in an INT64, so that the full UINT64 range can be written.
+func (p *Parser) integerLiteral(literal string) Expression {
+	val, err := strconv.ParseInt(literal, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return &CastExpression{Expression: &StringLiteral{Value: literal}, DataType: "DECIMAL"}
+	}
+	if err != nil {
+		p.addError(fmt.Sprintf("could not parse %q as integer", literal))
+		return nil
+	}
+	return &IntegerLiteral{Value: val}
+}
+
+func (p *Parser) parseDropStatement() Statement {
+	if p.peekKeywordIs("SEQUENCE") {
+		p.nextToken()
+		stmt := &DropSequenceStatement{}
+		var ok bool
+		if stmt.IfExists, ok = p.parseIfExists(false); !ok {
+			return nil
+		}
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.Name = p.curToken.Literal
+		stmt.Cascade = p.parseDropBehavior()
+		return stmt
+	}
+	if p.peekKeywordIs("TYPE") {
+		p.nextToken()
+		stmt := &DropTypeStatement{}
+		var ok bool
+		if stmt.IfExists, ok = p.parseIfExists(false); !ok {
+			return nil
+		}
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.Name = p.curToken.Literal
+		stmt.Cascade = p.parseDropBehavior()
+		return stmt
+	}
+
 	if !p.expectPeek(TOKEN_TABLE) {
 		return nil
 	}
-	p.nextToken()
 
 	stmt := &DropTableStatement{}
 
+	var ok bool
+	if stmt.IfExists, ok = p.parseIfExists(false); !ok {
+		return nil
+	}
+	p.nextToken()
+
 	if !p.curTokenIs(TOKEN_IDENT) {
 		p.addError("expected table name")
 		return nil
 	}
 	stmt.TableName = p.curToken.Literal
+	stmt.Cascade = p.parseDropBehavior()
 
 	return stmt
 }
 
-func (p *Parser) parseColumnDefinitions() []ColumnDefinition {
-	var defs []ColumnDefinition
-
+// parseIfExists parses an optional IF EXISTS, or IF NOT EXISTS when not is
+// set. It reports whether the clause was present, and ok is false only on a
+// syntax error.
+func (p *Parser) parseIfExists(not bool) (present, ok bool) {
+	if !p.peekKeywordIs("IF") {
+		return false, true
+	}
 	p.nextToken()
+	if not && !p.expectPeek(TOKEN_NOT) {
+		return false, false
+	}
+	if !p.expectPeekKeyword("EXISTS") {
+		return false, false
+	}
+	return true, true
+}
 
-	for !p.curTokenIs(TOKEN_RPAREN) && !p.curTokenIs(TOKEN_EOF) {
-		def := ColumnDefinition{Nullable: true}
+// parseDropBehavior parses an optional CASCADE or RESTRICT and reports
+// whether CASCADE was given.
+func (p *Parser) parseDropBehavior() bool {
+	if p.peekKeywordIs("CASCADE") {
+		p.nextToken()
+		return true
+	}
+	p.skipKeyword("RESTRICT")
+	return false
+}
 
-		if !p.curTokenIs(TOKEN_IDENT) {
+// parseTruncateStatement parses TRUNCATE [TABLE] name [, ...]
+// [RESTART IDENTITY | CONTINUE IDENTITY].
+func (p *Parser) parseTruncateStatement() *TruncateStatement {
+	stmt := &TruncateStatement{}
+	if p.peekTokenIs(TOKEN_TABLE) {
+		p.nextToken()
+	}
+
+	for {
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.TableNames = append(stmt.TableNames, p.curToken.Literal)
+		if !p.peekTokenIs(TOKEN_COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	switch {
+	case p.peekKeywordIs("RESTART"):
+		p.nextToken()
+		if !p.expectPeekKeyword("IDENTITY") {
+			return nil
+		}
+		stmt.RestartIdentity = true
+	case p.peekKeywordIs("CONTINUE"):
+		p.nextToken()
+		if !p.expectPeekKeyword("IDENTITY") {
+			return nil
+		}
+	}
+
+	return stmt
+}
+
+// parseAlterStatement parses ALTER TABLE name followed by one of
+//
+//	ADD [COLUMN] name type [constraints]
+//	DROP [COLUMN] name
+//	RENAME [COLUMN] name TO new_name
+//	ALTER [COLUMN] name [SET DATA] TYPE type [COLLATE collation]
+//	RENAME TO new_name
+func (p *Parser) parseAlterStatement() *AlterTableStatement {
+	if !p.expectPeek(TOKEN_TABLE) || !p.expectPeek(TOKEN_IDENT) {
+		return nil
+	}
+	stmt := &AlterTableStatement{TableName: p.curToken.Literal}
+
+	switch {
+	case p.peekKeywordIs("ADD"):
+		p.nextToken()
+		p.skipKeyword("COLUMN")
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.Action = AlterAddColumn
+		stmt.Column = ColumnDefinition{Name: p.curToken.Literal, Nullable: true}
+		p.nextToken()
+		stmt.Column.DataType = p.parseDataType()
+		if !p.parseColumnConstraints(&stmt.Column) {
+			return nil
+		}
+
+	case p.peekTokenIs(TOKEN_DROP):
+		p.nextToken()
+		p.skipKeyword("COLUMN")
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.Action = AlterDropColumn
+		stmt.ColumnName = p.curToken.Literal
+
+	case p.peekKeywordIs("RENAME"):
+		p.nextToken()
+		if p.peekKeywordIs("TO") {
+			p.nextToken()
+			if !p.expectPeek(TOKEN_IDENT) {
+				return nil
+			}
+			stmt.Action = AlterRenameTable
+			stmt.NewName = p.curToken.Literal
 			break
 		}
-		def.Name = p.curToken.Literal
+		p.skipKeyword("COLUMN")
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.Action = AlterRenameColumn
+		stmt.ColumnName = p.curToken.Literal
+		if !p.expectPeekKeyword("TO") || !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.NewName = p.curToken.Literal
+
+	case p.peekTokenIs(TOKEN_ALTER):
+		p.nextToken()
+		p.skipKeyword("COLUMN")
+		if !p.expectPeek(TOKEN_IDENT) {
+			return nil
+		}
+		stmt.Action = AlterColumnType
+		stmt.ColumnName = p.curToken.Literal
+		if p.peekTokenIs(TOKEN_SET) {
+			p.nextToken()
+			if !p.expectPeekKeyword("DATA") {
+				return nil
+			}
+		}
+		if !p.expectPeekKeyword("TYPE") {
+			return nil
+		}
+		p.nextToken()
+		stmt.DataType = p.parseDataType()
+		if p.peekTokenIs(TOKEN_COLLATE) {
+			p.nextToken()
+			var ok bool
+			if stmt.Collation, ok = p.parseCollationName(); !ok {
+				return nil
+			}
+		}
+
+	default:
+		p.addError(fmt.Sprintf("expected ADD, DROP, RENAME or ALTER, got %s", p.peekToken.Literal))
+		return nil
+	}
+
+	return stmt
+}
 
+// skipKeyword consumes the next token if it is the given optional
+// non-reserved keyword.
+func (p *Parser) skipKeyword(keyword string) {
+	if p.peekKeywordIs(keyword) {
 		p.nextToken()
-		def.DataType = p.parseDataType()
+	}
+}
+
+func (p *Parser) parseColumnDefinitions() ([]ColumnDefinition, []TableConstraint) {
+	var defs []ColumnDefinition
+	var constraints []TableConstraint
+
+	p.nextToken()
+
+	for !p.curTokenIs(TOKEN_RPAREN) && !p.curTokenIs(TOKEN_EOF) {
+		switch p.curToken.Type {
+		case TOKEN_PRIMARY, TOKEN_UNIQUE, TOKEN_CONSTRAINT:
+			constraint, ok := p.parseTableConstraint()
+			if !ok {
+				return nil, nil
+			}
+			constraints = append(constraints, constraint)
+
+		case TOKEN_IDENT:
+			def := ColumnDefinition{Nullable: true}
+			def.Name = p.curToken.Literal
 
-		defs = append(defs, def)
+			p.nextToken()
+			def.DataType = p.parseDataType()
+
+			if !p.parseColumnConstraints(&def) {
+				return nil, nil
+			}
+
+			defs = append(defs, def)
+
+		default:
+			return defs, constraints
+		}
 
 		if p.peekTokenIs(TOKEN_COMMA) {
 			p.nextToken()
@@ -226,7 +1071,137 @@ func (p *Parser) parseColumnDefinitions() []ColumnDefinition {
 		}
 	}
 
-	return defs
+	return defs, constraints
+}
+
+// parseTableConstraint parses [CONSTRAINT name] PRIMARY KEY (cols) or
+// [CONSTRAINT name] UNIQUE (cols).
+func (p *Parser) parseTableConstraint() (TableConstraint, bool) {
+	var constraint TableConstraint
+
+	if p.curTokenIs(TOKEN_CONSTRAINT) {
+		if !p.expectPeek(TOKEN_IDENT) {
+			return constraint, false
+		}
+		constraint.Name = p.curToken.Literal
+		p.nextToken()
+	}
+
+	switch p.curToken.Type {
+	case TOKEN_PRIMARY:
+		if !p.expectPeekKeyword("KEY") {
+			return constraint, false
+		}
+		constraint.PrimaryKey = true
+	case TOKEN_UNIQUE:
+	default:
+		p.addError(fmt.Sprintf("expected PRIMARY KEY or UNIQUE, got %s", p.curToken.Literal))
+		return constraint, false
+	}
+
+	if !p.expectPeek(TOKEN_LPAREN) {
+		return constraint, false
+	}
+	constraint.Columns = p.parseIdentifierList()
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return constraint, false
+	}
+	if len(constraint.Columns) == 0 {
+		p.addError("expected column list in constraint")
+		return constraint, false
+	}
+
+	return constraint, true
+}
+
+// parseColumnConstraints parses the constraints that follow a column's data
+// type. On return the current token is the last token of the definition.
+func (p *Parser) parseColumnConstraints(def *ColumnDefinition) bool {
+	for {
+		switch p.peekToken.Type {
+		case TOKEN_NOT:
+			p.nextToken()
+			if !p.expectPeek(TOKEN_NULL) {
+				return false
+			}
+			def.Nullable = false
+		case TOKEN_NULL:
+			p.nextToken()
+			def.Nullable = true
+		case TOKEN_PRIMARY:
+			p.nextToken()
+			if !p.expectPeekKeyword("KEY") {
+				return false
+			}
+			def.PrimaryKey = true
+			def.Nullable = false
+		case TOKEN_UNIQUE:
+			p.nextToken()
+			def.Unique = true
+		case TOKEN_COLLATE:
+			p.nextToken()
+			var ok bool
+			if def.Collation, ok = p.parseCollationName(); !ok {
+				return false
+			}
+		case TOKEN_DEFAULT:
+			p.nextToken()
+			p.nextToken()
+			// Parse above NOT so that DEFAULT 0 NOT NULL works.
+			def.Default = p.parseExpression(LIKE)
+			if def.Default == nil {
+				return false
+			}
+		case TOKEN_IDENT:
+			if !p.peekKeywordIs("GENERATED") {
+				return true
+			}
+			p.nextToken()
+			if p.peekTokenIs(TOKEN_BY) {
+				p.nextToken()
+				if !p.expectPeek(TOKEN_DEFAULT) || !p.expectPeek(TOKEN_AS) || !p.expectPeekKeyword("IDENTITY") {
+					return false
+				}
+				def.Identity = "BY DEFAULT"
+				if !p.parseIdentityOptions(def) {
+					return false
+				}
+				continue
+			}
+			if !p.expectPeekKeyword("ALWAYS") || !p.expectPeek(TOKEN_AS) {
+				return false
+			}
+			if p.peekKeywordIs("IDENTITY") {
+				p.nextToken()
+				def.Identity = "ALWAYS"
+				if !p.parseIdentityOptions(def) {
+					return false
+				}
+				continue
+			}
+			if !p.expectPeek(TOKEN_LPAREN) {
+				return false
+			}
+			p.nextToken()
+			def.Generated = p.parseExpression(LOWEST)
+			if def.Generated == nil || !p.expectPeek(TOKEN_RPAREN) || !p.expectPeekKeyword("STORED") {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// parseIdentityOptions parses the optional parenthesized sequence options
+// after AS IDENTITY. Identity columns are implicitly NOT NULL.
+func (p *Parser) parseIdentityOptions(def *ColumnDefinition) bool {
+	def.Nullable = false
+	if !p.peekTokenIs(TOKEN_LPAREN) {
+		return true
+	}
+	p.nextToken()
+	return p.parseSequenceOptions(&def.IdentityOptions) && p.expectPeek(TOKEN_RPAREN)
 }
 
 func (p *Parser) parseDataType() string {
@@ -239,9 +1214,78 @@ func (p *Parser) parseDataType() string {
 		return "STRING"
 	case TOKEN_TYPE_BOOL:
 		return "BOOL"
-	default:
-		return strings.ToUpper(p.curToken.Literal)
 	}
+
+	name := strings.ToUpper(p.curToken.Literal)
+	if name == "TIMESTAMP" && (p.peekKeywordIs("WITH") || p.peekKeywordIs("WITHOUT")) {
+		p.nextToken()
+		if strings.EqualFold(p.curToken.Literal, "WITH") {
+			name = "TIMESTAMPTZ"
+		}
+		if !p.expectPeekKeyword("TIME") || !p.expectPeekKeyword("ZONE") {
+			return ""
+		}
+	}
+	if (name == "LIST" || name == "STRUCT") && p.peekTokenIs(TOKEN_LT) {
+		return p.parseNestedType(name)
+	}
+	if p.peekTokenIs(TOKEN_LPAREN) {
+		return p.parseTypeModifiers(name)
+	}
+	return name
+}
+
+// parseNestedType parses the element type of LIST<type> or the fields of
+// STRUCT<name type, ...> and returns the type in canonical form.
+func (p *Parser) parseNestedType(name string) string {
+	p.nextToken()
+	var parts []string
+	for {
+		p.nextToken()
+		field := ""
+		if name == "STRUCT" {
+			if !p.curTokenIs(TOKEN_IDENT) {
+				p.addError(fmt.Sprintf("expected field name, got %s", p.curToken.Literal))
+				return ""
+			}
+			field = p.curToken.Literal + " "
+			p.nextToken()
+		}
+		dataType := p.parseDataType()
+		if dataType == "" {
+			return ""
+		}
+		parts = append(parts, field+dataType)
+		if name == "LIST" || !p.peekTokenIs(TOKEN_COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(TOKEN_GT) {
+		return ""
+	}
+	return name + "<" + strings.Join(parts, ", ") + ">"
+}
+
+// parseTypeModifiers parses the parenthesized precision and scale after a
+// type name, as in DECIMAL(10,2), and appends them to the name.
+func (p *Parser) parseTypeModifiers(name string) string {
+	p.nextToken()
+	var mods []string
+	for {
+		if !p.expectPeek(TOKEN_INT) {
+			return ""
+		}
+		mods = append(mods, p.curToken.Literal)
+		if !p.peekTokenIs(TOKEN_COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(TOKEN_RPAREN) {
+		return ""
+	}
+	return name + "(" + strings.Join(mods, ",") + ")"
 }
 
 func (p *Parser) parseIdentifierList() []string {
@@ -269,7 +1313,11 @@ func (p *Parser) parseValueList() []Expression {
 	var exprs []Expression
 
 	for !p.curTokenIs(TOKEN_RPAREN) && !p.curTokenIs(TOKEN_EOF) {
-		exprs = append(exprs, p.parseLiteral())
+		if p.curTokenIs(TOKEN_DEFAULT) {
+			exprs = append(exprs, &DefaultKeyword{})
+		} else {
+			exprs = append(exprs, p.parseExpression(LOWEST))
+		}
 
 		if p.peekTokenIs(TOKEN_COMMA) {
 			p.nextToken()
@@ -285,12 +1333,7 @@ func (p *Parser) parseValueList() []Expression {
 func (p *Parser) parseLiteral() Expression {
 	switch p.curToken.Type {
 	case TOKEN_INT:
-		val, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
-		if err != nil {
-			p.addError(fmt.Sprintf("could not parse %q as integer", p.curToken.Literal))
-			return nil
-		}
-		return &IntegerLiteral{Value: val}
+		return p.integerLiteral(p.curToken.Literal)
 
 	case TOKEN_FLOAT:
 		val, err := strconv.ParseFloat(p.curToken.Literal, 64)
@@ -303,6 +1346,14 @@ func (p *Parser) parseLiteral() Expression {
 	case TOKEN_STRING:
 		return &StringLiteral{Value: p.curToken.Literal}
 
+	case TOKEN_BYTES:
+		val, err := hex.DecodeString(p.curToken.Literal)
+		if err != nil {
+			p.addError(fmt.Sprintf("invalid hexadecimal literal X'%s'", p.curToken.Literal))
+			return nil
+		}
+		return &BytesLiteral{Value: val}
+
 	case TOKEN_TRUE:
 		return &BoolLiteral{Value: true}
 
@@ -0,0 +1,55 @@
+package parser
+
+// Inspect traverses an expression tree in depth-first order. It calls f for
+// each expression; if f returns false, the children of that expression are
+// not visited.
+func Inspect(expr Expression, f func(Expression) bool) {
+	if expr == nil || !f(expr) {
+		return
+	}
+
+	switch e := expr.(type) {
+	case *PrefixExpression:
+		Inspect(e.Right, f)
+	case *InfixExpression:
+		Inspect(e.Left, f)
+		Inspect(e.Right, f)
+	case *IsNullExpression:
+		Inspect(e.Expression, f)
+	case *IsDistinctFromExpression:
+		Inspect(e.Left, f)
+		Inspect(e.Right, f)
+	case *LikeExpression:
+		Inspect(e.Expression, f)
+		Inspect(e.Pattern, f)
+		Inspect(e.Escape, f)
+	case *CastExpression:
+		Inspect(e.Expression, f)
+	case *CollateExpression:
+		Inspect(e.Expression, f)
+	case *CaseExpression:
+		Inspect(e.Operand, f)
+		for _, w := range e.Whens {
+			Inspect(w.Condition, f)
+			Inspect(w.Result, f)
+		}
+		Inspect(e.Else, f)
+	case *FunctionCall:
+		for _, arg := range e.Arguments {
+			Inspect(arg, f)
+		}
+	case *ListLiteral:
+		for _, elem := range e.Elements {
+			Inspect(elem, f)
+		}
+	case *StructLiteral:
+		for _, value := range e.Values {
+			Inspect(value, f)
+		}
+	case *IndexExpression:
+		Inspect(e.Expression, f)
+		Inspect(e.Index, f)
+	case *FieldAccess:
+		Inspect(e.Expression, f)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,16 +24,20 @@ type Shell struct {
 	dataDir  string
 	in       io.Reader
 	out      io.Writer
+	// maxColumnWidth is the display width text cells of results are truncated
+	// to, or zero for no limit.
+	maxColumnWidth int
 }
 
 // New creates a new Shell instance.
 func New(catalog *storage.Catalog, exec *executor.Executor, dataDir string) *Shell {
 	return &Shell{
-		catalog:  catalog,
-		executor: exec,
-		dataDir:  dataDir,
-		in:       os.Stdin,
-		out:      os.Stdout,
+		catalog:        catalog,
+		executor:       exec,
+		dataDir:        dataDir,
+		in:             os.Stdin,
+		out:            os.Stdout,
+		maxColumnWidth: executor.DefaultMaxColumnWidth,
 	}
 }
 
@@ -100,6 +105,11 @@ func (s *Shell) handleCommand(input string) bool {
 		fmt.Fprint(s.out, "\033[H\033[2J")
 		return true
 
+	case lower == "width" || lower == "\\w" || strings.HasPrefix(lower, "width ") || strings.HasPrefix(lower, "\\w "):
+		_, arg, _ := strings.Cut(input, " ")
+		s.setMaxColumnWidth(strings.TrimSpace(arg))
+		return true
+
 	case strings.HasPrefix(lower, "describe ") || strings.HasPrefix(lower, "\\d "):
 		var tableName string
 		if strings.HasPrefix(lower, "describe ") {
@@ -121,21 +131,75 @@ Available Commands:
   exit, \q           - Exit the program
   tables, \dt        - List all tables
   describe <table>   - Show table schema
+  width, \w [n]      - Show or set the maximum column width (0 for no limit)
   clear, \c          - Clear the screen
 
 SQL Commands:
-  CREATE TABLE table_name (col1 TYPE, col2 TYPE, ...)
+  CREATE TABLE [IF NOT EXISTS] table_name (col1 TYPE [NOT NULL], col2 TYPE, ...)
+  CREATE TABLE table_name (col1 TYPE PRIMARY KEY, col2 TYPE UNIQUE, UNIQUE (col1, col2))
+  CREATE TABLE table_name (id INT64 GENERATED [ALWAYS | BY DEFAULT] AS IDENTITY, ...)
+  CREATE TABLE table_name (col1 STRING COLLATE binary | nocase | unicode, ...)
+  CREATE SEQUENCE [IF NOT EXISTS] seq_name [START WITH n] [INCREMENT BY n] [MINVALUE n] [MAXVALUE n]
+  SELECT NEXTVAL('seq_name'), CURRVAL('seq_name'), SETVAL('seq_name', n) FROM table_name
+  DROP SEQUENCE [IF EXISTS] seq_name [CASCADE | RESTRICT]
+  CREATE TYPE [IF NOT EXISTS] type_name AS ENUM ('label1', 'label2', ...)
+  DROP TYPE [IF EXISTS] type_name [CASCADE | RESTRICT]
   INSERT INTO table_name VALUES (val1, val2, ...)
   INSERT INTO table_name (col1, col2) VALUES (val1, val2)
   SELECT col1, col2 FROM table_name
   SELECT * FROM table_name
-  DROP TABLE table_name
+  SELECT col1 * col2 AS total FROM table_name ORDER BY total
+  SELECT * FROM table_name WHERE col1 IS NOT NULL AND col2 > 10
+  SELECT * FROM table_name WHERE col1 LIKE 'abc%' OR col1 ~ '^[0-9]+$'
+  SELECT COALESCE(col1, 'n/a'), CASE WHEN col2 > 0 THEN 'pos' ELSE 'neg' END FROM table_name
+  SELECT DISTINCT col1 FROM table_name ORDER BY col1 [ASC|DESC]
+  SELECT DISTINCT ON (col1) * FROM table_name ORDER BY col1, col2 DESC
+  SELECT * FROM table_name WHERE col1 = 'abc' COLLATE nocase ORDER BY col1 COLLATE unicode
+  SELECT "Quoted Name", E'tab\there' FROM "Mixed Case Table" /* comment */
+  SELECT * FROM table_name WHERE ts >= NOW() - INTERVAL '1 day'
+  SELECT DATE_TRUNC('month', ts), EXTRACT(YEAR FROM ts), STRFTIME(ts, '%Y/%m/%d') FROM table_name
+  SELECT COUNT(*), SUM(col1), AVG(col1), MIN(col1), MAX(col1) FROM table_name WHERE condition
+  SELECT ROUND(col1, 2, 'half_even') FROM table_name
+  SELECT ENCODE(col1, 'hex'), DECODE('3q2+7w==', 'base64') FROM table_name WHERE col1 = X'DEADBEEF'
+  SELECT doc -> 'a' ->> 'b', JSON_EXTRACT(doc, '$.a.b[0]'), JSON_ARRAY_LENGTH(doc -> 'c'), JSON_KEYS(doc) FROM table_name
+  SELECT SUM(CAST(doc -> 'n' AS FLOAT64)) FROM table_name WHERE (doc ->> 'kind') = 'click'
+  SELECT tags[1], addr.city, [1, 2, 3], {'a': 1, 'b': 'x'} FROM table_name
+  SELECT id, tag FROM table_name, UNNEST(tags) [AS] tag
+  SELECT x FROM UNNEST([1, 2, 3]) x
+  INSERT INTO table_name VALUES (...) ON CONFLICT (col1) DO NOTHING
+  INSERT INTO table_name VALUES (...) ON CONFLICT (col1) DO UPDATE SET col2 = excluded.col2
+  UPDATE table_name SET col1 = expr, col2 = DEFAULT WHERE condition
+  DELETE FROM table_name WHERE condition
+  INSERT / UPDATE / DELETE ... RETURNING * | expr [AS alias], ...
+  MERGE INTO target t USING source s ON t.id = s.id
+    WHEN MATCHED THEN UPDATE SET col1 = s.col1 | DELETE
+    WHEN NOT MATCHED THEN INSERT VALUES (s.id, s.col1)
+  ALTER TABLE table_name ADD [COLUMN] col TYPE [NOT NULL] [DEFAULT expr]
+  ALTER TABLE table_name DROP [COLUMN] col
+  ALTER TABLE table_name RENAME [COLUMN] col TO new_col
+  ALTER TABLE table_name ALTER [COLUMN] col [SET DATA] TYPE TYPE [COLLATE collation]
+  ALTER TABLE table_name RENAME TO new_name
+  TRUNCATE [TABLE] table_name [, ...] [RESTART IDENTITY | CONTINUE IDENTITY]
+  DROP TABLE [IF EXISTS] table_name [CASCADE | RESTRICT]
 
 Supported Data Types:
-  INT64    - 64-bit integer
-  FLOAT64  - 64-bit floating point
+  INT64    - 64-bit integer (INT/INTEGER, BIGINT)
+  INT8, INT16, INT32 - Narrow signed integers (TINYINT, SMALLINT, INT4)
+  UINT8, UINT16, UINT32, UINT64 - Unsigned integers
+  FLOAT64  - 64-bit floating point (FLOAT, DOUBLE, REAL)
+  FLOAT32  - 32-bit floating point (FLOAT4)
   STRING   - Variable-length string
   BOOL     - Boolean (TRUE/FALSE)
+  DATE     - Calendar date ('2026-01-31')
+  TIMESTAMP [WITH TIME ZONE] - Date and time (TIMESTAMPTZ is shown in UTC)
+  INTERVAL - Span of time ('1 day 02:00:00', '90 minutes')
+  DECIMAL[(p[,s])] - Exact fixed-point number, up to 38 digits (NUMERIC; default 18,3)
+  BYTES    - Binary data (BLOB, BYTEA), written X'DEADBEEF' and shown as \xdeadbeef
+  JSON     - JSON document, validated on insert and stored compactly (JSONB)
+  LIST<T>  - List of values of type T, indexed from 1 (list[1])
+  STRUCT<a T, b U> - Named fields, read with struct.a
+  <enum>   - Type created with CREATE TYPE ... AS ENUM, ordered by label position
+  UUID     - 128-bit identifier, written '<hex>' and generated with GEN_RANDOM_UUID()
 
 Examples:
   CREATE TABLE users (id INT64, name STRING, active BOOL);
@@ -184,11 +248,52 @@ func (s *Shell) describeTable(tableName string) {
 		if !col.Nullable {
 			props = append(props, "NOT NULL")
 		}
-		fmt.Fprintf(s.out, "%-20s %-15s %s\n", col.Name, col.Type.String(), strings.Join(props, ", "))
+		if col.Collation != storage.CollationBinary {
+			props = append(props, "COLLATE "+col.Collation.String())
+		}
+		if col.Default != "" {
+			props = append(props, "DEFAULT "+col.Default)
+		}
+		if col.Generated != "" {
+			props = append(props, "GENERATED ALWAYS AS ("+col.Generated+") STORED")
+		}
+		if col.Identity != "" {
+			props = append(props, "GENERATED "+col.Identity+" AS IDENTITY")
+		}
+		fmt.Fprintf(s.out, "%-20s %-15s %s\n", col.Name, col.TypeName(), strings.Join(props, ", "))
+	}
+
+	if len(schema.UniqueKeys) > 0 {
+		fmt.Fprintln(s.out, "Constraints:")
+		for _, uc := range schema.UniqueKeys {
+			kind := "UNIQUE"
+			if uc.Primary {
+				kind = "PRIMARY KEY"
+			}
+			fmt.Fprintf(s.out, "  %s %s (%s)\n", uc.Name, kind, strings.Join(uc.Columns, ", "))
+		}
 	}
 	fmt.Fprintln(s.out)
 }
 
+// setMaxColumnWidth sets the maximum column width from the argument of the
+// width command, or shows it if there is none.
+func (s *Shell) setMaxColumnWidth(arg string) {
+	if arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			fmt.Fprintf(s.out, "Invalid width %q: expected a number of columns, or 0 for no limit.\n", arg)
+			return
+		}
+		s.maxColumnWidth = n
+	}
+	if s.maxColumnWidth == 0 {
+		fmt.Fprintln(s.out, "Maximum column width: unlimited")
+		return
+	}
+	fmt.Fprintf(s.out, "Maximum column width: %d\n", s.maxColumnWidth)
+}
+
 func (s *Shell) executeSQL(sql string) {
 	start := time.Now()
 
@@ -222,6 +327,7 @@ func (s *Shell) executeSQL(sql string) {
 	}
 
 	if result.RowCount() > 0 || len(result.Columns) > 0 {
+		result.MaxColumnWidth = s.maxColumnWidth
 		fmt.Fprintln(s.out, result.String())
 	}
 
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// checkName rejects a table, column or constraint name that cannot be part
+// of a file name. Only quoted identifiers can contain such characters.
+func checkName(kind, name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\\x00") {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+func columnPath(tableDir, column string) string {
+	return filepath.Join(tableDir, fmt.Sprintf("col_%s.dat", column))
+}
+
+// AddColumn appends a column to the table, with one value for each
+// existing row. Only the new column file and the metadata are written.
+func (t *Table) AddColumn(col ColumnDef, values []Value) error {
+	if err := checkName("column", col.Name); err != nil {
+		return err
+	}
+	if _, exists := t.Schema.GetColumn(col.Name); exists {
+		return fmt.Errorf("column %q of table %q already exists", col.Name, t.Schema.Name)
+	}
+	if uint64(len(values)) != t.RowCount() {
+		return fmt.Errorf("expected %d values for new column, got %d", t.RowCount(), len(values))
+	}
+
+	cf := newColumnFile(columnPath(t.dataDir, col.Name), col)
+	for _, v := range values {
+		if v.IsNull && !col.Nullable {
+			return fmt.Errorf("column %q of table %q contains null values", col.Name, t.Schema.Name)
+		}
+		if err := cf.AppendValue(v); err != nil {
+			return fmt.Errorf("column %q: %w", col.Name, err)
+		}
+	}
+
+	if err := cf.Save(); err != nil {
+		return fmt.Errorf("failed to save column %q: %w", col.Name, err)
+	}
+
+	t.Schema.AddColumnDef(col)
+	t.Columns[col.Name] = cf
+	return t.saveMetadata()
+}
+
+// DropColumn removes a column and any unique constraints that include it.
+func (t *Table) DropColumn(name string) error {
+	idx := t.Schema.GetColumnIndex(name)
+	if idx == -1 {
+		return fmt.Errorf("column %q of table %q does not exist", name, t.Schema.Name)
+	}
+	if len(t.Schema.Columns) == 1 {
+		return fmt.Errorf("cannot drop the only column of table %q", t.Schema.Name)
+	}
+
+	t.Schema.Columns = slices.Delete(t.Schema.Columns, idx, idx+1)
+	for i := range t.Schema.Columns {
+		t.Schema.Columns[i].Position = i
+	}
+
+	var kept []UniqueConstraint
+	for _, uc := range t.Schema.UniqueKeys {
+		if slices.Contains(uc.Columns, name) {
+			_ = os.Remove(filepath.Join(t.dataDir, fmt.Sprintf("idx_%s.dat", uc.Name)))
+			continue
+		}
+		kept = append(kept, uc)
+	}
+	t.Schema.UniqueKeys = kept
+
+	delete(t.Columns, name)
+	if err := os.Remove(columnPath(t.dataDir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove column file: %w", err)
+	}
+
+	if err := t.openIndexes(); err != nil {
+		return err
+	}
+	return t.saveMetadata()
+}
+
+// RenameColumn renames a column and its column file.
+func (t *Table) RenameColumn(oldName, newName string) error {
+	if err := checkName("column", newName); err != nil {
+		return err
+	}
+	col, ok := t.Schema.GetColumn(oldName)
+	if !ok {
+		return fmt.Errorf("column %q of table %q does not exist", oldName, t.Schema.Name)
+	}
+	if _, exists := t.Schema.GetColumn(newName); exists {
+		return fmt.Errorf("column %q of table %q already exists", newName, t.Schema.Name)
+	}
+
+	cf := t.Columns[oldName]
+	newPath := columnPath(t.dataDir, newName)
+	if err := cf.Save(); err != nil {
+		return fmt.Errorf("failed to save column %q: %w", oldName, err)
+	}
+	if err := os.Rename(cf.path, newPath); err != nil {
+		return fmt.Errorf("failed to rename column file: %w", err)
+	}
+	cf.path = newPath
+
+	col.Name = newName
+	delete(t.Columns, oldName)
+	t.Columns[newName] = cf
+
+	for i := range t.Schema.UniqueKeys {
+		uc := &t.Schema.UniqueKeys[i]
+		for j, name := range uc.Columns {
+			if name == oldName {
+				uc.Columns[j] = newName
+			}
+		}
+	}
+
+	if err := t.buildIndexes(); err != nil {
+		return err
+	}
+	if err := t.saveIndexes(); err != nil {
+		return err
+	}
+	return t.saveMetadata()
+}
+
+// SetColumnType replaces the values of a column with values of a new type,
+// one per row. The type is taken from the Type, Precision, Scale, Children,
+// Enum and Collation of def. Unique constraints are checked against the new values.
+func (t *Table) SetColumnType(name string, def ColumnDef, values []Value) error {
+	col, ok := t.Schema.GetColumn(name)
+	if !ok {
+		return fmt.Errorf("column %q of table %q does not exist", name, t.Schema.Name)
+	}
+	if uint64(len(values)) != t.RowCount() {
+		return fmt.Errorf("expected %d values for column %q, got %d", t.RowCount(), name, len(values))
+	}
+
+	old := t.Columns[name]
+	oldCol := *col
+	col.Type, col.Precision, col.Scale, col.Children, col.Enum = def.Type, def.Precision, def.Scale, def.Children, def.Enum
+	col.Collation = def.Collation
+	cf := newColumnFile(old.path, *col)
+	for _, v := range values {
+		if err := cf.AppendValue(v); err != nil {
+			*col = oldCol
+			return fmt.Errorf("column %q: %w", name, err)
+		}
+	}
+
+	t.Columns[name] = cf
+	if err := t.buildIndexes(); err != nil {
+		*col = oldCol
+		t.Columns[name] = old
+		_ = t.buildIndexes()
+		return err
+	}
+
+	if err := cf.Save(); err != nil {
+		return fmt.Errorf("failed to save column %q: %w", name, err)
+	}
+	if err := t.saveIndexes(); err != nil {
+		return err
+	}
+	return t.saveMetadata()
+}
+
+// Rename renames the table and moves its directory.
+func (t *Table) Rename(newName string) error {
+	if err := checkName("table", newName); err != nil {
+		return err
+	}
+	newDir := filepath.Join(filepath.Dir(t.dataDir), newName)
+	if _, err := os.Stat(newDir); err == nil {
+		return fmt.Errorf("table directory %q already exists", newDir)
+	}
+	if err := os.Rename(t.dataDir, newDir); err != nil {
+		return fmt.Errorf("failed to rename table directory: %w", err)
+	}
+
+	t.dataDir = newDir
+	t.Schema.Name = newName
+	for name, cf := range t.Columns {
+		cf.path = columnPath(newDir, name)
+	}
+	if err := t.openIndexes(); err != nil {
+		return err
+	}
+	return t.saveMetadata()
+}
+
+// SaveSchema writes the table metadata without touching the data files.
+func (t *Table) SaveSchema() error {
+	return t.saveMetadata()
+}
+
+// saveIndexes writes the index files to disk.
+func (t *Table) saveIndexes() error {
+	for _, idx := range t.indexes {
+		if err := idx.Save(); err != nil {
+			return fmt.Errorf("failed to save index %q: %w", idx.constraint.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package storage
+
+// NewBytesValue creates a BYTES value. The value keeps b, which must not
+// be modified afterwards.
+func NewBytesValue(b []byte) Value {
+	if b == nil {
+		b = []byte{}
+	}
+	return Value{Type: TypeBytes, data: b}
+}
+
+// AsBytes returns a BYTES value. The result must not be modified.
+func (v Value) AsBytes() ([]byte, bool) {
+	if v.Type != TypeBytes || v.IsNull {
+		return nil, false
+	}
+	return v.data.([]byte), true
+}
@@ -14,12 +14,48 @@ type ColumnDef struct {
 	Type     DataType `json:"type"`
 	Nullable bool     `json:"nullable"`
 	Position int      `json:"position"`
+	// Precision and Scale are the total and fractional digits of a DECIMAL.
+	Precision int `json:"precision,omitempty"`
+	Scale     int `json:"scale,omitempty"`
+	// Default is the SQL text of the DEFAULT expression, if any.
+	Default string `json:"default,omitempty"`
+	// Generated is the SQL text of a GENERATED ALWAYS AS expression, if any.
+	Generated string `json:"generated,omitempty"`
+	// Identity is "ALWAYS" or "BY DEFAULT" for identity columns, which take
+	// their values from the sequence named by Sequence.
+	Identity string `json:"identity,omitempty"`
+	Sequence string `json:"sequence,omitempty"`
+	// Children are the element of a LIST, named "element", or the fields
+	// of a STRUCT.
+	Children []ColumnDef `json:"children,omitempty"`
+	// Enum is the type of an ENUM column. The labels are copied from the
+	// catalog so that the column can be read on its own.
+	Enum *EnumType `json:"enum,omitempty"`
+	// Collation is the collation of a STRING column.
+	Collation Collation `json:"collation,omitempty"`
+}
+
+// TypeName returns the column type as written in SQL, with the precision
+// and scale of a DECIMAL and the element or field types of a nested type.
+func (c ColumnDef) TypeName() string {
+	switch c.Type {
+	case TypeDecimal:
+		return fmt.Sprintf("DECIMAL(%d,%d)", c.Precision, c.Scale)
+	case TypeList, TypeStruct:
+		return nestedTypeName(c)
+	case TypeEnum:
+		return c.Enum.Name
+	default:
+		return c.Type.String()
+	}
 }
 
 // TableSchema represents the schema of a table.
 type TableSchema struct {
 	Name    string      `json:"name"`
 	Columns []ColumnDef `json:"columns"`
+	// UniqueKeys lists the PRIMARY KEY and UNIQUE constraints.
+	UniqueKeys []UniqueConstraint `json:"unique_keys,omitempty"`
 }
 
 // NewTableSchema creates a new table schema.
@@ -32,15 +68,26 @@ func NewTableSchema(name string) *TableSchema {
 
 // AddColumn adds a column to the schema.
 func (s *TableSchema) AddColumn(name string, dataType DataType, nullable bool) {
-	col := ColumnDef{
-		Name:     name,
-		Type:     dataType,
-		Nullable: nullable,
-		Position: len(s.Columns),
-	}
+	s.AddColumnDef(ColumnDef{Name: name, Type: dataType, Nullable: nullable})
+}
+
+// AddColumnDef adds a fully specified column to the schema. The position is
+// assigned from the column's place in the schema.
+func (s *TableSchema) AddColumnDef(col ColumnDef) {
+	col.Position = len(s.Columns)
 	s.Columns = append(s.Columns, col)
 }
 
+// PrimaryKey returns the primary key constraint, if the table has one.
+func (s *TableSchema) PrimaryKey() (*UniqueConstraint, bool) {
+	for i := range s.UniqueKeys {
+		if s.UniqueKeys[i].Primary {
+			return &s.UniqueKeys[i], true
+		}
+	}
+	return nil, false
+}
+
 // GetColumn returns a column definition by name.
 func (s *TableSchema) GetColumn(name string) (*ColumnDef, bool) {
 	for i := range s.Columns {
@@ -72,16 +119,19 @@ func (s *TableSchema) ColumnNames() []string {
 
 // Catalog manages database metadata.
 type Catalog struct {
-	Tables  map[string]*TableSchema `json:"tables"`
-	dataDir string
-	mu      sync.RWMutex
+	Tables    map[string]*TableSchema `json:"tables"`
+	Sequences map[string]*Sequence    `json:"sequences,omitempty"`
+	Types     map[string]*EnumType    `json:"types,omitempty"`
+	dataDir   string
+	mu        sync.RWMutex
 }
 
 // NewCatalog creates a new catalog.
 func NewCatalog(dataDir string) (*Catalog, error) {
 	c := &Catalog{
-		Tables:  make(map[string]*TableSchema),
-		dataDir: dataDir,
+		Tables:    make(map[string]*TableSchema),
+		Sequences: make(map[string]*Sequence),
+		dataDir:   dataDir,
 	}
 
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
@@ -114,7 +164,7 @@ func (c *Catalog) RegisterTable(schema *TableSchema) error {
 	return nil
 }
 
-// DropTable removes a table from the catalog.
+// DropTable removes a table and the sequences it owns from the catalog.
 func (c *Catalog) DropTable(name string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -124,8 +174,44 @@ func (c *Catalog) DropTable(name string) error {
 	}
 
 	delete(c.Tables, name)
+	for seqName, seq := range c.Sequences {
+		if seq.OwnedBy == name {
+			delete(c.Sequences, seqName)
+		}
+	}
+
+	if err := c.save(); err != nil {
+		return fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceTable stores the altered schema of the table registered as
+// oldName. If the table was renamed, the sequences it owns follow it.
+func (c *Catalog) ReplaceTable(oldName string, schema *TableSchema) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old, exists := c.Tables[oldName]
+	if !exists {
+		return fmt.Errorf("table %q does not exist", oldName)
+	}
+	if _, taken := c.Tables[schema.Name]; taken && schema.Name != oldName {
+		return fmt.Errorf("table %q already exists", schema.Name)
+	}
+
+	delete(c.Tables, oldName)
+	c.Tables[schema.Name] = schema
+	for _, seq := range c.Sequences {
+		if seq.OwnedBy == oldName {
+			seq.OwnedBy = schema.Name
+		}
+	}
 
 	if err := c.save(); err != nil {
+		delete(c.Tables, schema.Name)
+		c.Tables[oldName] = old
 		return fmt.Errorf("failed to save catalog: %w", err)
 	}
 
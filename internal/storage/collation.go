@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Collation determines how STRING values are compared, sorted and grouped.
+type Collation uint8
+
+const (
+	// CollationBinary compares strings byte by byte, which orders them by
+	// code point.
+	CollationBinary Collation = iota
+	// CollationNoCase compares strings as if they were in lower case.
+	CollationNoCase
+	// CollationUnicode compares strings by a simplified form of the
+	// Unicode root collation: first by base letters, then by accents, then
+	// by case and by the difference between katakana and hiragana and
+	// between full-width and ASCII forms. Strings that differ in none of
+	// these are equal.
+	CollationUnicode
+)
+
+var collationNames = []string{
+	CollationBinary:  "binary",
+	CollationNoCase:  "nocase",
+	CollationUnicode: "unicode",
+}
+
+// ParseCollation returns the collation with the given name, ignoring case.
+// Besides the names returned by String it accepts "C", "case_insensitive"
+// and "und", the root locale.
+func ParseCollation(name string) (Collation, bool) {
+	switch strings.ToLower(name) {
+	case "binary", "c":
+		return CollationBinary, true
+	case "nocase", "case_insensitive":
+		return CollationNoCase, true
+	case "unicode", "und":
+		return CollationUnicode, true
+	}
+	return CollationBinary, false
+}
+
+func (c Collation) String() string {
+	return collationNames[c]
+}
+
+// MarshalText stores a collation in the catalog by name.
+func (c Collation) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText reads a collation name written by MarshalText.
+func (c *Collation) UnmarshalText(text []byte) error {
+	collation, ok := ParseCollation(string(text))
+	if !ok {
+		return fmt.Errorf("unknown collation %q", text)
+	}
+	*c = collation
+	return nil
+}
+
+// Collation returns the collation a STRING value is compared by.
+func (v Value) Collation() Collation {
+	return v.collation
+}
+
+// WithCollation returns the value compared by collation c. Values that are
+// not STRING are returned unchanged.
+func (v Value) WithCollation(c Collation) Value {
+	if v.Type == TypeString {
+		v.collation = c
+	}
+	return v
+}
+
+// compareStrings orders two STRING values by the collation of the first,
+// or of the second if the first is binary.
+func compareStrings(v, other Value) int {
+	c := v.collation
+	if c == CollationBinary {
+		c = other.collation
+	}
+	a, b := v.data.(string), other.data.(string)
+	if c == CollationBinary {
+		return strings.Compare(a, b)
+	}
+	return bytes.Compare(c.key(a), c.key(b))
+}
+
+// key returns a sort key for s: two strings compare equal under the
+// collation exactly when their keys are equal, and in the order of their
+// keys otherwise.
+func (c Collation) key(s string) []byte {
+	switch c {
+	case CollationNoCase:
+		key := make([]byte, 0, len(s))
+		for _, r := range s {
+			key = utf8.AppendRune(key, unicode.ToLower(r))
+		}
+		return key
+	case CollationUnicode:
+		return unicodeKey(s)
+	default:
+		return []byte(s)
+	}
+}
+
+// collationElement holds the weights of a character at the three levels of
+// the Unicode collation. A combining mark has no primary weight.
+type collationElement struct {
+	primary   uint32
+	secondary uint16
+	tertiary  uint8
+}
+
+// Tertiary weights. A character's weight is the sum of those that apply.
+const (
+	tertiaryCommon  = 1
+	tertiaryUpper   = 1 // upper or title case
+	tertiaryVariant = 2 // katakana, full-width form or ligature
+)
+
+// unicodeKey builds the key of s under CollationUnicode: the primary
+// weights of its characters, then the secondary weights, then the
+// tertiary weights, each level ended by zero bytes.
+func unicodeKey(s string) []byte {
+	var elems []collationElement
+	for _, r := range s {
+		elems = appendCollationElements(elems, r, false)
+	}
+
+	key := make([]byte, 0, len(elems)*6+3)
+	for _, e := range elems {
+		if e.primary != 0 {
+			key = append(key, byte(e.primary>>16), byte(e.primary>>8), byte(e.primary))
+		}
+	}
+	key = append(key, 0)
+	for _, e := range elems {
+		key = append(key, byte(e.secondary>>8), byte(e.secondary))
+	}
+	key = append(key, 0, 0)
+	for _, e := range elems {
+		key = append(key, e.tertiary)
+	}
+	return key
+}
+
+// appendCollationElements appends the elements of r. Accented letters are
+// decomposed into a base letter and combining marks, so that accents only
+// matter at the secondary level. Katakana are weighted as the matching
+// hiragana and full-width characters as their ASCII forms, with a tertiary
+// difference. The primary weight orders spaces, punctuation and symbols
+// before digits and digits before letters, and otherwise follows code
+// points, which keeps the letters of each script together.
+func appendCollationElements(elems []collationElement, r rune, variant bool) []collationElement {
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E:
+		r, variant = r-0xFEE0, true
+	case r >= 0x30A1 && r <= 0x30F6:
+		r, variant = r-0x60, true
+	}
+	if d, ok := collationDecompositions[r]; ok {
+		for _, dr := range d {
+			elems = appendCollationElements(elems, dr, variant)
+		}
+		return elems
+	}
+	if e, ok := collationExpansions[r]; ok {
+		for _, er := range e {
+			elems = appendCollationElements(elems, er, true)
+		}
+		return elems
+	}
+	if unicode.Is(unicode.Mn, r) {
+		return append(elems, collationElement{secondary: uint16(r), tertiary: tertiaryCommon})
+	}
+
+	lower := unicode.ToLower(r)
+	var group uint32
+	switch {
+	case unicode.IsDigit(lower):
+		group = 2
+	case unicode.IsLetter(lower) || unicode.IsMark(lower):
+		group = 3
+	default:
+		group = 1
+	}
+	tertiary := uint8(tertiaryCommon)
+	if lower != r {
+		tertiary += tertiaryUpper
+	}
+	if variant {
+		tertiary += tertiaryVariant
+	}
+	return append(elems, collationElement{primary: group<<21 | uint32(lower), secondary: 1, tertiary: tertiary})
+}
+
+// collationExpansions lists characters weighted as a sequence of letters.
+var collationExpansions = map[rune]string{
+	'ß': "ss", 'ẞ': "SS", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE",
+}
+
+// collationDecompositions maps accented Latin letters and voiced kana to
+// their canonical decompositions. Letters with a stroke, which have none,
+// are mapped to the base letter and a combining overlay.
+var collationDecompositions = map[rune]string{
+	'À': "A\u0300", 'Á': "A\u0301", 'Â': "A\u0302", 'Ã': "A\u0303",
+	'Ä': "A\u0308", 'Å': "A\u030a", 'Ç': "C\u0327", 'È': "E\u0300",
+	'É': "E\u0301", 'Ê': "E\u0302", 'Ë': "E\u0308", 'Ì': "I\u0300",
+	'Í': "I\u0301", 'Î': "I\u0302", 'Ï': "I\u0308", 'Ñ': "N\u0303",
+	'Ò': "O\u0300", 'Ó': "O\u0301", 'Ô': "O\u0302", 'Õ': "O\u0303",
+	'Ö': "O\u0308", 'Ù': "U\u0300", 'Ú': "U\u0301", 'Û': "U\u0302",
+	'Ü': "U\u0308", 'Ý': "Y\u0301", 'à': "a\u0300", 'á': "a\u0301",
+	'â': "a\u0302", 'ã': "a\u0303", 'ä': "a\u0308", 'å': "a\u030a",
+	'ç': "c\u0327", 'è': "e\u0300", 'é': "e\u0301", 'ê': "e\u0302",
+	'ë': "e\u0308", 'ì': "i\u0300", 'í': "i\u0301", 'î': "i\u0302",
+	'ï': "i\u0308", 'ñ': "n\u0303", 'ò': "o\u0300", 'ó': "o\u0301",
+	'ô': "o\u0302", 'õ': "o\u0303", 'ö': "o\u0308", 'ù': "u\u0300",
+	'ú': "u\u0301", 'û': "u\u0302", 'ü': "u\u0308", 'ý': "y\u0301",
+	'ÿ': "y\u0308", 'Ā': "A\u0304", 'ā': "a\u0304", 'Ă': "A\u0306",
+	'ă': "a\u0306", 'Ą': "A\u0328", 'ą': "a\u0328", 'Ć': "C\u0301",
+	'ć': "c\u0301", 'Ĉ': "C\u0302", 'ĉ': "c\u0302", 'Ċ': "C\u0307",
+	'ċ': "c\u0307", 'Č': "C\u030c", 'č': "c\u030c", 'Ď': "D\u030c",
+	'ď': "d\u030c", 'Ē': "E\u0304", 'ē': "e\u0304", 'Ĕ': "E\u0306",
+	'ĕ': "e\u0306", 'Ė': "E\u0307", 'ė': "e\u0307", 'Ę': "E\u0328",
+	'ę': "e\u0328", 'Ě': "E\u030c", 'ě': "e\u030c", 'Ĝ': "G\u0302",
+	'ĝ': "g\u0302", 'Ğ': "G\u0306", 'ğ': "g\u0306", 'Ġ': "G\u0307",
+	'ġ': "g\u0307", 'Ģ': "G\u0327", 'ģ': "g\u0327", 'Ĥ': "H\u0302",
+	'ĥ': "h\u0302", 'Ĩ': "I\u0303", 'ĩ': "i\u0303", 'Ī': "I\u0304",
+	'ī': "i\u0304", 'Ĭ': "I\u0306", 'ĭ': "i\u0306", 'Į': "I\u0328",
+	'į': "i\u0328", 'İ': "I\u0307", 'Ĵ': "J\u0302", 'ĵ': "j\u0302",
+	'Ķ': "K\u0327", 'ķ': "k\u0327", 'Ĺ': "L\u0301", 'ĺ': "l\u0301",
+	'Ļ': "L\u0327", 'ļ': "l\u0327", 'Ľ': "L\u030c", 'ľ': "l\u030c",
+	'Ń': "N\u0301", 'ń': "n\u0301", 'Ņ': "N\u0327", 'ņ': "n\u0327",
+	'Ň': "N\u030c", 'ň': "n\u030c", 'Ō': "O\u0304", 'ō': "o\u0304",
+	'Ŏ': "O\u0306", 'ŏ': "o\u0306", 'Ő': "O\u030b", 'ő': "o\u030b",
+	'Ŕ': "R\u0301", 'ŕ': "r\u0301", 'Ŗ': "R\u0327", 'ŗ': "r\u0327",
+	'Ř': "R\u030c", 'ř': "r\u030c", 'Ś': "S\u0301", 'ś': "s\u0301",
+	'Ŝ': "S\u0302", 'ŝ': "s\u0302", 'Ş': "S\u0327", 'ş': "s\u0327",
+	'Š': "S\u030c", 'š': "s\u030c", 'Ţ': "T\u0327", 'ţ': "t\u0327",
+	'Ť': "T\u030c", 'ť': "t\u030c", 'Ũ': "U\u0303", 'ũ': "u\u0303",
+	'Ū': "U\u0304", 'ū': "u\u0304", 'Ŭ': "U\u0306", 'ŭ': "u\u0306",
+	'Ů': "U\u030a", 'ů': "u\u030a", 'Ű': "U\u030b", 'ű': "u\u030b",
+	'Ų': "U\u0328", 'ų': "u\u0328", 'Ŵ': "W\u0302", 'ŵ': "w\u0302",
+	'Ŷ': "Y\u0302", 'ŷ': "y\u0302", 'Ÿ': "Y\u0308", 'Ź': "Z\u0301",
+	'ź': "z\u0301", 'Ż': "Z\u0307", 'ż': "z\u0307", 'Ž': "Z\u030c",
+	'ž': "z\u030c", 'Ơ': "O\u031b", 'ơ': "o\u031b", 'Ư': "U\u031b",
+	'ư': "u\u031b", 'Ǎ': "A\u030c", 'ǎ': "a\u030c", 'Ǐ': "I\u030c",
+	'ǐ': "i\u030c", 'Ǒ': "O\u030c", 'ǒ': "o\u030c", 'Ǔ': "U\u030c",
+	'ǔ': "u\u030c", 'Ǖ': "U\u0308\u0304", 'ǖ': "u\u0308\u0304", 'Ǘ': "U\u0308\u0301",
+	'ǘ': "u\u0308\u0301", 'Ǚ': "U\u0308\u030c", 'ǚ': "u\u0308\u030c", 'Ǜ': "U\u0308\u0300",
+	'ǜ': "u\u0308\u0300", 'Ǟ': "A\u0308\u0304", 'ǟ': "a\u0308\u0304", 'Ǡ': "A\u0307\u0304",
+	'ǡ': "a\u0307\u0304", 'Ǣ': "Æ\u0304", 'ǣ': "æ\u0304", 'Ǧ': "G\u030c",
+	'ǧ': "g\u030c", 'Ǩ': "K\u030c", 'ǩ': "k\u030c", 'Ǫ': "O\u0328",
+	'ǫ': "o\u0328", 'Ǭ': "O\u0328\u0304", 'ǭ': "o\u0328\u0304", 'Ǯ': "Ʒ\u030c",
+	'ǯ': "ʒ\u030c", 'ǰ': "j\u030c", 'Ǵ': "G\u0301", 'ǵ': "g\u0301",
+	'Ǹ': "N\u0300", 'ǹ': "n\u0300", 'Ǻ': "A\u030a\u0301", 'ǻ': "a\u030a\u0301",
+	'Ǽ': "Æ\u0301", 'ǽ': "æ\u0301", 'Ǿ': "Ø\u0301", 'ǿ': "ø\u0301",
+	'Ȁ': "A\u030f", 'ȁ': "a\u030f", 'Ȃ': "A\u0311", 'ȃ': "a\u0311",
+	'Ȅ': "E\u030f", 'ȅ': "e\u030f", 'Ȇ': "E\u0311", 'ȇ': "e\u0311",
+	'Ȉ': "I\u030f", 'ȉ': "i\u030f", 'Ȋ': "I\u0311", 'ȋ': "i\u0311",
+	'Ȍ': "O\u030f", 'ȍ': "o\u030f", 'Ȏ': "O\u0311", 'ȏ': "o\u0311",
+	'Ȑ': "R\u030f", 'ȑ': "r\u030f", 'Ȓ': "R\u0311", 'ȓ': "r\u0311",
+	'Ȕ': "U\u030f", 'ȕ': "u\u030f", 'Ȗ': "U\u0311", 'ȗ': "u\u0311",
+	'Ș': "S\u0326", 'ș': "s\u0326", 'Ț': "T\u0326", 'ț': "t\u0326",
+	'Ȟ': "H\u030c", 'ȟ': "h\u030c", 'Ȧ': "A\u0307", 'ȧ': "a\u0307",
+	'Ȩ': "E\u0327", 'ȩ': "e\u0327", 'Ȫ': "O\u0308\u0304", 'ȫ': "o\u0308\u0304",
+	'Ȭ': "O\u0303\u0304", 'ȭ': "o\u0303\u0304", 'Ȯ': "O\u0307", 'ȯ': "o\u0307",
+	'Ȱ': "O\u0307\u0304", 'ȱ': "o\u0307\u0304", 'Ȳ': "Y\u0304", 'ȳ': "y\u0304",
+	'が': "か\u3099", 'ぎ': "き\u3099", 'ぐ': "く\u3099", 'げ': "け\u3099",
+	'ご': "こ\u3099", 'ざ': "さ\u3099", 'じ': "し\u3099", 'ず': "す\u3099",
+	'ぜ': "せ\u3099", 'ぞ': "そ\u3099", 'だ': "た\u3099", 'ぢ': "ち\u3099",
+	'づ': "つ\u3099", 'で': "て\u3099", 'ど': "と\u3099", 'ば': "は\u3099",
+	'ぱ': "は\u309a", 'び': "ひ\u3099", 'ぴ': "ひ\u309a", 'ぶ': "ふ\u3099",
+	'ぷ': "ふ\u309a", 'べ': "へ\u3099", 'ぺ': "へ\u309a", 'ぼ': "ほ\u3099",
+	'ぽ': "ほ\u309a", 'ゔ': "う\u3099",
+	'Đ': "D\u0335", 'đ': "d\u0335", 'Ħ': "H\u0335", 'ħ': "h\u0335",
+	'Ł': "L\u0337", 'ł': "l\u0337", 'Ø': "O\u0338", 'ø': "o\u0338",
+}
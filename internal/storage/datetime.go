@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	MicrosPerSecond = int64(1_000_000)
+	MicrosPerDay    = 86400 * MicrosPerSecond
+	// DaysPerMonth is the length of a month when intervals are compared,
+	// as in PostgreSQL.
+	DaysPerMonth = 30
+)
+
+// Interval is a span of time kept as separate months, days and
+// microseconds, since the length of a month or a day in absolute time
+// depends on where it is applied.
+type Interval struct {
+	Months int32
+	Days   int32
+	Micros int64
+}
+
+// span returns the interval as whole days and remaining microseconds,
+// counting a month as DaysPerMonth days. It orders intervals.
+func (iv Interval) span() (days, micros int64) {
+	days = int64(iv.Months)*DaysPerMonth + int64(iv.Days) + iv.Micros/MicrosPerDay
+	micros = iv.Micros % MicrosPerDay
+	if micros < 0 {
+		days--
+		micros += MicrosPerDay
+	}
+	return days, micros
+}
+
+// String formats the interval as PostgreSQL does, for example
+// "1 year 2 mons 3 days 04:05:06".
+func (iv Interval) String() string {
+	var parts []string
+	unit := func(n int64, singular, plural string) {
+		if n == 0 {
+			return
+		}
+		name := plural
+		if n == 1 || n == -1 {
+			name = singular
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, name))
+	}
+	unit(int64(iv.Months/12), "year", "years")
+	unit(int64(iv.Months%12), "mon", "mons")
+	unit(int64(iv.Days), "day", "days")
+
+	if iv.Micros != 0 || len(parts) == 0 {
+		micros := iv.Micros
+		sign := ""
+		if micros < 0 {
+			sign = "-"
+			micros = -micros
+		}
+		secs := micros / MicrosPerSecond
+		clock := fmt.Sprintf("%s%02d:%02d:%02d", sign, secs/3600, secs/60%60, secs%60)
+		if frac := micros % MicrosPerSecond; frac != 0 {
+			clock += strings.TrimRight(fmt.Sprintf(".%06d", frac), "0")
+		}
+		parts = append(parts, clock)
+	}
+	return strings.Join(parts, " ")
+}
+
+// NewDateValue creates a DATE value from the calendar date of t.
+func NewDateValue(t time.Time) Value {
+	y, m, d := t.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+	return Value{Type: TypeDate, data: days}
+}
+
+// NewTimestampValue creates a TIMESTAMP value from the wall clock time of
+// t, ignoring its time zone.
+func NewTimestampValue(t time.Time) Value {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return Value{Type: TypeTimestamp, data: wall.UnixMicro()}
+}
+
+// NewTimestampTZValue creates a TIMESTAMPTZ value for the instant t.
+func NewTimestampTZValue(t time.Time) Value {
+	return Value{Type: TypeTimestampTZ, data: t.UnixMicro()}
+}
+
+// NewIntervalValue creates an INTERVAL value.
+func NewIntervalValue(iv Interval) Value {
+	return Value{Type: TypeInterval, data: iv}
+}
+
+// AsTime returns a DATE, TIMESTAMP or TIMESTAMPTZ value as a time in UTC.
+// A DATE is midnight of that day.
+func (v Value) AsTime() (time.Time, bool) {
+	if !v.IsTemporal() || v.IsNull {
+		return time.Time{}, false
+	}
+	return time.UnixMicro(v.temporalMicros()).UTC(), true
+}
+
+// AsInterval returns the value as an Interval.
+func (v Value) AsInterval() (Interval, bool) {
+	if v.Type != TypeInterval || v.IsNull {
+		return Interval{}, false
+	}
+	return v.data.(Interval), true
+}
+
+// IsTemporal reports whether the value is a DATE, TIMESTAMP or TIMESTAMPTZ.
+func (v Value) IsTemporal() bool {
+	return v.Type == TypeDate || v.Type == TypeTimestamp || v.Type == TypeTimestampTZ
+}
+
+// temporalMicros returns a point in time as microseconds since the Unix
+// epoch. TIMESTAMP values are taken to be in UTC.
+func (v Value) temporalMicros() int64 {
+	if v.Type == TypeDate {
+		return v.data.(int64) * MicrosPerDay
+	}
+	return v.data.(int64)
+}
+
+func formatTemporal(v Value) string {
+	t, _ := v.AsTime()
+	switch v.Type {
+	case TypeDate:
+		return t.Format("2006-01-02")
+	case TypeTimestampTZ:
+		return t.Format("2006-01-02 15:04:05.999999") + "+00"
+	default:
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+}
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// MaxDecimalPrecision is the most digits a DECIMAL can hold, the most
+	// that fit in its 128-bit coefficient.
+	MaxDecimalPrecision = 38
+	// DefaultDecimalPrecision and DefaultDecimalScale apply to a DECIMAL
+	// declared without precision and scale.
+	DefaultDecimalPrecision = 18
+	DefaultDecimalScale     = 3
+)
+
+// RoundingMode selects how a decimal is rounded to fewer digits.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero, as SQL does by default.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even neighbor (banker's rounding).
+	RoundHalfEven
+	// RoundDown truncates toward zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+	// RoundCeiling rounds toward positive infinity.
+	RoundCeiling
+	// RoundFloor rounds toward negative infinity.
+	RoundFloor
+)
+
+// ParseRoundingMode parses a rounding mode name such as "half_even".
+func ParseRoundingMode(s string) (RoundingMode, bool) {
+	switch strings.ToLower(s) {
+	case "half_up":
+		return RoundHalfUp, true
+	case "half_even":
+		return RoundHalfEven, true
+	case "down", "truncate":
+		return RoundDown, true
+	case "up":
+		return RoundUp, true
+	case "ceiling":
+		return RoundCeiling, true
+	case "floor":
+		return RoundFloor, true
+	default:
+		return 0, false
+	}
+}
+
+// Decimal is an exact fixed-point number, coef × 10^-scale. Decimals are
+// immutable.
+type Decimal struct {
+	coef  *big.Int
+	scale int
+}
+
+var (
+	bigTen     = big.NewInt(10)
+	decimalMax = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	decimalMin = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))
+)
+
+// NewDecimal creates a decimal from its coefficient and scale.
+func NewDecimal(coef *big.Int, scale int) Decimal {
+	return Decimal{coef: new(big.Int).Set(coef), scale: scale}
+}
+
+// DecimalFromInt creates a decimal with scale 0.
+func DecimalFromInt(n int64) Decimal {
+	return Decimal{coef: big.NewInt(n)}
+}
+
+// ParseDecimal parses a number such as "-12.50" or "1e3", keeping the
+// scale it is written with.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if _, err := fmt.Sscanf(s[i+1:], "%d", &exp); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		mantissa = s[:i]
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	digits := intPart + fracPart
+	sign := ""
+	if digits != "" && (digits[0] == '-' || digits[0] == '+') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" || strings.ContainsAny(fracPart, "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	coef, _ := new(big.Int).SetString(sign+digits, 10)
+	d := Decimal{coef: coef, scale: len(fracPart) - exp}
+	if d.scale < 0 {
+		d = d.Rescale(0, RoundDown)
+	}
+	return d, nil
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Precision returns the number of significant digits of the coefficient,
+// counting at least the digits after the point.
+func (d Decimal) Precision() int {
+	n := len(new(big.Int).Abs(d.coef).String())
+	if d.coef.Sign() == 0 {
+		n = 1
+	}
+	return max(n, d.scale)
+}
+
+// Sign returns -1, 0 or 1.
+func (d Decimal) Sign() int {
+	return d.coef.Sign()
+}
+
+// fits reports whether the coefficient fits in 128 bits.
+func (d Decimal) fits() bool {
+	return d.coef.Cmp(decimalMax) <= 0 && d.coef.Cmp(decimalMin) >= 0
+}
+
+// String formats the decimal with exactly Scale digits after the point.
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.coef).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.coef.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Float64 returns the nearest float64.
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Float).SetPrec(128).Quo(
+		new(big.Float).SetInt(d.coef),
+		new(big.Float).SetInt(pow10(d.scale)),
+	).Float64()
+	return f
+}
+
+// Integer rounds the decimal to an integer.
+func (d Decimal) Integer(mode RoundingMode) *big.Int {
+	return new(big.Int).Set(d.Rescale(0, mode).coef)
+}
+
+// Rescale returns the decimal with a new scale, rounding with mode when
+// digits are dropped.
+func (d Decimal) Rescale(scale int, mode RoundingMode) Decimal {
+	switch {
+	case scale == d.scale:
+		return d
+	case scale > d.scale:
+		coef := new(big.Int).Mul(d.coef, pow10(scale-d.scale))
+		return Decimal{coef: coef, scale: scale}
+	}
+
+	divisor := pow10(d.scale - scale)
+	q, r := new(big.Int).QuoRem(d.coef, divisor, new(big.Int))
+	if r.Sign() != 0 && roundAway(q, r, divisor, d.coef.Sign(), mode) {
+		q.Add(q, big.NewInt(int64(d.coef.Sign())))
+	}
+	return Decimal{coef: q, scale: scale}
+}
+
+// roundAway reports whether a truncated quotient q with nonzero remainder
+// r must move one step away from zero.
+func roundAway(q, r, divisor *big.Int, sign int, mode RoundingMode) bool {
+	switch mode {
+	case RoundDown:
+		return false
+	case RoundUp:
+		return true
+	case RoundCeiling:
+		return sign > 0
+	case RoundFloor:
+		return sign < 0
+	}
+
+	twice := new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2))
+	switch c := twice.Cmp(divisor); {
+	case c > 0:
+		return true
+	case c < 0:
+		return false
+	case mode == RoundHalfEven:
+		return q.Bit(0) == 1
+	default:
+		return true
+	}
+}
+
+// Cmp compares two decimals exactly.
+func (d Decimal) Cmp(other Decimal) int {
+	scale := max(d.scale, other.scale)
+	return d.Rescale(scale, RoundDown).coef.Cmp(other.Rescale(scale, RoundDown).coef)
+}
+
+// Add returns d + other, with the larger of the two scales.
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+	coef := new(big.Int).Add(d.Rescale(scale, RoundDown).coef, other.Rescale(scale, RoundDown).coef)
+	return Decimal{coef: coef, scale: scale}
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.coef), scale: d.scale}
+}
+
+// Mul returns d × other, with the sum of the two scales.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.coef, other.coef), scale: d.scale + other.scale}
+}
+
+// Quo returns d / other rounded to the given scale. other must not be zero.
+func (d Decimal) Quo(other Decimal, scale int, mode RoundingMode) Decimal {
+	// d/other = d.coef × 10^(scale + other.scale - d.scale) / other.coef × 10^-scale.
+	shift := scale + other.scale - d.scale
+	num := new(big.Int).Set(d.coef)
+	den := new(big.Int).Set(other.coef)
+	if shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	sign := num.Sign() * den.Sign()
+	if r.Sign() != 0 && roundAway(q, r, new(big.Int).Abs(den), sign, mode) {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return Decimal{coef: q, scale: scale}
+}
+
+// Rem returns the remainder of d / other truncated toward zero, with the
+// larger of the two scales. other must not be zero.
+func (d Decimal) Rem(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+	coef := new(big.Int).Rem(d.Rescale(scale, RoundDown).coef, other.Rescale(scale, RoundDown).coef)
+	return Decimal{coef: coef, scale: scale}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// NewDecimalValue creates a DECIMAL value.
+func NewDecimalValue(d Decimal) Value {
+	return Value{Type: TypeDecimal, data: d}
+}
+
+// AsDecimal returns the value as a Decimal.
+func (v Value) AsDecimal() (Decimal, bool) {
+	if v.Type != TypeDecimal || v.IsNull {
+		return Decimal{}, false
+	}
+	return v.data.(Decimal), true
+}
+
+// appendDecimal appends the coefficient of d rescaled to scale as a
+// 128-bit two's complement integer, low word first.
+func appendDecimal(buf []byte, d Decimal, scale int) ([]byte, error) {
+	d = d.Rescale(scale, RoundHalfUp)
+	if !d.fits() {
+		return buf, fmt.Errorf("value %s does not fit in DECIMAL", d)
+	}
+	u := new(big.Int).Set(d.coef)
+	if u.Sign() < 0 {
+		u.Add(u, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	var word [16]byte
+	u.FillBytes(word[:])
+	for i := 15; i >= 0; i-- {
+		buf = append(buf, word[i])
+	}
+	return buf, nil
+}
+
+// readDecimal reads a coefficient written by appendDecimal.
+func readDecimal(b []byte, scale int) Decimal {
+	var word [16]byte
+	for i := range word {
+		word[i] = b[15-i]
+	}
+	coef := new(big.Int).SetBytes(word[:])
+	if word[0]&0x80 != 0 {
+		coef.Sub(coef, new(big.Int).Lsh(big.NewInt(1), 128))
+	}
+	return Decimal{coef: coef, scale: scale}
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MaxEnumLabels is the most labels an ENUM type can have, the most whose
+// codes fit in the two bytes a value takes in a column file.
+const MaxEnumLabels = math.MaxUint16 + 1
+
+// EnumType is a user-defined type whose values are a fixed list of labels.
+// Values are ordered as the labels are declared.
+type EnumType struct {
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
+}
+
+// Code returns the position of a label in the type.
+func (t *EnumType) Code(label string) (int, bool) {
+	for i, l := range t.Labels {
+		if l == label {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// enumValue is the data of an ENUM value: the code of its label.
+type enumValue struct {
+	typ  *EnumType
+	code int
+}
+
+// NewEnumValue creates a value of an ENUM type from the code of a label.
+func NewEnumValue(t *EnumType, code int) Value {
+	return Value{Type: TypeEnum, data: enumValue{typ: t, code: code}}
+}
+
+// AsEnum returns the type and code of an ENUM value.
+func (v Value) AsEnum() (*EnumType, int, bool) {
+	if v.Type != TypeEnum || v.IsNull {
+		return nil, 0, false
+	}
+	e := v.data.(enumValue)
+	return e.typ, e.code, true
+}
+
+// compareEnum orders values of one ENUM type by code. Values of different
+// ENUM types are ordered by type name.
+func compareEnum(v, other Value) int {
+	a, b := v.data.(enumValue), other.data.(enumValue)
+	if c := strings.Compare(a.typ.Name, b.typ.Name); c != 0 {
+		return c
+	}
+	return compareOrdered(int64(a.code), int64(b.code))
+}
+
+// CreateType registers a new ENUM type. Type names are matched without
+// regard to case.
+func (c *Catalog) CreateType(t *EnumType) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.lookupType(t.Name); exists {
+		return fmt.Errorf("type %q already exists", t.Name)
+	}
+	if c.Types == nil {
+		c.Types = make(map[string]*EnumType)
+	}
+
+	c.Types[t.Name] = t
+
+	if err := c.save(); err != nil {
+		delete(c.Types, t.Name)
+		return fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return nil
+}
+
+// DropType removes an ENUM type from the catalog.
+func (c *Catalog) DropType(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, exists := c.lookupType(name)
+	if !exists {
+		return fmt.Errorf("type %q does not exist", name)
+	}
+
+	delete(c.Types, t.Name)
+
+	if err := c.save(); err != nil {
+		c.Types[t.Name] = t
+		return fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return nil
+}
+
+// GetType returns an ENUM type by name.
+func (c *Catalog) GetType(name string) (*EnumType, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lookupType(name)
+}
+
+func (c *Catalog) lookupType(name string) (*EnumType, bool) {
+	if t, exists := c.Types[name]; exists {
+		return t, true
+	}
+	for _, t := range c.Types {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	IndexMagicNumber   = "TIDX"
+	IndexFormatVersion = 1
+)
+
+// UniqueConstraint is a PRIMARY KEY or UNIQUE constraint on a table.
+type UniqueConstraint struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Primary bool     `json:"primary,omitempty"`
+}
+
+// HashIndex maps the hash of a key to the rows holding that key. It backs a
+// unique constraint. Rows whose key contains a NULL are not indexed, since
+// NULLs never conflict with each other. Strings are compared by the
+// collations of their columns, so that keys differing only in case conflict
+// under CollationNoCase.
+type HashIndex struct {
+	constraint UniqueConstraint
+	positions  []int
+	collations []Collation
+	buckets    map[uint64][]uint64
+	rowCount   uint64
+	path       string
+}
+
+func newHashIndex(dir string, schema *TableSchema, uc UniqueConstraint) (*HashIndex, error) {
+	idx := &HashIndex{
+		constraint: uc,
+		buckets:    make(map[uint64][]uint64),
+		path:       filepath.Join(dir, fmt.Sprintf("idx_%s.dat", uc.Name)),
+	}
+	for _, name := range uc.Columns {
+		pos := schema.GetColumnIndex(name)
+		if pos == -1 {
+			return nil, fmt.Errorf("constraint %q refers to unknown column %q", uc.Name, name)
+		}
+		idx.positions = append(idx.positions, pos)
+		idx.collations = append(idx.collations, schema.Columns[pos].Collation)
+	}
+	return idx, nil
+}
+
+// key extracts the indexed values from a row. It returns nil if any of
+// them is NULL.
+func (idx *HashIndex) key(row []Value) []Value {
+	key := make([]Value, len(idx.positions))
+	for i, pos := range idx.positions {
+		if row[pos].IsNull {
+			return nil
+		}
+		key[i] = row[pos].WithCollation(idx.collations[i])
+	}
+	return key
+}
+
+// find returns the row holding key, using rowKey to read the key of an
+// indexed row so that hash collisions are resolved.
+func (idx *HashIndex) find(key []Value, rowKey func(rowID uint64) []Value) (uint64, bool) {
+	for _, rowID := range idx.buckets[HashValues(key)] {
+		existing := rowKey(rowID)
+		if existing != nil && tuplesEqual(existing, key) {
+			return rowID, true
+		}
+	}
+	return 0, false
+}
+
+func (idx *HashIndex) add(key []Value, rowID uint64) {
+	if key != nil {
+		h := HashValues(key)
+		idx.buckets[h] = append(idx.buckets[h], rowID)
+	}
+	idx.rowCount = rowID + 1
+}
+
+// violation returns the error reported when key is already present.
+func (idx *HashIndex) violation(key []Value) error {
+	vals := make([]string, len(key))
+	for i, v := range key {
+		vals[i] = v.String()
+	}
+	return fmt.Errorf("duplicate key value violates unique constraint %q: key (%s)=(%s) already exists",
+		idx.constraint.Name, strings.Join(idx.constraint.Columns, ", "), strings.Join(vals, ", "))
+}
+
+// build indexes rows 0..n-1, failing on the first duplicate key.
+func (idx *HashIndex) build(n uint64, row func(rowID uint64) []Value) error {
+	idx.buckets = make(map[uint64][]uint64)
+	idx.rowCount = 0
+	rowKey := func(rowID uint64) []Value { return idx.key(row(rowID)) }
+	for i := uint64(0); i < n; i++ {
+		key := rowKey(i)
+		if key != nil {
+			if _, dup := idx.find(key, rowKey); dup {
+				return idx.violation(key)
+			}
+		}
+		idx.add(key, i)
+	}
+	idx.rowCount = n
+	return nil
+}
+
+func tuplesEqual(a, b []Value) bool {
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Save writes the index file to disk.
+func (idx *HashIndex) Save() (err error) {
+	file, err := os.Create(idx.path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	w := bufio.NewWriter(file)
+
+	if _, err := w.WriteString(IndexMagicNumber); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint16(IndexFormatVersion)); err != nil {
+		return err
+	}
+
+	// The column list lets a load detect an index built for other columns.
+	columns := strings.Join(idx.constraint.Columns, ",")
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(columns))); err != nil {
+		return err
+	}
+	if _, err := w.WriteString(columns); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, idx.rowCount); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(idx.buckets))); err != nil {
+		return err
+	}
+	for h, rows := range idx.buckets {
+		if err := binary.Write(w, binary.LittleEndian, h); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, uint32(len(rows))); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, rows); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// load reads the index file from disk. It fails if the file is missing,
+// malformed or was built for different columns.
+func (idx *HashIndex) load() (err error) {
+	file, err := os.Open(idx.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	r := bufio.NewReader(file)
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return err
+	}
+	if string(magic) != IndexMagicNumber {
+		return fmt.Errorf("invalid index file format")
+	}
+
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+	if version != IndexFormatVersion {
+		return fmt.Errorf("unsupported index file version %d", version)
+	}
+
+	var columnsLen uint32
+	if err := binary.Read(r, binary.LittleEndian, &columnsLen); err != nil {
+		return err
+	}
+	columns := make([]byte, columnsLen)
+	if _, err := io.ReadFull(r, columns); err != nil {
+		return err
+	}
+	if string(columns) != strings.Join(idx.constraint.Columns, ",") {
+		return fmt.Errorf("index was built for columns (%s)", columns)
+	}
+
+	var rowCount, bucketCount uint64
+	if err := binary.Read(r, binary.LittleEndian, &rowCount); err != nil {
+		return err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &bucketCount); err != nil {
+		return err
+	}
+
+	buckets := make(map[uint64][]uint64)
+	for i := uint64(0); i < bucketCount; i++ {
+		var h uint64
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+			return err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return err
+		}
+		rows := make([]uint64, n)
+		if err := binary.Read(r, binary.LittleEndian, rows); err != nil {
+			return err
+		}
+		for _, rowID := range rows {
+			if rowID >= rowCount {
+				return fmt.Errorf("index refers to row %d of %d", rowID, rowCount)
+			}
+		}
+		buckets[h] = rows
+	}
+
+	idx.buckets = buckets
+	idx.rowCount = rowCount
+	return nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"math"
+	"math/big"
+)
+
+// IsInteger reports whether t is a signed or unsigned integer type.
+func (t DataType) IsInteger() bool {
+	switch t {
+	case TypeInt8, TypeInt16, TypeInt32, TypeInt64, TypeUint8, TypeUint16, TypeUint32, TypeUint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFloat reports whether t is FLOAT32 or FLOAT64.
+func (t DataType) IsFloat() bool {
+	return t == TypeFloat32 || t == TypeFloat64
+}
+
+// IsNumeric reports whether t is an integer, floating point or DECIMAL type.
+func (t DataType) IsNumeric() bool {
+	return t.IsInteger() || t.IsFloat() || t == TypeDecimal
+}
+
+// IntegerRange returns the smallest and largest values of an integer type.
+func IntegerRange(t DataType) (lo *big.Int, hi *big.Int) {
+	switch t {
+	case TypeInt8:
+		return big.NewInt(math.MinInt8), big.NewInt(math.MaxInt8)
+	case TypeInt16:
+		return big.NewInt(math.MinInt16), big.NewInt(math.MaxInt16)
+	case TypeInt32:
+		return big.NewInt(math.MinInt32), big.NewInt(math.MaxInt32)
+	case TypeUint8:
+		return big.NewInt(0), big.NewInt(math.MaxUint8)
+	case TypeUint16:
+		return big.NewInt(0), big.NewInt(math.MaxUint16)
+	case TypeUint32:
+		return big.NewInt(0), big.NewInt(math.MaxUint32)
+	case TypeUint64:
+		return big.NewInt(0), new(big.Int).SetUint64(math.MaxUint64)
+	default:
+		return big.NewInt(math.MinInt64), big.NewInt(math.MaxInt64)
+	}
+}
+
+// NewIntegerValue creates a value of an integer type from n, which must be
+// within the range of the type.
+func NewIntegerValue(t DataType, n *big.Int) Value {
+	if t == TypeUint64 {
+		return NewUint64Value(n.Uint64())
+	}
+	return Value{Type: t, data: n.Int64()}
+}
+
+// NewUint64Value creates a UINT64 value.
+func NewUint64Value(v uint64) Value {
+	return Value{Type: TypeUint64, data: v}
+}
+
+// NewFloat32Value creates a FLOAT32 value.
+func NewFloat32Value(v float32) Value {
+	return Value{Type: TypeFloat32, data: v}
+}
+
+// AsUint64 returns a UINT64 value.
+func (v Value) AsUint64() (uint64, bool) {
+	if v.Type != TypeUint64 || v.IsNull {
+		return 0, false
+	}
+	return v.data.(uint64), true
+}
+
+// AsBigInt returns a value of any integer type as a big.Int.
+func (v Value) AsBigInt() (*big.Int, bool) {
+	if !v.Type.IsInteger() || v.IsNull {
+		return nil, false
+	}
+	if u, ok := v.AsUint64(); ok {
+		return new(big.Int).SetUint64(u), true
+	}
+	return big.NewInt(v.data.(int64)), true
+}
+
+// fixedWidth returns the number of bytes a value of t takes in a column
+// file, or 0 for variable-length types.
+func (t DataType) fixedWidth() int {
+	switch t {
+	case TypeBool, TypeInt8, TypeUint8:
+		return 1
+	case TypeInt16, TypeUint16, TypeEnum:
+		return 2
+	case TypeInt32, TypeUint32, TypeFloat32:
+		return 4
+	case TypeInt64, TypeUint64, TypeFloat64, TypeDate, TypeTimestamp, TypeTimestampTZ:
+		return 8
+	case TypeInterval, TypeDecimal, TypeUUID:
+		return 16
+	default:
+		return 0
+	}
+}
+
+// isSigned reports whether t is a signed integer type.
+func (t DataType) isSigned() bool {
+	return t == TypeInt8 || t == TypeInt16 || t == TypeInt32 || t == TypeInt64
+}
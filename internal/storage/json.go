@@ -0,0 +1,15 @@
+package storage
+
+// NewJSONValue creates a JSON value from the text of a JSON document, which
+// the caller must have validated.
+func NewJSONValue(text string) Value {
+	return Value{Type: TypeJSON, data: text}
+}
+
+// AsJSON returns the text of a JSON value.
+func (v Value) AsJSON() (string, bool) {
+	if v.Type != TypeJSON || v.IsNull {
+		return "", false
+	}
+	return v.data.(string), true
+}
@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IsNested reports whether t is LIST or STRUCT. A nested column stores its
+// elements or fields in child columns.
+func (t DataType) IsNested() bool {
+	return t == TypeList || t == TypeStruct
+}
+
+// Struct is the value of a STRUCT: field names with one value each.
+type Struct struct {
+	Names  []string
+	Values []Value
+}
+
+// Field returns the value of the named field.
+func (s Struct) Field(name string) (Value, bool) {
+	for i, n := range s.Names {
+		if n == name {
+			return s.Values[i], true
+		}
+	}
+	return Value{}, false
+}
+
+// NewListValue creates a LIST value. The value keeps elems, which must not
+// be modified afterwards.
+func NewListValue(elems []Value) Value {
+	if elems == nil {
+		elems = []Value{}
+	}
+	return Value{Type: TypeList, data: elems}
+}
+
+// AsList returns the elements of a LIST value. The result must not be
+// modified.
+func (v Value) AsList() ([]Value, bool) {
+	if v.Type != TypeList || v.IsNull {
+		return nil, false
+	}
+	return v.data.([]Value), true
+}
+
+// NewStructValue creates a STRUCT value with one value per name.
+func NewStructValue(names []string, values []Value) Value {
+	return Value{Type: TypeStruct, data: Struct{Names: names, Values: values}}
+}
+
+// AsStruct returns a STRUCT value.
+func (v Value) AsStruct() (Struct, bool) {
+	if v.Type != TypeStruct || v.IsNull {
+		return Struct{}, false
+	}
+	return v.data.(Struct), true
+}
+
+// nestedString formats a LIST as [1, 2] and a STRUCT as {a: 1, b: 'x'},
+// quoting the values that are not numbers or booleans.
+func nestedString(v Value) string {
+	var b strings.Builder
+	if elems, ok := v.AsList(); ok {
+		b.WriteByte('[')
+		for i, elem := range elems {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(elementString(elem))
+		}
+		b.WriteByte(']')
+		return b.String()
+	}
+	s := v.data.(Struct)
+	b.WriteByte('{')
+	for i, name := range s.Names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(name + ": " + elementString(s.Values[i]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func elementString(v Value) string {
+	if v.IsNull || v.isNumeric() || v.Type == TypeBool || v.Type.IsNested() {
+		return v.String()
+	}
+	return "'" + strings.ReplaceAll(v.String(), "'", "''") + "'"
+}
+
+// compareNested orders two lists or two structs element by element, with
+// a shorter list before any longer list it is a prefix of.
+func compareNested(v, other Value) int {
+	a, b := nestedValues(v), nestedValues(other)
+	for i := range min(len(a), len(b)) {
+		if c := a[i].Compare(b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareOrdered(int64(len(a)), int64(len(b)))
+}
+
+func nestedValues(v Value) []Value {
+	if elems, ok := v.AsList(); ok {
+		return elems
+	}
+	return v.data.(Struct).Values
+}
+
+// nestedTypeName formats a LIST or STRUCT column type as LIST<INT64> or
+// STRUCT<a INT64, b STRING>.
+func nestedTypeName(c ColumnDef) string {
+	if c.Type == TypeList {
+		return "LIST<" + c.Children[0].TypeName() + ">"
+	}
+	fields := make([]string, len(c.Children))
+	for i, child := range c.Children {
+		fields[i] = child.Name + " " + child.TypeName()
+	}
+	return "STRUCT<" + strings.Join(fields, ", ") + ">"
+}
+
+// validateValue checks that a non-NULL value fits a column type, including
+// the elements and fields of nested values.
+func validateValue(col ColumnDef, v Value) error {
+	if v.Type != col.Type {
+		return fmt.Errorf("value is of type %s, not %s", v.Type, col.TypeName())
+	}
+	if d, ok := v.AsDecimal(); ok && d.Rescale(col.Scale, RoundHalfUp).Precision() > col.Precision {
+		return fmt.Errorf("value %s overflows DECIMAL(%d,%d)", d, col.Precision, col.Scale)
+	}
+	if t, _, ok := v.AsEnum(); ok && t.Name != col.Enum.Name {
+		return fmt.Errorf("value is of type %s, not %s", t.Name, col.Enum.Name)
+	}
+	if !v.Type.IsNested() {
+		return nil
+	}
+	if s, ok := v.AsStruct(); ok {
+		if len(s.Names) != len(col.Children) {
+			return fmt.Errorf("value %s does not match %s", v, col.TypeName())
+		}
+		for i, child := range col.Children {
+			if s.Names[i] != child.Name {
+				return fmt.Errorf("value %s does not match %s", v, col.TypeName())
+			}
+		}
+	}
+	for i, elem := range nestedValues(v) {
+		child := col.Children[min(i, len(col.Children)-1)]
+		if elem.IsNull {
+			continue
+		}
+		if err := validateValue(child, elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendNested appends a LIST or STRUCT value. A LIST stores its elements
+// in a child column and the end of each row's run of elements as an 8-byte
+// offset in data. A STRUCT stores each field in a child column and nothing
+// in data.
+func (cf *ColumnFile) appendNested(v Value) error {
+	if elems, ok := v.AsList(); ok {
+		for _, elem := range elems {
+			if err := cf.children[0].AppendValue(elem); err != nil {
+				return err
+			}
+		}
+		cf.data = appendUint(cf.data, cf.children[0].rowCount, 8)
+		return nil
+	}
+	s := v.data.(Struct)
+	for i, child := range cf.children {
+		if err := child.AppendValue(s.Values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendNullNested records a NULL LIST or STRUCT: an empty run of elements
+// or a NULL in every field.
+func (cf *ColumnFile) appendNullNested() {
+	if cf.dataType == TypeList {
+		cf.data = appendUint(cf.data, cf.listEnd(cf.rowCount), 8)
+		return
+	}
+	for _, child := range cf.children {
+		_ = child.AppendValue(NewNullValue())
+	}
+}
+
+// listEnd returns the position in the element column where the elements
+// of row begin, which is the end of those of the row before.
+func (cf *ColumnFile) listEnd(row uint64) uint64 {
+	if row == 0 || row*8 > uint64(len(cf.data)) {
+		return 0
+	}
+	return readUint(cf.data[(row-1)*8:], 8)
+}
+
+// getNested reads a LIST or STRUCT value.
+func (cf *ColumnFile) getNested(rowIndex uint64) Value {
+	if cf.dataType == TypeList {
+		if (rowIndex+1)*8 > uint64(len(cf.data)) {
+			return NewNullValue()
+		}
+		start, end := cf.listEnd(rowIndex), cf.listEnd(rowIndex+1)
+		elems := make([]Value, 0, end-start)
+		for i := start; i < end; i++ {
+			elems = append(elems, cf.children[0].GetValue(i))
+		}
+		return NewListValue(elems)
+	}
+	values := make([]Value, len(cf.children))
+	for i, child := range cf.children {
+		values[i] = child.GetValue(rowIndex)
+	}
+	return NewStructValue(cf.names, values)
+}
+
+// applyColumnDef sets the parts of a column definition that column files
+// do not record: the scale of DECIMAL values, the labels of ENUM values,
+// the collation of strings and the names of STRUCT fields. It creates
+// missing child columns.
+func (cf *ColumnFile) applyColumnDef(col ColumnDef) {
+	cf.scale, cf.enum, cf.collation = col.Scale, col.Enum, col.Collation
+	if !col.Type.IsNested() {
+		return
+	}
+	cf.names = make([]string, len(col.Children))
+	for i, child := range col.Children {
+		cf.names[i] = child.Name
+		if i >= len(cf.children) {
+			cf.children = append(cf.children, newColumnFile("", child))
+		}
+		cf.children[i].applyColumnDef(child)
+	}
+}
+
+// writeChildren writes the child columns of a nested column after its own
+// data, each in the same layout as a column file without the header.
+func (cf *ColumnFile) writeChildren(w io.Writer) error {
+	if !cf.dataType.IsNested() {
+		return nil
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint16(len(cf.children))); err != nil {
+		return err
+	}
+	for _, child := range cf.children {
+		if err := child.writeTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readChildren reads the child columns written by writeChildren.
+func (cf *ColumnFile) readChildren(r io.Reader) error {
+	if !cf.dataType.IsNested() {
+		return nil
+	}
+	var n uint16
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return err
+	}
+	cf.children = make([]*ColumnFile, n)
+	for i := range cf.children {
+		child := &ColumnFile{}
+		if err := child.readFrom(r); err != nil {
+			return err
+		}
+		cf.children[i] = child
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+)
+
+// Sequence generates a series of integers. Its state is saved in the
+// catalog each time a value is handed out, so values are never reused.
+type Sequence struct {
+	Name      string `json:"name"`
+	Start     int64  `json:"start"`
+	Increment int64  `json:"increment"`
+	MinValue  int64  `json:"min_value"`
+	MaxValue  int64  `json:"max_value"`
+	// Last is the value most recently returned, valid only when Called.
+	Last   int64 `json:"last"`
+	Called bool  `json:"called"`
+	// OwnedBy names the table of an identity column using the sequence.
+	// Owned sequences are dropped with their table.
+	OwnedBy string `json:"owned_by,omitempty"`
+}
+
+// NewSequence creates a sequence. Nil options take the defaults: an
+// increment of 1, bounds of 1 and MaxInt64 for ascending sequences or
+// MinInt64 and -1 for descending ones, and a start at the lower bound
+// (upper bound when descending).
+func NewSequence(name string, start, increment, minValue, maxValue *int64) (*Sequence, error) {
+	seq := &Sequence{Name: name, Increment: 1}
+	if increment != nil {
+		seq.Increment = *increment
+	}
+	if seq.Increment == 0 {
+		return nil, fmt.Errorf("INCREMENT must not be zero")
+	}
+
+	if seq.Increment > 0 {
+		seq.MinValue, seq.MaxValue = 1, math.MaxInt64
+	} else {
+		seq.MinValue, seq.MaxValue = math.MinInt64, -1
+	}
+	if minValue != nil {
+		seq.MinValue = *minValue
+	}
+	if maxValue != nil {
+		seq.MaxValue = *maxValue
+	}
+	if seq.MinValue >= seq.MaxValue {
+		return nil, fmt.Errorf("MINVALUE (%d) must be less than MAXVALUE (%d)", seq.MinValue, seq.MaxValue)
+	}
+
+	seq.Start = seq.MinValue
+	if seq.Increment < 0 {
+		seq.Start = seq.MaxValue
+	}
+	if start != nil {
+		seq.Start = *start
+	}
+	if seq.Start < seq.MinValue || seq.Start > seq.MaxValue {
+		return nil, fmt.Errorf("START value (%d) must be between MINVALUE (%d) and MAXVALUE (%d)",
+			seq.Start, seq.MinValue, seq.MaxValue)
+	}
+
+	return seq, nil
+}
+
+// next returns the value after Last without advancing the sequence.
+func (s *Sequence) next() (int64, error) {
+	if !s.Called {
+		return s.Start, nil
+	}
+	if s.Increment > 0 && s.Last > s.MaxValue-s.Increment {
+		return 0, fmt.Errorf("nextval: reached maximum value of sequence %q (%d)", s.Name, s.MaxValue)
+	}
+	if s.Increment < 0 && s.Last < s.MinValue-s.Increment {
+		return 0, fmt.Errorf("nextval: reached minimum value of sequence %q (%d)", s.Name, s.MinValue)
+	}
+	return s.Last + s.Increment, nil
+}
+
+// CreateSequence registers a new sequence.
+func (c *Catalog) CreateSequence(seq *Sequence) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Sequences[seq.Name]; exists {
+		return fmt.Errorf("sequence %q already exists", seq.Name)
+	}
+	if c.Sequences == nil {
+		c.Sequences = make(map[string]*Sequence)
+	}
+
+	c.Sequences[seq.Name] = seq
+
+	if err := c.save(); err != nil {
+		delete(c.Sequences, seq.Name)
+		return fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return nil
+}
+
+// DropSequence removes a sequence from the catalog.
+func (c *Catalog) DropSequence(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.Sequences[name]; !exists {
+		return fmt.Errorf("sequence %q does not exist", name)
+	}
+
+	delete(c.Sequences, name)
+
+	if err := c.save(); err != nil {
+		return fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return nil
+}
+
+// GetSequence returns a sequence by name.
+func (c *Catalog) GetSequence(name string) (*Sequence, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	seq, exists := c.Sequences[name]
+	return seq, exists
+}
+
+// NextVal advances a sequence and returns its new value. The catalog is
+// saved before the value is returned.
+func (c *Catalog) NextVal(name string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seq, exists := c.Sequences[name]
+	if !exists {
+		return 0, fmt.Errorf("sequence %q does not exist", name)
+	}
+
+	val, err := seq.next()
+	if err != nil {
+		return 0, err
+	}
+
+	prevLast, prevCalled := seq.Last, seq.Called
+	seq.Last, seq.Called = val, true
+	if err := c.save(); err != nil {
+		seq.Last, seq.Called = prevLast, prevCalled
+		return 0, fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return val, nil
+}
+
+// SetVal sets the value most recently returned by a sequence, so the next
+// call to NextVal returns the value after it.
+func (c *Catalog) SetVal(name string, val int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seq, exists := c.Sequences[name]
+	if !exists {
+		return fmt.Errorf("sequence %q does not exist", name)
+	}
+	if val < seq.MinValue || val > seq.MaxValue {
+		return fmt.Errorf("setval: value %d is out of bounds for sequence %q (%d..%d)",
+			val, name, seq.MinValue, seq.MaxValue)
+	}
+
+	prevLast, prevCalled := seq.Last, seq.Called
+	seq.Last, seq.Called = val, true
+	if err := c.save(); err != nil {
+		seq.Last, seq.Called = prevLast, prevCalled
+		return fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return nil
+}
+
+// RestartSequence resets a sequence so the next call to NextVal returns its
+// start value.
+func (c *Catalog) RestartSequence(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seq, exists := c.Sequences[name]
+	if !exists {
+		return fmt.Errorf("sequence %q does not exist", name)
+	}
+
+	prevLast, prevCalled := seq.Last, seq.Called
+	seq.Last, seq.Called = 0, false
+	if err := c.save(); err != nil {
+		seq.Last, seq.Called = prevLast, prevCalled
+		return fmt.Errorf("failed to save catalog: %w", err)
+	}
+
+	return nil
+}
@@ -8,11 +8,15 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
+// FormatVersion 2 added the child columns written after the data of a LIST
+// or STRUCT column. Version 1 files hold no nested columns and read the
+// same way.
 const (
 	MagicNumber   = "TCOL"
-	FormatVersion = 1
+	FormatVersion = 2
 )
 
 // ColumnFile manages a single column's data.
@@ -22,6 +26,20 @@ type ColumnFile struct {
 	data     []byte
 	rowCount uint64
 	path     string
+	// scale is the number of fractional digits of a DECIMAL column, which
+	// stores the coefficients only.
+	scale int
+	// offsets caches the start of each variable-length value in data,
+	// filled in lazily as rows are read.
+	offsets []uint64
+	// children holds the elements of a LIST or the fields of a STRUCT,
+	// and names the STRUCT field names.
+	children []*ColumnFile
+	names    []string
+	// enum is the type of an ENUM column, which stores label codes.
+	enum *EnumType
+	// collation is the collation of the strings of a STRING column.
+	collation Collation
 }
 
 // NewColumnFile creates a new column file.
@@ -34,15 +52,30 @@ func NewColumnFile(path string, dataType DataType) *ColumnFile {
 	}
 }
 
+// newColumnFile creates an empty column file for a column definition.
+func newColumnFile(path string, col ColumnDef) *ColumnFile {
+	cf := NewColumnFile(path, col.Type)
+	cf.applyColumnDef(col)
+	return cf
+}
+
 // AppendValue appends a value to the column.
 func (cf *ColumnFile) AppendValue(v Value) error {
 	if v.IsNull {
 		cf.appendNullBit(true)
-		cf.appendZeroValue()
+		if cf.dataType.IsNested() {
+			cf.appendNullNested()
+		} else {
+			cf.appendZeroValue()
+		}
 		cf.rowCount++
 		return nil
 	}
 
+	if v.Type != cf.dataType {
+		return fmt.Errorf("cannot store %s value in %s column", v.Type, cf.dataType)
+	}
+
 	cf.appendNullBit(false)
 
 	switch cf.dataType {
@@ -53,23 +86,59 @@ func (cf *ColumnFile) AppendValue(v Value) error {
 		} else {
 			cf.data = append(cf.data, 0)
 		}
-	case TypeInt64:
+	case TypeInt8, TypeInt16, TypeInt32, TypeInt64, TypeUint8, TypeUint16, TypeUint32:
 		val, _ := v.AsInt64()
-		buf := make([]byte, 8)
-		binary.LittleEndian.PutUint64(buf, uint64(val))
-		cf.data = append(cf.data, buf...)
+		cf.data = appendUint(cf.data, uint64(val), cf.dataType.fixedWidth())
+	case TypeUint64:
+		val, _ := v.AsUint64()
+		cf.data = appendUint(cf.data, val, 8)
+	case TypeFloat32:
+		val, _ := v.AsFloat64()
+		cf.data = appendUint(cf.data, uint64(math.Float32bits(float32(val))), 4)
 	case TypeFloat64:
 		val, _ := v.AsFloat64()
 		buf := make([]byte, 8)
 		binary.LittleEndian.PutUint64(buf, math.Float64bits(val))
 		cf.data = append(cf.data, buf...)
-	case TypeString:
-		val, _ := v.AsString()
-		strBytes := []byte(val)
+	case TypeDate, TypeTimestamp, TypeTimestampTZ:
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, uint64(v.data.(int64)))
+		cf.data = append(cf.data, buf...)
+	case TypeInterval:
+		iv, _ := v.AsInterval()
+		buf := make([]byte, 16)
+		binary.LittleEndian.PutUint32(buf, uint32(iv.Months))
+		binary.LittleEndian.PutUint32(buf[4:], uint32(iv.Days))
+		binary.LittleEndian.PutUint64(buf[8:], uint64(iv.Micros))
+		cf.data = append(cf.data, buf...)
+	case TypeDecimal:
+		d, _ := v.AsDecimal()
+		data, err := appendDecimal(cf.data, d, cf.scale)
+		if err != nil {
+			return err
+		}
+		cf.data = data
+	case TypeString, TypeBytes, TypeJSON:
+		var strBytes []byte
+		if val, ok := v.AsBytes(); ok {
+			strBytes = val
+		} else {
+			strBytes = []byte(v.data.(string))
+		}
 		lenBuf := make([]byte, 4)
 		binary.LittleEndian.PutUint32(lenBuf, uint32(len(strBytes)))
 		cf.data = append(cf.data, lenBuf...)
 		cf.data = append(cf.data, strBytes...)
+	case TypeList, TypeStruct:
+		if err := cf.appendNested(v); err != nil {
+			return err
+		}
+	case TypeEnum:
+		_, code, _ := v.AsEnum()
+		cf.data = appendUint(cf.data, uint64(code), 2)
+	case TypeUUID:
+		u, _ := v.AsUUID()
+		cf.data = append(cf.data, u[:]...)
 	default:
 		return fmt.Errorf("unsupported data type: %v", cf.dataType)
 	}
@@ -92,14 +161,28 @@ func (cf *ColumnFile) appendNullBit(isNull bool) {
 }
 
 func (cf *ColumnFile) appendZeroValue() {
-	switch cf.dataType {
-	case TypeBool:
-		cf.data = append(cf.data, 0)
-	case TypeInt64, TypeFloat64:
-		cf.data = append(cf.data, make([]byte, 8)...)
-	case TypeString:
-		cf.data = append(cf.data, 0, 0, 0, 0)
+	width := cf.dataType.fixedWidth()
+	if cf.dataType == TypeString || cf.dataType == TypeBytes || cf.dataType == TypeJSON {
+		width = 4 // length prefix
 	}
+	cf.data = append(cf.data, make([]byte, width)...)
+}
+
+// appendUint appends the low width bytes of bits in little-endian order.
+func appendUint(buf []byte, bits uint64, width int) []byte {
+	for i := range width {
+		buf = append(buf, byte(bits>>(8*i)))
+	}
+	return buf
+}
+
+// readUint reads a little-endian unsigned integer of width bytes.
+func readUint(b []byte, width int) uint64 {
+	var bits uint64
+	for i := range width {
+		bits |= uint64(b[i]) << (8 * i)
+	}
+	return bits
 }
 
 // IsNull returns true if the value at the given row index is NULL.
@@ -126,11 +209,26 @@ func (cf *ColumnFile) GetValue(rowIndex uint64) Value {
 		if rowIndex < uint64(len(cf.data)) {
 			return NewBoolValue(cf.data[rowIndex] != 0)
 		}
-	case TypeInt64:
-		offset := rowIndex * 8
-		if offset+8 <= uint64(len(cf.data)) {
-			v := int64(binary.LittleEndian.Uint64(cf.data[offset:]))
-			return NewInt64Value(v)
+	case TypeInt8, TypeInt16, TypeInt32, TypeInt64, TypeUint8, TypeUint16, TypeUint32, TypeUint64:
+		width := cf.dataType.fixedWidth()
+		offset := rowIndex * uint64(width)
+		if offset+uint64(width) <= uint64(len(cf.data)) {
+			bits := readUint(cf.data[offset:], width)
+			switch {
+			case cf.dataType == TypeUint64:
+				return NewUint64Value(bits)
+			case cf.dataType.isSigned():
+				// Sign-extend from the stored width.
+				shift := 64 - 8*width
+				return Value{Type: cf.dataType, data: int64(bits<<shift) >> shift}
+			default:
+				return Value{Type: cf.dataType, data: int64(bits)}
+			}
+		}
+	case TypeFloat32:
+		offset := rowIndex * 4
+		if offset+4 <= uint64(len(cf.data)) {
+			return NewFloat32Value(math.Float32frombits(uint32(readUint(cf.data[offset:], 4))))
 		}
 	case TypeFloat64:
 		offset := rowIndex * 8
@@ -138,29 +236,81 @@ func (cf *ColumnFile) GetValue(rowIndex uint64) Value {
 			bits := binary.LittleEndian.Uint64(cf.data[offset:])
 			return NewFloat64Value(math.Float64frombits(bits))
 		}
-	case TypeString:
-		offset := uint64(0)
-		for i := uint64(0); i < rowIndex; i++ {
-			if offset+4 > uint64(len(cf.data)) {
-				return NewNullValue()
-			}
-			strLen := binary.LittleEndian.Uint32(cf.data[offset:])
-			offset += 4 + uint64(strLen)
+	case TypeDate, TypeTimestamp, TypeTimestampTZ:
+		offset := rowIndex * 8
+		if offset+8 <= uint64(len(cf.data)) {
+			v := int64(binary.LittleEndian.Uint64(cf.data[offset:]))
+			return Value{Type: cf.dataType, data: v}
+		}
+	case TypeInterval:
+		offset := rowIndex * 16
+		if offset+16 <= uint64(len(cf.data)) {
+			return NewIntervalValue(Interval{
+				Months: int32(binary.LittleEndian.Uint32(cf.data[offset:])),
+				Days:   int32(binary.LittleEndian.Uint32(cf.data[offset+4:])),
+				Micros: int64(binary.LittleEndian.Uint64(cf.data[offset+8:])),
+			})
 		}
-		if offset+4 > uint64(len(cf.data)) {
+	case TypeDecimal:
+		offset := rowIndex * 16
+		if offset+16 <= uint64(len(cf.data)) {
+			return NewDecimalValue(readDecimal(cf.data[offset:], cf.scale))
+		}
+	case TypeString, TypeBytes, TypeJSON:
+		offset, ok := cf.stringOffset(rowIndex)
+		if !ok || offset+4 > uint64(len(cf.data)) {
 			return NewNullValue()
 		}
 		strLen := binary.LittleEndian.Uint32(cf.data[offset:])
 		start := offset + 4
 		end := start + uint64(strLen)
 		if end <= uint64(len(cf.data)) {
-			return NewStringValue(string(cf.data[start:end]))
+			switch cf.dataType {
+			case TypeBytes:
+				return NewBytesValue(slices.Clone(cf.data[start:end]))
+			case TypeJSON:
+				return NewJSONValue(string(cf.data[start:end]))
+			}
+			return NewStringValue(string(cf.data[start:end])).WithCollation(cf.collation)
+		}
+	case TypeList, TypeStruct:
+		return cf.getNested(rowIndex)
+	case TypeEnum:
+		offset := rowIndex * 2
+		if offset+2 <= uint64(len(cf.data)) && cf.enum != nil {
+			code := int(readUint(cf.data[offset:], 2))
+			if code < len(cf.enum.Labels) {
+				return NewEnumValue(cf.enum, code)
+			}
+		}
+	case TypeUUID:
+		offset := rowIndex * 16
+		if offset+16 <= uint64(len(cf.data)) {
+			return NewUUIDValue(UUID(cf.data[offset : offset+16]))
 		}
 	}
 
 	return NewNullValue()
 }
 
+// stringOffset returns the offset of a STRING, BYTES or JSON value in data.
+// Values are length-prefixed, so offsets are found by walking from the last
+// one cached.
+func (cf *ColumnFile) stringOffset(rowIndex uint64) (uint64, bool) {
+	for uint64(len(cf.offsets)) <= rowIndex {
+		offset := uint64(0)
+		if n := len(cf.offsets); n > 0 {
+			prev := cf.offsets[n-1]
+			if prev+4 > uint64(len(cf.data)) {
+				return 0, false
+			}
+			offset = prev + 4 + uint64(binary.LittleEndian.Uint32(cf.data[prev:]))
+		}
+		cf.offsets = append(cf.offsets, offset)
+	}
+	return cf.offsets[rowIndex], true
+}
+
 // RowCount returns the number of rows.
 func (cf *ColumnFile) RowCount() uint64 {
 	return cf.rowCount
@@ -188,33 +338,38 @@ func (cf *ColumnFile) Save() (err error) {
 		return err
 	}
 
+	return cf.writeTo(file)
+}
+
+// writeTo writes the column without the file header.
+func (cf *ColumnFile) writeTo(w io.Writer) error {
 	// Write data type
-	if err := binary.Write(file, binary.LittleEndian, uint8(cf.dataType)); err != nil {
+	if err := binary.Write(w, binary.LittleEndian, uint8(cf.dataType)); err != nil {
 		return err
 	}
 
 	// Write row count
-	if err := binary.Write(file, binary.LittleEndian, cf.rowCount); err != nil {
+	if err := binary.Write(w, binary.LittleEndian, cf.rowCount); err != nil {
 		return err
 	}
 
 	// Write null mask size and data
-	if err := binary.Write(file, binary.LittleEndian, uint64(len(cf.nullMask))); err != nil {
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(cf.nullMask))); err != nil {
 		return err
 	}
-	if _, err := file.Write(cf.nullMask); err != nil {
+	if _, err := w.Write(cf.nullMask); err != nil {
 		return err
 	}
 
 	// Write data size and data
-	if err := binary.Write(file, binary.LittleEndian, uint64(len(cf.data))); err != nil {
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(cf.data))); err != nil {
 		return err
 	}
-	if _, err := file.Write(cf.data); err != nil {
+	if _, err := w.Write(cf.data); err != nil {
 		return err
 	}
 
-	return nil
+	return cf.writeChildren(w)
 }
 
 // LoadColumnFile loads a column file from disk.
@@ -245,51 +400,76 @@ func LoadColumnFile(path string) (_ *ColumnFile, err error) {
 	if err := binary.Read(file, binary.LittleEndian, &version); err != nil {
 		return nil, err
 	}
+	if version == 0 || version > FormatVersion {
+		return nil, fmt.Errorf("unsupported column file version %d", version)
+	}
+
+	if err := cf.readFrom(file); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
 
+// readFrom reads a column written by writeTo.
+func (cf *ColumnFile) readFrom(r io.Reader) error {
 	// Read data type
 	var dt uint8
-	if err := binary.Read(file, binary.LittleEndian, &dt); err != nil {
-		return nil, err
+	if err := binary.Read(r, binary.LittleEndian, &dt); err != nil {
+		return err
 	}
 	cf.dataType = DataType(dt)
 
 	// Read row count
-	if err := binary.Read(file, binary.LittleEndian, &cf.rowCount); err != nil {
-		return nil, err
+	if err := binary.Read(r, binary.LittleEndian, &cf.rowCount); err != nil {
+		return err
 	}
 
 	// Read null mask
 	var nullMaskSize uint64
-	if err := binary.Read(file, binary.LittleEndian, &nullMaskSize); err != nil {
-		return nil, err
+	if err := binary.Read(r, binary.LittleEndian, &nullMaskSize); err != nil {
+		return err
 	}
 	cf.nullMask = make([]byte, nullMaskSize)
-	if _, err := io.ReadFull(file, cf.nullMask); err != nil {
-		return nil, err
+	if _, err := io.ReadFull(r, cf.nullMask); err != nil {
+		return err
 	}
 
 	// Read data
 	var dataSize uint64
-	if err := binary.Read(file, binary.LittleEndian, &dataSize); err != nil {
-		return nil, err
+	if err := binary.Read(r, binary.LittleEndian, &dataSize); err != nil {
+		return err
 	}
 	cf.data = make([]byte, dataSize)
-	if _, err := io.ReadFull(file, cf.data); err != nil {
-		return nil, err
+	if _, err := io.ReadFull(r, cf.data); err != nil {
+		return err
 	}
 
-	return cf, nil
+	return cf.readChildren(r)
 }
 
 // Table represents a columnar table.
 type Table struct {
 	Schema  *TableSchema
 	Columns map[string]*ColumnFile
+	indexes []*HashIndex
 	dataDir string
 }
 
 // CreateTable creates a new table with the given schema.
 func CreateTable(dataDir string, schema *TableSchema) (*Table, error) {
+	if err := checkName("table", schema.Name); err != nil {
+		return nil, err
+	}
+	for _, col := range schema.Columns {
+		if err := checkName("column", col.Name); err != nil {
+			return nil, err
+		}
+	}
+	for _, uc := range schema.UniqueKeys {
+		if err := checkName("constraint", uc.Name); err != nil {
+			return nil, err
+		}
+	}
 	tableDir := filepath.Join(dataDir, "tables", schema.Name)
 
 	if err := os.MkdirAll(tableDir, 0755); err != nil {
@@ -303,8 +483,12 @@ func CreateTable(dataDir string, schema *TableSchema) (*Table, error) {
 	}
 
 	for _, col := range schema.Columns {
-		colPath := filepath.Join(tableDir, fmt.Sprintf("col_%s.dat", col.Name))
-		t.Columns[col.Name] = NewColumnFile(colPath, col.Type)
+		colPath := columnPath(tableDir, col.Name)
+		t.Columns[col.Name] = newColumnFile(colPath, col)
+	}
+
+	if err := t.openIndexes(); err != nil {
+		return nil, err
 	}
 
 	if err := t.saveMetadata(); err != nil {
@@ -336,28 +520,87 @@ func LoadTable(dataDir string, tableName string) (*Table, error) {
 	}
 
 	for _, col := range schema.Columns {
-		colPath := filepath.Join(tableDir, fmt.Sprintf("col_%s.dat", col.Name))
+		colPath := columnPath(tableDir, col.Name)
 		cf, err := LoadColumnFile(colPath)
 		if err != nil {
 			if os.IsNotExist(err) {
-				t.Columns[col.Name] = NewColumnFile(colPath, col.Type)
+				t.Columns[col.Name] = newColumnFile(colPath, col)
 				continue
 			}
 			return nil, fmt.Errorf("failed to load column %q: %w", col.Name, err)
 		}
+		cf.applyColumnDef(col)
 		t.Columns[col.Name] = cf
 	}
 
+	if err := t.openIndexes(); err != nil {
+		return nil, err
+	}
+
 	return t, nil
 }
 
-// Insert inserts a row into the table.
+// openIndexes sets up the hash index of each unique constraint. An index
+// file that is missing, unreadable or out of date with the column data is
+// rebuilt from the columns.
+func (t *Table) openIndexes() error {
+	return t.setupIndexes(true)
+}
+
+// buildIndexes rebuilds every index from the column data, as needed when
+// indexed values change in place.
+func (t *Table) buildIndexes() error {
+	return t.setupIndexes(false)
+}
+
+func (t *Table) setupIndexes(load bool) error {
+	var indexes []*HashIndex
+	rowCount := t.RowCount()
+	for _, uc := range t.Schema.UniqueKeys {
+		idx, err := newHashIndex(t.dataDir, t.Schema, uc)
+		if err != nil {
+			return err
+		}
+		if !load || idx.load() != nil || idx.rowCount != rowCount {
+			if err := idx.build(rowCount, t.Row); err != nil {
+				return fmt.Errorf("failed to rebuild index %q: %w", uc.Name, err)
+			}
+		}
+		indexes = append(indexes, idx)
+	}
+	t.indexes = indexes
+	return nil
+}
+
+// Row reads a single row from the column files.
+func (t *Table) Row(rowIndex uint64) []Value {
+	row := make([]Value, len(t.Schema.Columns))
+	for j, col := range t.Schema.Columns {
+		row[j] = t.Columns[col.Name].GetValue(rowIndex)
+	}
+	return row
+}
+
+// Insert inserts a row into the table. The row is validated against the
+// schema and unique constraints before anything is written, so a rejected
+// row leaves the table unchanged.
 func (t *Table) Insert(values []Value) error {
-	if len(values) != len(t.Schema.Columns) {
-		return fmt.Errorf("column count mismatch: expected %d, got %d",
-			len(t.Schema.Columns), len(values))
+	if err := t.Validate(values); err != nil {
+		return err
 	}
 
+	keys := make([][]Value, len(t.indexes))
+	for i, idx := range t.indexes {
+		keys[i] = idx.key(values)
+		if keys[i] == nil {
+			continue
+		}
+		if _, dup := t.findKey(idx, keys[i]); dup {
+			return idx.violation(keys[i])
+		}
+	}
+
+	rowID := t.RowCount()
 	for i, col := range t.Schema.Columns {
 		cf := t.Columns[col.Name]
 		if err := cf.AppendValue(values[i]); err != nil {
@@ -365,6 +608,137 @@ func (t *Table) Insert(values []Value) error {
 		}
 	}
 
+	for i, idx := range t.indexes {
+		idx.add(keys[i], rowID)
+	}
+
+	return nil
+}
+
+// FindConflict returns the row that holds the same key as values under the
+// named unique constraint. A key containing NULL never conflicts.
+func (t *Table) FindConflict(values []Value, constraint string) (uint64, bool) {
+	for _, idx := range t.indexes {
+		if idx.constraint.Name != constraint {
+			continue
+		}
+		key := idx.key(values)
+		if key == nil {
+			return 0, false
+		}
+		return t.findKey(idx, key)
+	}
+	return 0, false
+}
+
+// findKey returns the row holding key in an index of this table.
+func (t *Table) findKey(idx *HashIndex, key []Value) (uint64, bool) {
+	return idx.find(key, func(rowID uint64) []Value { return idx.key(t.Row(rowID)) })
+}
+
+// ReplaceRows replaces the contents of the table with rows. All rows are
+// validated and checked against the unique constraints first; on error the
+// table is left unchanged.
+func (t *Table) ReplaceRows(rows [][]Value) error {
+	for _, row := range rows {
+		if err := t.Validate(row); err != nil {
+			return err
+		}
+	}
+
+	indexes := make([]*HashIndex, len(t.indexes))
+	for i, old := range t.indexes {
+		idx, err := newHashIndex(t.dataDir, t.Schema, old.constraint)
+		if err != nil {
+			return err
+		}
+		if err := idx.build(uint64(len(rows)), func(rowID uint64) []Value { return rows[rowID] }); err != nil {
+			return err
+		}
+		indexes[i] = idx
+	}
+
+	columns := make(map[string]*ColumnFile, len(t.Columns))
+	for j, col := range t.Schema.Columns {
+		cf := newColumnFile(t.Columns[col.Name].path, col)
+		for _, row := range rows {
+			if err := cf.AppendValue(row[j]); err != nil {
+				return fmt.Errorf("failed to append value to column %q: %w", col.Name, err)
+			}
+		}
+		columns[col.Name] = cf
+	}
+
+	t.Columns = columns
+	t.indexes = indexes
+	return nil
+}
+
+// Truncate removes all rows. The empty column files are written under
+// temporary names and then renamed over the old ones, so a failed write
+// leaves the table data untouched. Constraints and indexes are kept.
+func (t *Table) Truncate() error {
+	columns := make(map[string]*ColumnFile, len(t.Columns))
+	var temps []string
+	for _, col := range t.Schema.Columns {
+		path := t.Columns[col.Name].path
+		cf := newColumnFile(path+".tmp", col)
+		if err := cf.Save(); err != nil {
+			for _, tmp := range temps {
+				_ = os.Remove(tmp)
+			}
+			return fmt.Errorf("failed to save column %q: %w", col.Name, err)
+		}
+		temps = append(temps, cf.path)
+		cf.path = path
+		columns[col.Name] = cf
+	}
+
+	for name, cf := range columns {
+		if err := os.Rename(cf.path+".tmp", cf.path); err != nil {
+			return fmt.Errorf("failed to replace column %q: %w", name, err)
+		}
+	}
+
+	t.Columns = columns
+	if err := t.buildIndexes(); err != nil {
+		return err
+	}
+	return t.saveIndexes()
+}
+
+// Validate checks that a row matches the schema: one value per column, each
+// either NULL or of the column's type, and no NULL in a NOT NULL column.
+// The elements and fields of nested values must match the column type too.
+func (t *Table) Validate(values []Value) error {
+	if len(values) != len(t.Schema.Columns) {
+		return fmt.Errorf("column count mismatch: expected %d, got %d",
+			len(t.Schema.Columns), len(values))
+	}
+
+	for i, col := range t.Schema.Columns {
+		v := values[i]
+		if v.IsNull {
+			if !col.Nullable {
+				return fmt.Errorf("null value in column %q violates not-null constraint", col.Name)
+			}
+			continue
+		}
+		if v.Type != col.Type {
+			return fmt.Errorf("column %q is of type %s but value is of type %s",
+				col.Name, col.Type, v.Type)
+		}
+		if d, ok := v.AsDecimal(); ok && d.Rescale(col.Scale, RoundHalfUp).Precision() > col.Precision {
+			return fmt.Errorf("value %s overflows column %q of type DECIMAL(%d,%d)",
+				d, col.Name, col.Precision, col.Scale)
+		}
+		if v.Type.IsNested() || v.Type == TypeEnum {
+			if err := validateValue(col, v); err != nil {
+				return fmt.Errorf("column %q: %w", col.Name, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -380,12 +754,7 @@ func (t *Table) RowCount() uint64 {
 func (t *Table) Scan(callback func(rowIndex uint64, row []Value) bool) error {
 	rowCount := t.RowCount()
 	for i := uint64(0); i < rowCount; i++ {
-		row := make([]Value, len(t.Schema.Columns))
-		for j, col := range t.Schema.Columns {
-			cf := t.Columns[col.Name]
-			row[j] = cf.GetValue(i)
-		}
-		if !callback(i, row) {
+		if !callback(i, t.Row(i)) {
 			break
 		}
 	}
@@ -399,12 +768,15 @@ func (t *Table) Save() error {
 			return fmt.Errorf("failed to save column %q: %w", name, err)
 		}
 	}
+	if err := t.saveIndexes(); err != nil {
+		return err
+	}
 	return t.saveMetadata()
 }
 
-// Drop deletes the table from disk.
-func (t *Table) Drop() error {
-	return os.RemoveAll(t.dataDir)
+// DropTable deletes a table from disk, whether or not it is loaded.
+func DropTable(dataDir string, tableName string) error {
+	return os.RemoveAll(filepath.Join(dataDir, "tables", tableName))
 }
 
 func (t *Table) saveMetadata() error {
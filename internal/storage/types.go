@@ -1,7 +1,15 @@
 // Package storage implements the storage layer of the database.
 package storage
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+)
 
 // DataType represents the type of a column value.
 type DataType uint8
@@ -12,6 +20,25 @@ const (
 	TypeInt64
 	TypeFloat64
 	TypeString
+	TypeDate
+	TypeTimestamp
+	TypeTimestampTZ
+	TypeInterval
+	TypeDecimal
+	TypeInt8
+	TypeInt16
+	TypeInt32
+	TypeUint8
+	TypeUint16
+	TypeUint32
+	TypeUint64
+	TypeFloat32
+	TypeBytes
+	TypeJSON
+	TypeList
+	TypeStruct
+	TypeEnum
+	TypeUUID
 )
 
 // String returns the string representation of the data type.
@@ -27,22 +54,101 @@ func (t DataType) String() string {
 		return "FLOAT64"
 	case TypeString:
 		return "STRING"
+	case TypeDate:
+		return "DATE"
+	case TypeTimestamp:
+		return "TIMESTAMP"
+	case TypeTimestampTZ:
+		return "TIMESTAMPTZ"
+	case TypeInterval:
+		return "INTERVAL"
+	case TypeDecimal:
+		return "DECIMAL"
+	case TypeInt8:
+		return "INT8"
+	case TypeInt16:
+		return "INT16"
+	case TypeInt32:
+		return "INT32"
+	case TypeUint8:
+		return "UINT8"
+	case TypeUint16:
+		return "UINT16"
+	case TypeUint32:
+		return "UINT32"
+	case TypeUint64:
+		return "UINT64"
+	case TypeFloat32:
+		return "FLOAT32"
+	case TypeBytes:
+		return "BYTES"
+	case TypeJSON:
+		return "JSON"
+	case TypeList:
+		return "LIST"
+	case TypeStruct:
+		return "STRUCT"
+	case TypeEnum:
+		return "ENUM"
+	case TypeUUID:
+		return "UUID"
 	default:
 		return "UNKNOWN"
 	}
 }
 
-// ParseDataType parses a string into a DataType.
+// ParseDataType parses a string into a DataType. Type modifiers such as
+// the precision and scale in DECIMAL(10,2) and the element type in
+// LIST<INT64> are ignored.
 func ParseDataType(s string) DataType {
+	if i := strings.IndexAny(s, "(<"); i >= 0 {
+		s = s[:i]
+	}
 	switch s {
+	case "INT8", "TINYINT":
+		return TypeInt8
+	case "INT16", "SMALLINT":
+		return TypeInt16
+	case "INT32", "INT4":
+		return TypeInt32
 	case "INT64", "INT", "INTEGER", "BIGINT":
 		return TypeInt64
+	case "UINT8":
+		return TypeUint8
+	case "UINT16":
+		return TypeUint16
+	case "UINT32":
+		return TypeUint32
+	case "UINT64":
+		return TypeUint64
+	case "FLOAT32", "FLOAT4":
+		return TypeFloat32
 	case "FLOAT64", "FLOAT", "DOUBLE", "REAL":
 		return TypeFloat64
 	case "STRING", "VARCHAR", "TEXT":
 		return TypeString
+	case "BYTES", "BLOB", "BYTEA":
+		return TypeBytes
+	case "JSON", "JSONB":
+		return TypeJSON
+	case "UUID":
+		return TypeUUID
 	case "BOOL", "BOOLEAN":
 		return TypeBool
+	case "DATE":
+		return TypeDate
+	case "TIMESTAMP", "TIMESTAMP WITHOUT TIME ZONE":
+		return TypeTimestamp
+	case "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE":
+		return TypeTimestampTZ
+	case "INTERVAL":
+		return TypeInterval
+	case "DECIMAL", "NUMERIC":
+		return TypeDecimal
+	case "LIST":
+		return TypeList
+	case "STRUCT":
+		return TypeStruct
 	default:
 		return TypeNull
 	}
@@ -53,6 +159,8 @@ type Value struct {
 	Type   DataType
 	IsNull bool
 	data   any
+	// collation is the collation of a STRING value.
+	collation Collation
 }
 
 // NewNullValue creates a NULL value.
@@ -88,19 +196,27 @@ func (v Value) AsBool() (bool, bool) {
 	return v.data.(bool), true
 }
 
-// AsInt64 returns the value as an int64.
+// AsInt64 returns a value of any integer type as an int64. It reports
+// false for a UINT64 above the int64 range.
 func (v Value) AsInt64() (int64, bool) {
-	if v.Type != TypeInt64 || v.IsNull {
+	if !v.Type.IsInteger() || v.IsNull {
 		return 0, false
 	}
+	if v.Type == TypeUint64 {
+		u := v.data.(uint64)
+		return int64(u), u <= math.MaxInt64
+	}
 	return v.data.(int64), true
 }
 
-// AsFloat64 returns the value as a float64.
+// AsFloat64 returns a FLOAT64 or FLOAT32 value as a float64.
 func (v Value) AsFloat64() (float64, bool) {
-	if v.Type != TypeFloat64 || v.IsNull {
+	if !v.Type.IsFloat() || v.IsNull {
 		return 0, false
 	}
+	if v.Type == TypeFloat32 {
+		return float64(v.data.(float32)), true
+	}
 	return v.data.(float64), true
 }
 
@@ -120,13 +236,241 @@ func (v Value) String() string {
 	switch v.Type {
 	case TypeBool:
 		return fmt.Sprintf("%t", v.data.(bool))
-	case TypeInt64:
-		return fmt.Sprintf("%d", v.data.(int64))
-	case TypeFloat64:
-		return fmt.Sprintf("%.6f", v.data.(float64))
-	case TypeString:
+	case TypeInt64, TypeInt8, TypeInt16, TypeInt32, TypeUint8, TypeUint16, TypeUint32, TypeUint64:
+		return fmt.Sprintf("%d", v.data)
+	case TypeFloat64, TypeFloat32:
+		f, _ := v.AsFloat64()
+		return fmt.Sprintf("%.6f", f)
+	case TypeString, TypeJSON:
 		return v.data.(string)
+	case TypeDate, TypeTimestamp, TypeTimestampTZ:
+		return formatTemporal(v)
+	case TypeInterval:
+		return v.data.(Interval).String()
+	case TypeDecimal:
+		return v.data.(Decimal).String()
+	case TypeBytes:
+		return `\x` + hex.EncodeToString(v.data.([]byte))
+	case TypeList, TypeStruct:
+		return nestedString(v)
+	case TypeEnum:
+		e := v.data.(enumValue)
+		return e.typ.Labels[e.code]
+	case TypeUUID:
+		return v.data.(UUID).String()
 	default:
 		return "UNKNOWN"
 	}
 }
+
+// Compare orders two values. It returns a negative number when v sorts
+// before other, zero when they are equal and a positive number otherwise.
+// Numbers of all types are compared numerically, exactly unless a float is
+// involved, DATE and TIMESTAMP values as points in time, intervals by their
+// length with a month of 30 days, strings by their collation, ENUM values
+// in the order of their labels, and lists and structs element by element.
+// NULL sorts after every non-NULL value and two NULLs compare equal, so
+// Compare defines a total order suitable for sorting and grouping.
+func (v Value) Compare(other Value) int {
+	switch {
+	case v.IsNull && other.IsNull:
+		return 0
+	case v.IsNull:
+		return 1
+	case other.IsNull:
+		return -1
+	}
+
+	if v.isNumeric() && other.isNumeric() {
+		if a, ok := v.AsInt64(); ok {
+			if b, ok := other.AsInt64(); ok {
+				return compareOrdered(a, b)
+			}
+		}
+		if !v.Type.IsFloat() && !other.Type.IsFloat() {
+			return v.exactDecimal().Cmp(other.exactDecimal())
+		}
+		return compareFloat(v.numericFloat(), other.numericFloat())
+	}
+
+	if v.IsTemporal() && other.IsTemporal() {
+		return compareOrdered(v.temporalMicros(), other.temporalMicros())
+	}
+
+	if v.Type != other.Type {
+		return compareOrdered(v.Type, other.Type)
+	}
+
+	switch v.Type {
+	case TypeBool:
+		a, b := v.data.(bool), other.data.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		default:
+			return 1
+		}
+	case TypeString:
+		return compareStrings(v, other)
+	case TypeJSON:
+		return strings.Compare(v.data.(string), other.data.(string))
+	case TypeBytes:
+		return bytes.Compare(v.data.([]byte), other.data.([]byte))
+	case TypeInterval:
+		aDays, aMicros := v.data.(Interval).span()
+		bDays, bMicros := other.data.(Interval).span()
+		if c := compareOrdered(aDays, bDays); c != 0 {
+			return c
+		}
+		return compareOrdered(aMicros, bMicros)
+	case TypeList, TypeStruct:
+		return compareNested(v, other)
+	case TypeEnum:
+		return compareEnum(v, other)
+	case TypeUUID:
+		a, b := v.data.(UUID), other.data.(UUID)
+		return bytes.Compare(a[:], b[:])
+	default:
+		return 0
+	}
+}
+
+// Equal reports whether two values are equal under Compare. Unlike the SQL
+// = operator, two NULLs are considered equal.
+func (v Value) Equal(other Value) bool {
+	return v.Compare(other) == 0
+}
+
+func (v Value) isNumeric() bool {
+	return v.Type.IsNumeric()
+}
+
+func (v Value) numericFloat() float64 {
+	switch {
+	case v.Type == TypeUint64:
+		return float64(v.data.(uint64))
+	case v.Type.IsInteger():
+		return float64(v.data.(int64))
+	case v.Type == TypeDecimal:
+		return v.data.(Decimal).Float64()
+	}
+	f, _ := v.AsFloat64()
+	return f
+}
+
+// exactDecimal returns an integer or DECIMAL value as a Decimal.
+func (v Value) exactDecimal() Decimal {
+	if n, ok := v.AsBigInt(); ok {
+		return Decimal{coef: n}
+	}
+	return v.data.(Decimal)
+}
+
+func compareOrdered[T int64 | DataType](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareFloat orders NaN after every other number so that sorting is stable.
+func compareFloat(a, b float64) int {
+	aNaN, bNaN := math.IsNaN(a), math.IsNaN(b)
+	switch {
+	case aNaN && bNaN:
+		return 0
+	case aNaN:
+		return 1
+	case bNaN:
+		return -1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// HashValues returns a hash of a tuple of values that is consistent with
+// Equal: tuples whose values are pairwise equal, and whose strings have
+// the same collations, hash to the same value.
+func HashValues(values []Value) uint64 {
+	h := fnv.New64a()
+	var buf [17]byte
+	for _, v := range values {
+		switch {
+		case v.IsNull:
+			buf[0] = byte(TypeNull)
+			_, _ = h.Write(buf[:1])
+		case v.isNumeric():
+			f := v.numericFloat()
+			if f == 0 {
+				f = 0 // fold -0 into +0
+			}
+			buf[0] = byte(TypeFloat64)
+			binary.LittleEndian.PutUint64(buf[1:], math.Float64bits(f))
+			if math.IsNaN(f) {
+				binary.LittleEndian.PutUint64(buf[1:], 0x7ff8000000000001)
+			}
+			_, _ = h.Write(buf[:9])
+		case v.Type == TypeBool:
+			buf[0] = byte(TypeBool)
+			buf[1] = 0
+			if v.data.(bool) {
+				buf[1] = 1
+			}
+			_, _ = h.Write(buf[:2])
+		case v.Type == TypeString || v.Type == TypeJSON:
+			s := v.data.(string)
+			if v.collation != CollationBinary {
+				s = string(v.collation.key(s))
+			}
+			buf[0] = byte(v.Type)
+			binary.LittleEndian.PutUint64(buf[1:], uint64(len(s)))
+			_, _ = h.Write(buf[:9])
+			_, _ = h.Write([]byte(s))
+		case v.Type == TypeBytes:
+			b := v.data.([]byte)
+			buf[0] = byte(TypeBytes)
+			binary.LittleEndian.PutUint64(buf[1:], uint64(len(b)))
+			_, _ = h.Write(buf[:9])
+			_, _ = h.Write(b)
+		case v.IsTemporal():
+			buf[0] = byte(TypeTimestamp)
+			binary.LittleEndian.PutUint64(buf[1:], uint64(v.temporalMicros()))
+			_, _ = h.Write(buf[:9])
+		case v.Type == TypeInterval:
+			days, micros := v.data.(Interval).span()
+			buf[0] = byte(TypeInterval)
+			binary.LittleEndian.PutUint64(buf[1:], uint64(days))
+			binary.LittleEndian.PutUint64(buf[9:], uint64(micros))
+			_, _ = h.Write(buf[:17])
+		case v.Type == TypeEnum:
+			e := v.data.(enumValue)
+			buf[0] = byte(TypeEnum)
+			binary.LittleEndian.PutUint64(buf[1:], uint64(e.code))
+			_, _ = h.Write(buf[:9])
+			_, _ = h.Write([]byte(e.typ.Name))
+		case v.Type == TypeUUID:
+			u := v.data.(UUID)
+			buf[0] = byte(TypeUUID)
+			copy(buf[1:], u[:])
+			_, _ = h.Write(buf[:17])
+		case v.Type.IsNested():
+			buf[0] = byte(v.Type)
+			binary.LittleEndian.PutUint64(buf[1:], HashValues(nestedValues(v)))
+			_, _ = h.Write(buf[:9])
+		default:
+			buf[0] = byte(v.Type)
+			_, _ = h.Write(buf[:1])
+		}
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// UUID is a 128-bit universally unique identifier.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in the standard form
+// a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11, in either case, with or without
+// the hyphens and optionally in braces.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	text := strings.TrimSpace(s)
+	if strings.HasPrefix(text, "{") && strings.HasSuffix(text, "}") {
+		text = text[1 : len(text)-1]
+	}
+	if len(text) == 36 {
+		for _, i := range []int{8, 13, 18, 23} {
+			if text[i] != '-' {
+				return u, fmt.Errorf("invalid UUID %q", s)
+			}
+		}
+		text = strings.ReplaceAll(text, "-", "")
+	}
+	if len(text) != 32 {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(text)); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// String formats the UUID in lower case with hyphens.
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// NewUUIDValue creates a UUID value.
+func NewUUIDValue(u UUID) Value {
+	return Value{Type: TypeUUID, data: u}
+}
+
+// AsUUID returns a UUID value.
+func (v Value) AsUUID() (UUID, bool) {
+	if v.Type != TypeUUID || v.IsNull {
+		return UUID{}, false
+	}
+	return v.data.(UUID), true
+}